@@ -0,0 +1,127 @@
+// Command breaches builds the breached password file the password policy loads from
+// password.breachesFile in config.yaml, without calling any external service at runtime.
+//
+// It reads the Pwned Passwords SHA-1 downloads of Have I Been Pwned, one HASH:COUNT per line,
+// or plain passwords, one per line, with -plain:
+//
+//	go run ./cmd/breaches -in pwned-passwords-sha1.txt -out breaches.bin -min-count 10
+package main
+
+import (
+	"bufio"
+	"crypto/sha1" // nolint:gosec
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/Salam4nder/identity/pkg/password"
+)
+
+func main() {
+	in := flag.String("in", "", "file of breached passwords, one per line")
+	out := flag.String("out", "breaches.bin", "file to write")
+	n := flag.Uint("n", 0, "expected number of passwords, counted from the input if 0")
+	fp := flag.Float64("fp", 0.001, "false positive rate")
+	minCount := flag.Int("min-count", 1, "skip hashes seen fewer times, to keep the file small")
+	plain := flag.Bool("plain", false, "read plain passwords instead of SHA-1 hashes")
+	flag.Parse()
+
+	if err := run(*in, *out, *n, *fp, *minCount, *plain); err != nil {
+		slog.Error("breaches: building file", "err", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out string, n uint, fp float64, minCount int, plain bool) error {
+	if in == "" {
+		return fmt.Errorf("breaches: -in is required")
+	}
+
+	if n == 0 {
+		var err error
+		if n, err = count(in); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(in)
+	if err != nil {
+		return fmt.Errorf("breaches: opening input, %w", err)
+	}
+	defer f.Close()
+
+	b := password.NewBreaches(max(n, 1), fp)
+	added, err := add(b, f, minCount, plain)
+	if err != nil {
+		return err
+	}
+
+	o, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("breaches: creating output, %w", err)
+	}
+	w := bufio.NewWriter(o)
+	if _, err = b.WriteTo(w); err != nil {
+		o.Close()
+		return fmt.Errorf("breaches: writing output, %w", err)
+	}
+	if err = w.Flush(); err != nil {
+		o.Close()
+		return fmt.Errorf("breaches: writing output, %w", err)
+	}
+	if err = o.Close(); err != nil {
+		return fmt.Errorf("breaches: closing output, %w", err)
+	}
+
+	slog.Info("breaches: wrote file", "path", out, "passwords", added)
+	return nil
+}
+
+// add the passwords read from r to b and returns how many were added.
+func add(b *password.Breaches, r io.Reader, minCount int, plain bool) (int, error) {
+	var added int
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if plain {
+			if scanner.Text() != "" {
+				b.Add(sha1.Sum(scanner.Bytes())) // nolint:gosec
+				added++
+			}
+			continue
+		}
+
+		sum, count, err := password.ParseBreachLine(scanner.Text())
+		if err != nil {
+			return added, fmt.Errorf("breaches: line %d, %w", line, err)
+		}
+		if count >= minCount {
+			b.Add(sum)
+			added++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return added, fmt.Errorf("breaches: reading input, %w", err)
+	}
+	return added, nil
+}
+
+// count the lines of the file at path.
+func count(path string) (uint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("breaches: opening input, %w", err)
+	}
+	defer f.Close()
+
+	var n uint
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		n++
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, fmt.Errorf("breaches: counting input, %w", err)
+	}
+	return n, nil
+}
@@ -66,7 +66,7 @@ func RegisterAndAuthenticate() error {
 		"refresh", authResp.GetRefreshToken(),
 	)
 
-	_, err = client.Validate(ctx, &gen.TokenRequest{
+	_, err = client.Validate(ctx, &gen.ValidateRequest{
 		Token: authResp.GetAccessToken(),
 	})
 	if err != nil {
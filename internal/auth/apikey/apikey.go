@@ -0,0 +1,185 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/apikey"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// Prefix starts every key, so that keys can be told apart from tokens and found by secret scanners.
+	Prefix = "idk_"
+	// TokenType is reported as the token type of keys on introspection.
+	TokenType = "api_key"
+
+	// keySize is the amount of random bytes in a key.
+	keySize = 32
+	// prefixLen is the length of the start of a key that is kept to tell keys apart.
+	prefixLen = len(Prefix) + 8
+	// lastUsedResolution limits how often the last use of a key is written.
+	lastUsedResolution = time.Minute
+)
+
+var (
+	tracer = otel.Tracer("apikey")
+
+	ErrInvalidKey   = errors.New("apikey: key does not exist or has expired")
+	ErrNotFound     = errors.New("apikey: key not found")
+	ErrInvalidInput = errors.New("apikey: invalid key")
+)
+
+// Owner is the user a key is created for.
+type Owner struct {
+	Strategy   gen.Strategy
+	Identifier string
+}
+
+// Keys creates and verifies long-lived API keys of users.
+// Only the SHA-256 hash of a key is stored, the key itself is returned once on creation.
+type Keys struct {
+	db  *sql.DB
+	cfg config.APIKeys
+}
+
+// NewKeys returns a new [Keys].
+func NewKeys(db *sql.DB, cfg config.APIKeys) *Keys {
+	return &Keys{db: db, cfg: cfg}
+}
+
+// IsKey reports whether s looks like an API key rather than a token.
+func IsKey(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+// Create a key for the owner with the given scopes, which must be a subset of allowed.
+// A zero lifetime uses the configured default. Returns the key, which is not returned again, and its entry.
+// Possible errors are [ErrInvalidInput] and a wrapped error indicating an internal error.
+func (x *Keys) Create(
+	ctx context.Context,
+	owner Owner,
+	name string,
+	scopes, allowed []string,
+	ttl time.Duration,
+) (string, *apikey.Key, error) {
+	ctx, span := tracer.Start(ctx, "Create")
+	defer span.End()
+	span.SetAttributes(attribute.String("strategy", owner.Strategy.String()))
+
+	if name == "" {
+		return "", nil, fmt.Errorf("%w, name is empty", ErrInvalidInput)
+	}
+	for _, s := range scopes {
+		if !slices.Contains(allowed, s) {
+			return "", nil, fmt.Errorf("%w, scope %s is not granted to the token", ErrInvalidInput, s)
+		}
+	}
+	if ttl == 0 {
+		ttl = x.cfg.DefaultTTL
+	}
+	if ttl <= 0 || ttl > x.cfg.MaxTTL {
+		return "", nil, fmt.Errorf("%w, lifetime must be positive and at most %s", ErrInvalidInput, x.cfg.MaxTTL)
+	}
+
+	secret, err := random.Token(keySize)
+	if err != nil {
+		return "", nil, fmt.Errorf("apikey: generating key, %w", err)
+	}
+	key := Prefix + secret
+
+	now := time.Now()
+	k := apikey.Key{
+		ID:         uuid.New(),
+		KeyHash:    apikey.HashKey(key),
+		Prefix:     key[:prefixLen],
+		Name:       name,
+		Strategy:   int32(owner.Strategy),
+		Identifier: owner.Identifier,
+		Scopes:     scopes,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	if err = apikey.Insert(ctx, x.db, k); err != nil {
+		return "", nil, fmt.Errorf("apikey: inserting key, %w", err)
+	}
+
+	return key, &k, nil
+}
+
+// List the keys of an owner, newest first, including expired ones.
+func (x *Keys) List(ctx context.Context, owner Owner) ([]apikey.Key, error) {
+	ctx, span := tracer.Start(ctx, "List")
+	defer span.End()
+
+	keys, err := apikey.List(ctx, x.db, int32(owner.Strategy), owner.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("apikey: listing keys, %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke deletes a key of the owner, it can not be used from then on.
+// Possible errors are [ErrNotFound] and a wrapped error indicating an internal error.
+func (x *Keys) Revoke(ctx context.Context, owner Owner, id string) error {
+	ctx, span := tracer.Start(ctx, "Revoke")
+	defer span.End()
+	span.SetAttributes(attribute.String("id", id))
+
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	if err = apikey.Delete(ctx, x.db, parsed, int32(owner.Strategy), owner.Identifier); err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("apikey: deleting key, %w", err)
+	}
+	return nil
+}
+
+// Verify returns the entry of a key that has not expired and records its use.
+// Possible errors are [ErrInvalidKey] and a wrapped error indicating an internal error.
+func (x *Keys) Verify(ctx context.Context, key string) (*apikey.Key, error) {
+	ctx, span := tracer.Start(ctx, "Verify")
+	defer span.End()
+
+	if !IsKey(key) {
+		return nil, ErrInvalidKey
+	}
+	k, err := apikey.ReadByHash(ctx, x.db, apikey.HashKey(key))
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return nil, ErrInvalidKey
+		}
+		return nil, fmt.Errorf("apikey: reading key, %w", err)
+	}
+	span.SetAttributes(attribute.String("id", k.ID.String()))
+
+	now := time.Now()
+	if !now.Before(k.ExpiresAt) {
+		return nil, ErrInvalidKey
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= lastUsedResolution {
+		// A failed write must not fail the request, the last use is informational.
+		if err = apikey.UpdateLastUsed(ctx, x.db, k.ID, now); err != nil {
+			slog.ErrorContext(ctx, "apikey: updating last use", "err", err)
+		} else {
+			k.LastUsedAt = &now
+		}
+	}
+	return k, nil
+}
@@ -0,0 +1,78 @@
+//go:build testdb
+// +build testdb
+
+package apikey_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth/apikey"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	apikeydb "github.com/Salam4nder/identity/internal/database/apikey"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(apikeydb.Tablename)
+	t.Cleanup(cleanup)
+
+	keys := apikey.NewKeys(db, config.APIKeys{DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour})
+	owner := apikey.Owner{Strategy: gen.Strategy_TypeCredentials, Identifier: random.Email()}
+	allowed := []string{"profile", "email"}
+
+	t.Run("OK", func(t *testing.T) {
+		key, k, err := keys.Create(ctx, owner, "ci", []string{"profile"}, allowed, 0)
+		require.NoError(t, err)
+		require.True(t, apikey.IsKey(key))
+		require.True(t, strings.HasPrefix(key, k.Prefix))
+		require.WithinDuration(t, time.Now().Add(time.Hour), k.ExpiresAt, time.Minute)
+
+		got, err := keys.Verify(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, k.ID, got.ID)
+		require.Equal(t, []string{"profile"}, got.Scopes)
+		require.NotNil(t, got.LastUsedAt)
+	})
+
+	t.Run("scopes must be allowed", func(t *testing.T) {
+		_, _, err := keys.Create(ctx, owner, "ci", []string{"admin"}, allowed, 0)
+		require.ErrorIs(t, err, apikey.ErrInvalidInput)
+	})
+
+	t.Run("lifetime is capped", func(t *testing.T) {
+		_, _, err := keys.Create(ctx, owner, "ci", nil, allowed, 48*time.Hour)
+		require.ErrorIs(t, err, apikey.ErrInvalidInput)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := keys.Verify(ctx, apikey.Prefix+random.String(43))
+		require.ErrorIs(t, err, apikey.ErrInvalidKey)
+
+		_, err = keys.Verify(ctx, random.String(43))
+		require.ErrorIs(t, err, apikey.ErrInvalidKey)
+	})
+
+	t.Run("list and revoke", func(t *testing.T) {
+		other := apikey.Owner{Strategy: gen.Strategy_TypePersonalNumber, Identifier: "42"}
+		key, k, err := keys.Create(ctx, other, "script", nil, nil, time.Minute)
+		require.NoError(t, err)
+
+		listed, err := keys.List(ctx, other)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		require.Equal(t, k.ID, listed[0].ID)
+
+		require.ErrorIs(t, keys.Revoke(ctx, owner, k.ID.String()), apikey.ErrNotFound)
+		require.NoError(t, keys.Revoke(ctx, other, k.ID.String()))
+
+		_, err = keys.Verify(ctx, key)
+		require.ErrorIs(t, err, apikey.ErrInvalidKey)
+	})
+}
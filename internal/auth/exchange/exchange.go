@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Salam4nder/identity/internal/config"
+)
+
+const (
+	// TokenTypeAccessToken is the RFC 8693 type of exchanged and issued tokens.
+	// nolint:gosec
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+	// ActorKey is the claim naming the client acting on behalf of the user.
+	ActorKey = "act"
+)
+
+var (
+	ErrUnknownClient      = errors.New("exchange: unknown client or incorrect secret")
+	ErrAudienceNotAllowed = errors.New("exchange: audience is not allowed for client")
+	ErrScopeNotAllowed    = errors.New("exchange: scope is not allowed")
+)
+
+// Decision is what an exchanged token is issued for.
+type Decision struct {
+	Scopes   []string
+	Audience string
+	// Actor is the act claim to add, nil unless the policy is a delegation.
+	Actor map[string]any
+}
+
+// Policies decide which tokens clients can exchange, per client and audience.
+type Policies struct {
+	clients map[string]config.ExchangeClient
+}
+
+// NewPolicies returns new [Policies]. Returns an error on clients without an ID or secret.
+func NewPolicies(cfg config.TokenExchange) (*Policies, error) {
+	clients := make(map[string]config.ExchangeClient, len(cfg.Clients))
+	for _, c := range cfg.Clients {
+		if c.ID == "" || c.Secret == "" {
+			return nil, errors.New("exchange: client without ID or secret")
+		}
+		clients[c.ID] = c
+	}
+	return &Policies{clients: clients}, nil
+}
+
+// Decide authenticates the client and decides what the exchanged token is issued for.
+// Requested scopes must be allowed by the policy of the audience and carried by the subject token,
+// none requested grants all that are. Possible errors are [ErrUnknownClient], [ErrAudienceNotAllowed]
+// and [ErrScopeNotAllowed].
+//
+// The actor of a subject token that was exchanged before is nested in the new one, see RFC 8693 section 4.1.
+func (x *Policies) Decide(
+	clientID, secret, audience string,
+	requested, subjectScopes []string,
+	subjectActor map[string]any,
+) (Decision, error) {
+	c, ok := x.clients[clientID]
+	if !ok || subtle.ConstantTimeCompare([]byte(c.Secret), []byte(secret)) != 1 {
+		return Decision{}, ErrUnknownClient
+	}
+
+	i := slices.IndexFunc(c.Policies, func(p config.ExchangePolicy) bool { return p.Audience == audience })
+	if audience == "" || i < 0 {
+		return Decision{}, fmt.Errorf("%w, %q", ErrAudienceNotAllowed, audience)
+	}
+	p := c.Policies[i]
+
+	var scopes []string
+	if len(requested) == 0 {
+		for _, s := range p.Scopes {
+			if slices.Contains(subjectScopes, s) {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	for _, s := range requested {
+		if !slices.Contains(p.Scopes, s) || !slices.Contains(subjectScopes, s) {
+			return Decision{}, fmt.Errorf("%w, %s", ErrScopeNotAllowed, s)
+		}
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+
+	d := Decision{Scopes: scopes, Audience: audience}
+	if p.Delegation {
+		d.Actor = map[string]any{"sub": clientID}
+		if subjectActor != nil {
+			d.Actor[ActorKey] = subjectActor
+		}
+	}
+	return d, nil
+}
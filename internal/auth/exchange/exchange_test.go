@@ -0,0 +1,72 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecide(t *testing.T) {
+	p, err := NewPolicies(config.TokenExchange{Clients: []config.ExchangeClient{{
+		ID:     "orders",
+		Secret: "secret",
+		Policies: []config.ExchangePolicy{
+			{Audience: "billing", Scopes: []string{"profile", "email"}, Delegation: true},
+			{Audience: "shipping", Scopes: []string{"profile"}},
+		},
+	}}})
+	require.NoError(t, err)
+
+	t.Run("delegation", func(t *testing.T) {
+		d, err := p.Decide("orders", "secret", "billing", []string{"profile"}, []string{"profile", "email"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"profile"}, d.Scopes)
+		require.Equal(t, "billing", d.Audience)
+		require.Equal(t, map[string]any{"sub": "orders"}, d.Actor)
+	})
+
+	t.Run("nests previous actor", func(t *testing.T) {
+		previous := map[string]any{"sub": "gateway"}
+		d, err := p.Decide("orders", "secret", "billing", nil, []string{"profile"}, previous)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"sub": "orders", "act": previous}, d.Actor)
+	})
+
+	t.Run("impersonation narrows to subject scopes by default", func(t *testing.T) {
+		d, err := p.Decide("orders", "secret", "shipping", nil, []string{"profile", "email"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"profile"}, d.Scopes)
+		require.Nil(t, d.Actor)
+	})
+
+	t.Run("incorrect secret", func(t *testing.T) {
+		_, err := p.Decide("orders", "guess", "billing", nil, nil, nil)
+		require.ErrorIs(t, err, ErrUnknownClient)
+	})
+
+	t.Run("unknown client", func(t *testing.T) {
+		_, err := p.Decide("payroll", "secret", "billing", nil, nil, nil)
+		require.ErrorIs(t, err, ErrUnknownClient)
+	})
+
+	t.Run("audience without policy", func(t *testing.T) {
+		_, err := p.Decide("orders", "secret", "payroll", nil, nil, nil)
+		require.ErrorIs(t, err, ErrAudienceNotAllowed)
+	})
+
+	t.Run("scope not allowed by policy", func(t *testing.T) {
+		_, err := p.Decide("orders", "secret", "shipping", []string{"email"}, []string{"email"}, nil)
+		require.ErrorIs(t, err, ErrScopeNotAllowed)
+	})
+
+	t.Run("scope the subject token does not carry", func(t *testing.T) {
+		_, err := p.Decide("orders", "secret", "billing", []string{"email"}, []string{"profile"}, nil)
+		require.ErrorIs(t, err, ErrScopeNotAllowed)
+	})
+
+	t.Run("client without secret", func(t *testing.T) {
+		_, err := NewPolicies(config.TokenExchange{Clients: []config.ExchangeClient{{ID: "orders"}}})
+		require.Error(t, err)
+	})
+}
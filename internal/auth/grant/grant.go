@@ -0,0 +1,95 @@
+package grant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+)
+
+var (
+	ErrScopeNotAllowed    = errors.New("grant: scope is not allowed")
+	ErrAudienceNotAllowed = errors.New("grant: audience is not allowed")
+)
+
+// Request is what an authenticated user asks tokens to be issued for.
+type Request struct {
+	Identifier any
+	Strategy   gen.Strategy
+	Scopes     []string
+	Audience   string
+	// Allowed scopes replace the ones allowed for the strategy if not nil, such as the scopes of a client.
+	Allowed []string
+}
+
+// Policy decides which scopes and audience a user may receive.
+type Policy interface {
+	// Decide returns the grant of a request, or [ErrScopeNotAllowed] or [ErrAudienceNotAllowed]
+	// if the user may not receive what they asked for.
+	Decide(ctx context.Context, r Request) (token.Grant, error)
+}
+
+// Enricher adds custom claims to the tokens of a user. It is called on every issue and refresh
+// of an access token, after the [Policy] decided the grant, with the granted scopes and audience.
+// Returned claims must not use the keys of the claims set by the service.
+type Enricher func(ctx context.Context, r Request) (map[string]any, error)
+
+var _ Policy = (*ConfigPolicy)(nil)
+
+// ConfigPolicy grants the scopes configured per strategy and the configured audiences.
+type ConfigPolicy struct {
+	defaults  []string
+	allowed   map[gen.Strategy][]string
+	audiences []string
+}
+
+// NewConfigPolicy returns a new [ConfigPolicy].
+// Returns an error if a strategy of the allowed scopes fails to parse.
+func NewConfigPolicy(cfg config.Scopes) (*ConfigPolicy, error) {
+	allowed := make(map[gen.Strategy][]string, len(cfg.Allowed))
+	for k, v := range cfg.Allowed {
+		strategy, err := auth.StrategyFromString(k)
+		if err != nil {
+			return nil, fmt.Errorf("grant: allowed scopes of %s, %w", k, err)
+		}
+		allowed[strategy] = v
+	}
+	return &ConfigPolicy{defaults: cfg.Default, allowed: allowed, audiences: cfg.Audiences}, nil
+}
+
+// Decide grants the requested scopes if they are all allowed for the strategy,
+// or the allowed default scopes if none are requested.
+func (x *ConfigPolicy) Decide(_ context.Context, r Request) (token.Grant, error) {
+	allowed := x.allowed[r.Strategy]
+	if r.Allowed != nil {
+		allowed = r.Allowed
+	}
+
+	var scopes []string
+	if len(r.Scopes) == 0 {
+		for _, s := range x.defaults {
+			if slices.Contains(allowed, s) {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	for _, s := range r.Scopes {
+		if !slices.Contains(allowed, s) {
+			return token.Grant{}, fmt.Errorf("%w, %s", ErrScopeNotAllowed, s)
+		}
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+
+	if r.Audience != "" && !slices.Contains(x.audiences, r.Audience) {
+		return token.Grant{}, fmt.Errorf("%w, %s", ErrAudienceNotAllowed, r.Audience)
+	}
+
+	return token.Grant{Scopes: scopes, Audience: r.Audience}, nil
+}
@@ -0,0 +1,84 @@
+package grant
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/Salam4nder/identity/internal/auth/strategy"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigPolicy(t *testing.T) {
+	ctx := context.Background()
+	p, err := NewConfigPolicy(config.Scopes{
+		Default: []string{"profile", "admin"},
+		Allowed: map[string][]string{
+			"credentials":     {"profile", "email"},
+			"personal_number": {"profile"},
+		},
+		Audiences: []string{"billing"},
+	})
+	require.NoError(t, err)
+
+	t.Run("requested scopes", func(t *testing.T) {
+		g, err := p.Decide(ctx, Request{
+			Strategy: gen.Strategy_TypeCredentials,
+			Scopes:   []string{"email", "profile", "email"},
+			Audience: "billing",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"email", "profile"}, g.Scopes)
+		require.Equal(t, "billing", g.Audience)
+	})
+
+	t.Run("allowed defaults when none are requested", func(t *testing.T) {
+		g, err := p.Decide(ctx, Request{Strategy: gen.Strategy_TypePersonalNumber})
+		require.NoError(t, err)
+		require.Equal(t, []string{"profile"}, g.Scopes)
+		require.Empty(t, g.Audience)
+	})
+
+	t.Run("scope not allowed for strategy", func(t *testing.T) {
+		_, err := p.Decide(ctx, Request{
+			Strategy: gen.Strategy_TypePersonalNumber,
+			Scopes:   []string{"email"},
+		})
+		require.ErrorIs(t, err, ErrScopeNotAllowed)
+	})
+
+	t.Run("unconfigured strategy gets no scopes", func(t *testing.T) {
+		g, err := p.Decide(ctx, Request{Strategy: gen.Strategy_TypeWebAuthn})
+		require.NoError(t, err)
+		require.Empty(t, g.Scopes)
+	})
+
+	t.Run("allowed scopes of the request replace the strategy's", func(t *testing.T) {
+		r := Request{
+			Strategy: gen.Strategy_TypeClientCredentials,
+			Scopes:   []string{"reports:read"},
+			Allowed:  []string{"reports:read", "reports:write"},
+		}
+		g, err := p.Decide(ctx, r)
+		require.NoError(t, err)
+		require.Equal(t, []string{"reports:read"}, g.Scopes)
+
+		r.Scopes = []string{"profile"}
+		_, err = p.Decide(ctx, r)
+		require.ErrorIs(t, err, ErrScopeNotAllowed)
+	})
+
+	t.Run("audience not allowed", func(t *testing.T) {
+		_, err := p.Decide(ctx, Request{
+			Strategy: gen.Strategy_TypeCredentials,
+			Audience: "payroll",
+		})
+		require.ErrorIs(t, err, ErrAudienceNotAllowed)
+	})
+
+	t.Run("unknown strategy fails to parse", func(t *testing.T) {
+		_, err := NewConfigPolicy(config.Scopes{Allowed: map[string][]string{"carrier_pigeon": {"profile"}}})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,77 @@
+//go:build testdb
+// +build testdb
+
+package lockout_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/loginattempt"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(loginattempt.Tablename)
+	t.Cleanup(cleanup)
+
+	g := lockout.New(db, lockout.Policy{
+		Window:    time.Hour,
+		BaseDelay: time.Minute,
+		MaxDelay:  time.Hour,
+		Account:   lockout.Thresholds{DelayAfter: 2, LockAfter: 3, Duration: time.Hour},
+		IP:        lockout.Thresholds{LockAfter: 5, Duration: time.Hour},
+	})
+
+	t.Run("delays and locks the account", func(t *testing.T) {
+		account := random.Email()
+		ip := "10.0.0.1:" + random.String(4)
+
+		until, err := g.Fail(ctx, account, ip)
+		require.NoError(t, err)
+		require.Zero(t, until)
+		require.NoError(t, g.Check(ctx, account, ip))
+
+		until, err = g.Fail(ctx, account, ip)
+		require.NoError(t, err)
+		require.Zero(t, until)
+
+		var locked *lockout.LockedError
+		require.ErrorAs(t, g.Check(ctx, account, ip), &locked)
+		require.Equal(t, lockout.ScopeAccount, locked.Scope)
+		require.InDelta(t, time.Minute, locked.RetryAfter, float64(time.Second))
+
+		until, err = g.Fail(ctx, account, ip)
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(time.Hour), until, time.Minute)
+	})
+
+	t.Run("success forgets the account failures", func(t *testing.T) {
+		account := random.Email()
+		for range 2 {
+			_, err := g.Fail(ctx, account, "")
+			require.NoError(t, err)
+		}
+		require.Error(t, g.Check(ctx, account, ""))
+
+		require.NoError(t, g.Succeed(ctx, account))
+		require.NoError(t, g.Check(ctx, account, ""))
+	})
+
+	t.Run("locks the client IP across accounts", func(t *testing.T) {
+		ip := "10.0.1." + random.String(3)
+		for range 5 {
+			_, err := g.Fail(ctx, random.Email(), ip)
+			require.NoError(t, err)
+		}
+
+		var locked *lockout.LockedError
+		require.ErrorAs(t, g.Check(ctx, random.Email(), ip), &locked)
+		require.Equal(t, lockout.ScopeIP, locked.Scope)
+	})
+}
@@ -0,0 +1,188 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/loginattempt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("lockout")
+
+// Scopes failed logins are counted in.
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+)
+
+// Thresholds decide when failed logins within a scope block further attempts.
+type Thresholds struct {
+	// DelayAfter consecutive failures, attempts are blocked for a delay that doubles with every further failure.
+	// Zero disables delays.
+	DelayAfter int
+	// LockAfter consecutive failures, attempts are blocked for Duration. Zero disables lockouts.
+	LockAfter int
+	Duration  time.Duration
+}
+
+// Policy decides how long failed logins block further attempts.
+type Policy struct {
+	// Window after which failures are forgotten.
+	Window time.Duration
+	// BaseDelay is the first delay, MaxDelay caps the delays.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Account   Thresholds
+	IP        Thresholds
+}
+
+// Block returns how long attempts are blocked after the given consecutive failures
+// and whether that is a lockout rather than a delay.
+func (x Policy) Block(t Thresholds, failures int) (time.Duration, bool) {
+	if t.LockAfter > 0 && failures >= t.LockAfter {
+		return t.Duration, true
+	}
+	if t.DelayAfter > 0 && failures >= t.DelayAfter {
+		// Stop shifting before the delay overflows.
+		shift := min(failures-t.DelayAfter, 32)
+		d := x.BaseDelay << shift
+		if d <= 0 || d > x.MaxDelay {
+			d = x.MaxDelay
+		}
+		return d, false
+	}
+	return 0, false
+}
+
+// LockedError is returned for attempts of an account or a client IP that is blocked.
+type LockedError struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("lockout: too many failed logins of %s, retry after %s", e.Scope, e.RetryAfter)
+}
+
+// Guard counts failed logins per account and per client IP and blocks further attempts as its [Policy] decides.
+// Failures are persisted so that every instance blocks them.
+type Guard struct {
+	db     *sql.DB
+	policy Policy
+}
+
+// New returns a new [Guard].
+func New(db *sql.DB, policy Policy) *Guard {
+	return &Guard{db: db, policy: policy}
+}
+
+// FromConfig returns a [Guard] with the configured policy, nil if lockouts are disabled.
+func FromConfig(db *sql.DB, c config.Lockout) *Guard {
+	if !c.Enabled {
+		return nil
+	}
+	return New(db, Policy{
+		Window:    c.Window,
+		BaseDelay: c.BaseDelay,
+		MaxDelay:  c.MaxDelay,
+		Account:   Thresholds(c.Account),
+		IP:        Thresholds(c.IP),
+	})
+}
+
+// Check returns a [LockedError] if the account or the client IP is blocked,
+// any other error indicates an internal error. An empty IP is not checked.
+func (x *Guard) Check(ctx context.Context, account, ip string) error {
+	ctx, span := tracer.Start(ctx, "Check")
+	defer span.End()
+	span.SetAttributes(attribute.String("ip", ip))
+
+	now := time.Now()
+	for _, k := range keys(account, ip) {
+		e, err := loginattempt.Read(ctx, x.db, k.scope, k.key)
+		if err != nil {
+			if errors.As(err, &database.NotFoundError{}) {
+				continue
+			}
+			return fmt.Errorf("lockout: reading login attempts, %w", err)
+		}
+		if e.LockedUntil != nil && e.LockedUntil.After(now) {
+			return &LockedError{Scope: k.scope, RetryAfter: e.LockedUntil.Sub(now).Round(time.Second)}
+		}
+	}
+	return nil
+}
+
+// Fail records a failed login of the account from the client IP and blocks further attempts
+// if a threshold is reached. Returns the time the account is locked until if this failure locked it,
+// the zero time otherwise. An empty IP is not counted.
+func (x *Guard) Fail(ctx context.Context, account, ip string) (time.Time, error) {
+	ctx, span := tracer.Start(ctx, "Fail")
+	defer span.End()
+	span.SetAttributes(attribute.String("ip", ip))
+
+	var lockedUntil time.Time
+	now := time.Now()
+	for _, k := range keys(account, ip) {
+		failures, err := loginattempt.Fail(ctx, x.db, k.scope, k.key, now, now.Add(-x.policy.Window))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("lockout: recording failed login, %w", err)
+		}
+
+		t := x.policy.Account
+		if k.scope == ScopeIP {
+			t = x.policy.IP
+		}
+		d, locked := x.policy.Block(t, failures)
+		if d <= 0 {
+			continue
+		}
+		if err = loginattempt.Lock(ctx, x.db, k.scope, k.key, now.Add(d)); err != nil {
+			return time.Time{}, fmt.Errorf("lockout: blocking attempts, %w", err)
+		}
+		if locked && k.scope == ScopeAccount {
+			lockedUntil = now.Add(d)
+		}
+	}
+	return lockedUntil, nil
+}
+
+// Succeed forgets the failed logins of the account. The ones of the client IP are kept,
+// so that signing in to an account does not allow guessing the passwords of others.
+func (x *Guard) Succeed(ctx context.Context, account string) error {
+	ctx, span := tracer.Start(ctx, "Succeed")
+	defer span.End()
+
+	if err := loginattempt.Delete(ctx, x.db, ScopeAccount, account); err != nil {
+		return fmt.Errorf("lockout: forgetting failed logins, %w", err)
+	}
+	return nil
+}
+
+type key struct {
+	scope, key string
+}
+
+func keys(account, ip string) []key {
+	k := []key{{scope: ScopeAccount, key: account}}
+	if ip != "" {
+		k = append(k, key{scope: ScopeIP, key: host(ip)})
+	}
+	return k
+}
+
+// host strips the port of a peer address, so that every connection of a client counts the same.
+func host(addr string) string {
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		return h
+	}
+	return addr
+}
@@ -0,0 +1,60 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlock(t *testing.T) {
+	p := Policy{
+		BaseDelay: time.Second,
+		MaxDelay:  time.Minute,
+		Account:   Thresholds{DelayAfter: 3, LockAfter: 10, Duration: 15 * time.Minute},
+	}
+
+	t.Run("no delay below the threshold", func(t *testing.T) {
+		d, locked := p.Block(p.Account, 2)
+		require.Zero(t, d)
+		require.False(t, locked)
+	})
+
+	t.Run("delays double", func(t *testing.T) {
+		for failures, want := range map[int]time.Duration{3: time.Second, 4: 2 * time.Second, 6: 8 * time.Second} {
+			d, locked := p.Block(p.Account, failures)
+			require.Equal(t, want, d)
+			require.False(t, locked)
+		}
+	})
+
+	t.Run("delays are capped", func(t *testing.T) {
+		d, _ := p.Block(p.Account, 9)
+		require.Equal(t, time.Minute, d)
+
+		d, _ = p.Block(Thresholds{DelayAfter: 1}, 200)
+		require.Equal(t, time.Minute, d)
+	})
+
+	t.Run("locks at the threshold", func(t *testing.T) {
+		d, locked := p.Block(p.Account, 10)
+		require.Equal(t, 15*time.Minute, d)
+		require.True(t, locked)
+	})
+
+	t.Run("disabled thresholds", func(t *testing.T) {
+		d, locked := p.Block(Thresholds{}, 100)
+		require.Zero(t, d)
+		require.False(t, locked)
+	})
+}
+
+func TestKeys(t *testing.T) {
+	require.Equal(t, []key{{ScopeAccount, "jane@example.com"}}, keys("jane@example.com", ""))
+	require.Equal(t,
+		[]key{{ScopeAccount, "jane@example.com"}, {ScopeIP, "10.0.0.1"}},
+		keys("jane@example.com", "10.0.0.1:52314"),
+	)
+	require.Equal(t, "::1", host("[::1]:52314"))
+	require.Equal(t, "10.0.0.1", host("10.0.0.1"))
+}
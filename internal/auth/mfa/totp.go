@@ -0,0 +1,214 @@
+package mfa
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/credentials"
+	totpdb "github.com/Salam4nder/identity/internal/database/totp"
+	"github.com/Salam4nder/identity/pkg/encryption"
+	pkggrpc "github.com/Salam4nder/identity/pkg/grpc"
+	"github.com/Salam4nder/identity/pkg/totp"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	tracer = otel.Tracer("mfa")
+
+	ErrNotEnrolled     = errors.New("mfa: totp is not enrolled")
+	ErrAlreadyEnrolled = errors.New("mfa: totp is already enrolled")
+	ErrInvalidCode     = errors.New("mfa: invalid code")
+)
+
+// TOTP handles enrollment and verification of RFC 6238 time-based one-time
+// passwords as a second factor for the credentials strategy.
+// Secrets are encrypted with key before they are stored.
+type TOTP struct {
+	db      *sql.DB
+	key     []byte
+	lockout *lockout.Guard
+}
+
+// NewTOTP returns a new [TOTP]. The key must be [encryption.KeySize] bytes.
+func NewTOTP(db *sql.DB, key []byte) (*TOTP, error) {
+	if len(key) != encryption.KeySize {
+		return nil, encryption.ErrInvalidKey
+	}
+	return &TOTP{db: db, key: key}, nil
+}
+
+// UseLockout counts invalid codes as failed logins of the user with guard, which blocks further
+// attempts of the account and the client IP as it does for incorrect passwords. A valid code
+// forgets the failed logins of the account, which a correct password alone does not.
+func (x *TOTP) UseLockout(guard *lockout.Guard) {
+	x.lockout = guard
+}
+
+// Enroll generates a new secret for the user with the given email and returns it
+// together with an otpauth:// URI. The secret is not used for logins
+// until it is confirmed with [TOTP.Confirm].
+// Returns [ErrAlreadyEnrolled] if the user has a confirmed secret.
+func (x *TOTP) Enroll(ctx context.Context, email string) (secret, uri string, err error) {
+	ctx, span := tracer.Start(ctx, "Enroll")
+	defer span.End()
+	span.SetAttributes(attribute.String("email", email))
+
+	c, err := credentials.ReadByEmail(ctx, x.db, email)
+	if err != nil {
+		return "", "", fmt.Errorf("mfa: reading credentials, %w", err)
+	}
+
+	secret, err = totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := encryption.Seal(x.key, []byte(secret))
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = totpdb.Upsert(ctx, x.db, c.ID, sealed); err != nil {
+		if errors.As(err, &database.RowsAffectedError{}) {
+			return "", "", ErrAlreadyEnrolled
+		}
+		return "", "", fmt.Errorf("mfa: storing secret, %w", err)
+	}
+
+	return secret, totp.URI(config.ApplicationName, email, secret), nil
+}
+
+// Confirm enables a pending enrollment once the user proves
+// they have set up their authenticator by submitting a valid code.
+func (x *TOTP) Confirm(ctx context.Context, email, code string) error {
+	ctx, span := tracer.Start(ctx, "Confirm")
+	defer span.End()
+	span.SetAttributes(attribute.String("email", email))
+
+	id, entry, err := x.read(ctx, email)
+	if err != nil {
+		return err
+	}
+	if entry.ConfirmedAt != nil {
+		return ErrAlreadyEnrolled
+	}
+
+	counter, err := x.validate(entry, code)
+	if err != nil {
+		return err
+	}
+
+	if err = totpdb.Confirm(ctx, x.db, id, counter); err != nil {
+		return fmt.Errorf("mfa: confirming secret, %w", err)
+	}
+	return nil
+}
+
+// Enabled reports whether the user with the given email has a confirmed secret.
+func (x *TOTP) Enabled(ctx context.Context, email string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "Enabled")
+	defer span.End()
+	span.SetAttributes(attribute.String("email", email))
+
+	_, entry, err := x.read(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrNotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+	return entry.ConfirmedAt != nil, nil
+}
+
+// Verify checks a code during login. A code can only be used once.
+// Returns [ErrNotEnrolled] or [ErrInvalidCode] on failure, and a [lockout.LockedError]
+// if the account or the client IP is blocked with a lockout guard.
+func (x *TOTP) Verify(ctx context.Context, email, code string) error {
+	ctx, span := tracer.Start(ctx, "Verify")
+	defer span.End()
+	span.SetAttributes(attribute.String("email", email))
+
+	if x.lockout == nil {
+		return x.verify(ctx, email, code)
+	}
+
+	ip := pkggrpc.MetadataFromContext(ctx).ClientIP
+	if err := x.lockout.Check(ctx, email, ip); err != nil {
+		return err
+	}
+
+	// Errors of the guard are logged, so that the login succeeds or fails for its own reason.
+	err := x.verify(ctx, email, code)
+	switch {
+	case err == nil:
+		// The password was correct too, the failed logins of the account are forgotten only now.
+		if err := x.lockout.Succeed(ctx, email); err != nil {
+			slog.ErrorContext(ctx, "mfa: forgetting failed logins", "err", err)
+		}
+	case errors.Is(err, ErrInvalidCode):
+		if _, err := x.lockout.Fail(ctx, email, ip); err != nil {
+			slog.ErrorContext(ctx, "mfa: recording failed login", "err", err)
+		}
+	}
+	return err
+}
+
+func (x *TOTP) verify(ctx context.Context, email, code string) error {
+	id, entry, err := x.read(ctx, email)
+	if err != nil {
+		return err
+	}
+	if entry.ConfirmedAt == nil {
+		return ErrNotEnrolled
+	}
+
+	counter, err := x.validate(entry, code)
+	if err != nil {
+		return err
+	}
+
+	if err = totpdb.UpdateCounter(ctx, x.db, id, counter); err != nil {
+		if errors.As(err, &database.RowsAffectedError{}) {
+			return ErrInvalidCode
+		}
+		return fmt.Errorf("mfa: updating counter, %w", err)
+	}
+	return nil
+}
+
+func (x *TOTP) read(ctx context.Context, email string) (uuid.UUID, *totpdb.Entry, error) {
+	c, err := credentials.ReadByEmail(ctx, x.db, email)
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("mfa: reading credentials, %w", err)
+	}
+
+	entry, err := totpdb.Read(ctx, x.db, c.ID)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return uuid.Nil, nil, ErrNotEnrolled
+		}
+		return uuid.Nil, nil, fmt.Errorf("mfa: reading secret, %w", err)
+	}
+	return c.ID, entry, nil
+}
+
+func (x *TOTP) validate(entry *totpdb.Entry, code string) (uint64, error) {
+	secret, err := encryption.Open(x.key, entry.Secret)
+	if err != nil {
+		return 0, fmt.Errorf("mfa: decrypting secret, %w", err)
+	}
+
+	counter, ok := totp.Validate(string(secret), code, time.Now())
+	if !ok || counter <= entry.LastCounter {
+		return 0, ErrInvalidCode
+	}
+	return counter, nil
+}
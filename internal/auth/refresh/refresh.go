@@ -0,0 +1,178 @@
+package refresh
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/refreshtoken"
+	"github.com/Salam4nder/identity/internal/event"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	tracer = otel.Tracer("refresh")
+
+	ErrNotFound = errors.New("refresh: token does not exist or has expired")
+	ErrRevoked  = errors.New("refresh: token has been revoked")
+	ErrReused   = errors.New("refresh: token has already been used")
+)
+
+// Families persists refresh token families. Every refresh token can be used once
+// and is replaced by the next token of its family. Using a token twice revokes its whole family.
+type Families struct {
+	db       *sql.DB
+	natsConn *nats.Conn
+}
+
+// NewFamilies returns new [Families].
+func NewFamilies(db *sql.DB, natsConn *nats.Conn) *Families {
+	return &Families{db: db, natsConn: natsConn}
+}
+
+// Start persists the first refresh token of a new family.
+func (x *Families) Start(ctx context.Context, expiresAt time.Time) (token.RefreshID, error) {
+	ctx, span := tracer.Start(ctx, "Start")
+	defer span.End()
+
+	id := token.NewRefreshID()
+	span.SetAttributes(attribute.String("family", id.Family.String()))
+
+	if err := refreshtoken.Insert(ctx, x.db, id.ID, id.Family, expiresAt); err != nil {
+		return token.RefreshID{}, fmt.Errorf("refresh: inserting token, %w", err)
+	}
+	return id, nil
+}
+
+// Rotate uses up the given refresh token and persists the next one of its family.
+// Presenting a token that was already used revokes its family and emits a security event
+// about the subject. Possible errors are [ErrNotFound], [ErrRevoked], [ErrReused] and a wrapped
+// error indicating an internal error.
+func (x *Families) Rotate(
+	ctx context.Context,
+	id token.RefreshID,
+	subject string,
+	expiresAt time.Time,
+) (token.RefreshID, error) {
+	ctx, span := tracer.Start(ctx, "Rotate")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("id", id.ID.String()),
+		attribute.String("family", id.Family.String()),
+	)
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return token.RefreshID{}, fmt.Errorf("refresh: beginning transaction, %w", err)
+	}
+	defer func() {
+		if err != nil {
+			slog.ErrorContext(ctx, "refresh: error occurred, rolling back", "err", err)
+			if err := tx.Rollback(); err != nil {
+				slog.ErrorContext(ctx, "refresh: failed rollback", "err", err)
+			}
+		}
+	}()
+
+	if err = refreshtoken.Use(ctx, tx, id.ID); err != nil {
+		if errors.As(err, &database.RowsAffectedError{}) {
+			return token.RefreshID{}, x.reject(ctx, id, subject)
+		}
+		return token.RefreshID{}, fmt.Errorf("refresh: using token, %w", err)
+	}
+
+	next := id.Next()
+	if err = refreshtoken.Insert(ctx, tx, next.ID, next.Family, expiresAt); err != nil {
+		return token.RefreshID{}, fmt.Errorf("refresh: inserting token, %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return token.RefreshID{}, fmt.Errorf("refresh: committing transaction, %w", err)
+	}
+
+	return next, nil
+}
+
+// reject finds out why a refresh token could not be used
+// and revokes its family if it was used before.
+func (x *Families) reject(ctx context.Context, id token.RefreshID, subject string) error {
+	e, err := refreshtoken.Read(ctx, x.db, id.ID)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("refresh: reading token, %w", err)
+	}
+
+	switch {
+	case e.RevokedAt != nil:
+		return ErrRevoked
+	case e.UsedAt == nil:
+		return ErrNotFound
+	}
+
+	if err = refreshtoken.RevokeFamily(ctx, x.db, e.FamilyID); err != nil {
+		return fmt.Errorf("refresh: revoking family, %w", err)
+	}
+	if err = event.EmitSecurity(ctx, x.natsConn, event.Security{
+		Kind:       event.SecurityRefreshTokenReused,
+		Subject:    subject,
+		Detail:     fmt.Sprintf("refresh token family %s was revoked", e.FamilyID),
+		OccurredAt: time.Now(),
+	}); err != nil {
+		slog.ErrorContext(ctx, "refresh: emitting security event", "err", err)
+	}
+
+	return ErrReused
+}
+
+// Revoke every refresh token of a family, such as on logout.
+func (x *Families) Revoke(ctx context.Context, family uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "Revoke")
+	defer span.End()
+	span.SetAttributes(attribute.String("family", family.String()))
+
+	if err := refreshtoken.RevokeFamily(ctx, x.db, family); err != nil {
+		return fmt.Errorf("refresh: revoking family, %w", err)
+	}
+	return nil
+}
+
+// Purge deletes the refresh tokens that have expired. Expired tokens are rejected
+// by the token maker before they are looked up, so their reuse no longer has to be detected.
+func (x *Families) Purge(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "Purge")
+	defer span.End()
+
+	n, err := refreshtoken.DeleteExpired(ctx, x.db, time.Now())
+	if err != nil {
+		return fmt.Errorf("refresh: deleting expired tokens, %w", err)
+	}
+	span.SetAttributes(attribute.Int64("purged", n))
+	return nil
+}
+
+// PurgeEvery calls [Families.Purge] on every interval until the context is done.
+func (x *Families) PurgeEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := x.Purge(ctx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "refresh: purging expired tokens", "err", err)
+			}
+		}
+	}
+}
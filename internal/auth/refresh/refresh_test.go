@@ -0,0 +1,65 @@
+//go:build testdb
+// +build testdb
+
+package refresh_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth/refresh"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/refreshtoken"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(refreshtoken.Tablename)
+	t.Cleanup(cleanup)
+
+	f := refresh.NewFamilies(db, nil)
+	expiresAt := time.Now().Add(time.Hour)
+
+	t.Run("OK", func(t *testing.T) {
+		first, err := f.Start(ctx, expiresAt)
+		require.NoError(t, err)
+
+		second, err := f.Rotate(ctx, first, random.Email(), expiresAt)
+		require.NoError(t, err)
+		require.Equal(t, first.Family, second.Family)
+		require.NotEqual(t, first.ID, second.ID)
+
+		_, err = f.Rotate(ctx, second, random.Email(), expiresAt)
+		require.NoError(t, err)
+	})
+
+	t.Run("reuse revokes family", func(t *testing.T) {
+		first, err := f.Start(ctx, expiresAt)
+		require.NoError(t, err)
+		second, err := f.Rotate(ctx, first, random.Email(), expiresAt)
+		require.NoError(t, err)
+
+		_, err = f.Rotate(ctx, first, random.Email(), expiresAt)
+		require.ErrorIs(t, err, refresh.ErrReused)
+
+		_, err = f.Rotate(ctx, second, random.Email(), expiresAt)
+		require.ErrorIs(t, err, refresh.ErrRevoked)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		_, err := f.Rotate(ctx, token.NewRefreshID(), random.Email(), expiresAt)
+		require.ErrorIs(t, err, refresh.ErrNotFound)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		first, err := f.Start(ctx, time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+
+		_, err = f.Rotate(ctx, first, random.Email(), expiresAt)
+		require.ErrorIs(t, err, refresh.ErrNotFound)
+	})
+}
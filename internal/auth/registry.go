@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+var registry = struct {
+	sync.RWMutex
+	byType map[gen.Strategy]entry
+	byName map[string]entry
+}{
+	byType: make(map[gen.Strategy]entry),
+	byName: make(map[string]entry),
+}
+
+// ErrRegistrationNotSupported is returned by [Mounted.Register] for strategies that do not register users.
+var ErrRegistrationNotSupported = errors.New("auth: strategy does not register users")
+
+// Deps are what strategies are built from when they are mounted.
+type Deps struct {
+	DB     *sql.DB
+	NATS   *nats.Conn
+	Config *config.Application
+}
+
+// Identity is a user a strategy signed in.
+type Identity struct {
+	// Strategy the tokens are issued for, which is not necessarily the one the user signed in with,
+	// such as for federated identities linked to a credentials user.
+	Strategy   gen.Strategy
+	Identifier any
+	// Scopes replace the allowed scopes of the strategy if not nil, and are granted when none are requested.
+	Scopes []string
+	// TokenTTL is the lifetime of the access token if positive. No refresh token is issued then,
+	// such as for machine identities that authenticate again instead.
+	TokenTTL time.Duration
+}
+
+// Registered is the outcome of a registration.
+type Registered struct {
+	Response *gen.RegisterResponse
+	// Created reports whether a user was created. Registering a taken identifier can succeed without one,
+	// so that callers can not learn which identifiers are registered.
+	Created bool
+}
+
+// Info describes a registered strategy.
+type Info struct {
+	// Name of the strategy in the configuration.
+	Name string
+	// Type of the strategy in requests and tokens.
+	Type gen.Strategy
+	// Identifier is the type of the identifiers of users of the strategy.
+	Identifier IdentifierKind
+	// MFA reports whether users of the strategy can enroll a second factor, which is then required to sign in.
+	MFA bool
+}
+
+// Registration describes a strategy S with inputs In and outputs Out, so that it can be mounted by name
+// and serve the Register, Authenticate and Refresh RPCs without the server knowing its types.
+type Registration[S Strategy[In, Out], In, Out any] struct {
+	Info
+
+	// New builds the strategy when it is mounted.
+	New func(Deps) (S, error)
+	// Register maps a request to the strategy and its outputs to the response.
+	// Nil if users of the strategy can not register.
+	Register func(context.Context, S, *gen.RegisterRequest) (Registered, error)
+	// Authenticate maps a request to the strategy and returns who signed in.
+	Authenticate func(context.Context, S, *gen.AuthenticateRequest) (Identity, error)
+	// Refresh checks that a refresh token issued at the given time can still be used. Optional.
+	Refresh func(ctx context.Context, s S, identifier any, issuedAt time.Time) error
+}
+
+// Mounted is a strategy built from its [Registration]. It serves requests
+// without its caller knowing the inputs and outputs of the strategy.
+type Mounted struct {
+	Info
+
+	strategy     any
+	register     func(context.Context, *gen.RegisterRequest) (Registered, error)
+	authenticate func(context.Context, *gen.AuthenticateRequest) (Identity, error)
+	refresh      func(context.Context, any, time.Time) error
+}
+
+// Register a user with the inputs of the request.
+// Returns [ErrRegistrationNotSupported] if the strategy does not register users.
+func (x *Mounted) Register(ctx context.Context, req *gen.RegisterRequest) (Registered, error) {
+	if x.register == nil {
+		return Registered{}, ErrRegistrationNotSupported
+	}
+	return x.register(ctx, req)
+}
+
+// Authenticate a user with the inputs of the request.
+func (x *Mounted) Authenticate(ctx context.Context, req *gen.AuthenticateRequest) (Identity, error) {
+	return x.authenticate(ctx, req)
+}
+
+// Refresh checks that a refresh token of the user issued at the given time can still be used.
+func (x *Mounted) Refresh(ctx context.Context, identifier any, issuedAt time.Time) error {
+	if x.refresh == nil {
+		return nil
+	}
+	return x.refresh(ctx, identifier, issuedAt)
+}
+
+// As returns the strategy of a mounted one as S, to use what it offers beyond registering and authenticating.
+func As[S any](m *Mounted) (S, bool) {
+	s, ok := m.strategy.(S)
+	return s, ok
+}
+
+// entry is a [Registration] with its types erased.
+type entry struct {
+	Info
+	mount func(Deps) (*Mounted, error)
+}
+
+// Register makes a strategy available to be mounted. Strategies register themselves
+// in an init function of their package, which is imported for its side effects.
+// Panics if the registration is incomplete or its name or type is taken.
+func Register[S Strategy[In, Out], In, Out any](r Registration[S, In, Out]) {
+	if r.Name == "" || r.Type == gen.Strategy_TypeNoStrategy {
+		panic("auth: registering strategy without name or type")
+	}
+	if r.New == nil || r.Authenticate == nil {
+		panic(fmt.Sprintf("auth: registering strategy %s without New or Authenticate", r.Name))
+	}
+
+	e := entry{Info: r.Info, mount: func(d Deps) (*Mounted, error) {
+		s, err := r.New(d)
+		if err != nil {
+			return nil, err
+		}
+		m := &Mounted{
+			Info:     r.Info,
+			strategy: s,
+			authenticate: func(ctx context.Context, req *gen.AuthenticateRequest) (Identity, error) {
+				return r.Authenticate(ctx, s, req)
+			},
+		}
+		if r.Register != nil {
+			m.register = func(ctx context.Context, req *gen.RegisterRequest) (Registered, error) {
+				return r.Register(ctx, s, req)
+			}
+		}
+		if r.Refresh != nil {
+			m.refresh = func(ctx context.Context, identifier any, issuedAt time.Time) error {
+				return r.Refresh(ctx, s, identifier, issuedAt)
+			}
+		}
+		return m, nil
+	}}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.byName[r.Name]; ok {
+		panic(fmt.Sprintf("auth: strategy %s is registered twice", r.Name))
+	}
+	if _, ok := registry.byType[r.Type]; ok {
+		panic(fmt.Sprintf("auth: strategy type %s is registered twice", r.Type))
+	}
+	registry.byName[r.Name] = e
+	registry.byType[r.Type] = e
+}
+
+// Mount builds the strategy registered under the given name.
+func Mount(name string, d Deps) (*Mounted, error) {
+	registry.RLock()
+	e, ok := registry.byName[name]
+	registry.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("auth: unsupported strategy %s", name)
+	}
+	return e.mount(d)
+}
+
+// Lookup returns the description of a registered strategy type.
+func Lookup(t gen.Strategy) (Info, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	e, ok := registry.byType[t]
+	return e.Info, ok
+}
+
+// LookupName returns the description of a registered strategy by its name in the configuration.
+func LookupName(name string) (Info, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	e, ok := registry.byName[name]
+	return e.Info, ok
+}
+
+// IdentifierKind is the type of the identifiers of a strategy, as tokens carry them.
+type IdentifierKind int
+
+const (
+	IdentifierString IdentifierKind = iota
+	IdentifierUint64
+)
+
+// Check that an identifier has the type of the kind.
+func (k IdentifierKind) Check(identifier any) error {
+	var ok bool
+	switch k {
+	case IdentifierString:
+		_, ok = identifier.(string)
+	case IdentifierUint64:
+		_, ok = identifier.(uint64)
+	}
+	if !ok {
+		return fmt.Errorf("auth: unexpected identifier type %T", identifier)
+	}
+	return nil
+}
+
+// Parse an identifier that was stored as text, such as in the database.
+func (k IdentifierKind) Parse(s string) (any, error) {
+	if k == IdentifierUint64 {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("auth: parsing identifier, %w", err)
+		}
+		return n, nil
+	}
+	return s, nil
+}
+
+// Error is an error of a strategy that was caused by the request, such as incorrect credentials.
+// It is returned to the caller with its code and message, other errors are internal.
+type Error struct {
+	Code    codes.Code
+	Message string
+	Err     error
+	// Fields of the request that are invalid, returned as [errdetails.BadRequest] details.
+	Fields []*errdetails.BadRequest_FieldViolation
+	// RetryAfter is how long the caller should wait before retrying if positive,
+	// returned as a [errdetails.RetryInfo] detail.
+	RetryAfter time.Duration
+}
+
+// NewError returns an [Error] wrapping err.
+func NewError(code codes.Code, err error, msg string) error {
+	return &Error{Code: code, Message: msg, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,174 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+const pigeonType = gen.Strategy(100)
+
+// pigeon signs users in by the number of their carrier pigeon.
+type pigeon struct{ registered uint64 }
+
+func (x *pigeon) Register(_ context.Context, n uint64) (uint64, error) {
+	x.registered = n
+	return n, nil
+}
+
+func (x *pigeon) Authenticate(_ context.Context, n uint64) (uint64, error) {
+	if n != x.registered {
+		return 0, errors.New("unknown pigeon")
+	}
+	return n, nil
+}
+
+func pigeonRegistration() auth.Registration[*pigeon, uint64, uint64] {
+	return auth.Registration[*pigeon, uint64, uint64]{
+		Info: auth.Info{
+			Name:       "carrier_pigeon",
+			Type:       pigeonType,
+			Identifier: auth.IdentifierUint64,
+		},
+		New: func(auth.Deps) (*pigeon, error) {
+			return &pigeon{}, nil
+		},
+		Register: func(ctx context.Context, s *pigeon, req *gen.RegisterRequest) (auth.Registered, error) {
+			n, err := s.Register(ctx, 7)
+			if err != nil {
+				return auth.Registered{}, err
+			}
+			return auth.Registered{
+				Response: &gen.RegisterResponse{Data: &gen.RegisterResponse_Number{Number: &gen.PersonalNumber{Number: n}}},
+				Created:  true,
+			}, nil
+		},
+		Authenticate: func(ctx context.Context, s *pigeon, req *gen.AuthenticateRequest) (auth.Identity, error) {
+			n, err := s.Authenticate(ctx, req.GetNumber().GetNumber())
+			if err != nil {
+				return auth.Identity{}, auth.NewError(codes.Unauthenticated, err, "incorrect pigeon")
+			}
+			return auth.Identity{Strategy: pigeonType, Identifier: n}, nil
+		},
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := pigeonRegistration()
+	auth.Register(r)
+
+	t.Run("lookup", func(t *testing.T) {
+		got, ok := auth.Lookup(pigeonType)
+		require.True(t, ok)
+		require.Equal(t, r.Info, got)
+
+		got, ok = auth.LookupName("carrier_pigeon")
+		require.True(t, ok)
+		require.Equal(t, r.Info, got)
+
+		s, err := auth.StrategyFromString("carrier_pigeon")
+		require.NoError(t, err)
+		require.Equal(t, pigeonType, s)
+
+		_, err = auth.StrategyFromString("smoke_signal")
+		require.Error(t, err)
+	})
+
+	t.Run("mount", func(t *testing.T) {
+		m, err := auth.Mount("carrier_pigeon", auth.Deps{})
+		require.NoError(t, err)
+		require.Equal(t, r.Info, m.Info)
+
+		reg, err := m.Register(ctx, &gen.RegisterRequest{Strategy: pigeonType})
+		require.NoError(t, err)
+		require.True(t, reg.Created)
+		require.Equal(t, uint64(7), reg.Response.GetNumber().GetNumber())
+
+		id, err := m.Authenticate(ctx, &gen.AuthenticateRequest{
+			Strategy: pigeonType,
+			Data:     &gen.AuthenticateRequest_Number{Number: &gen.PersonalNumber{Number: 7}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint64(7), id.Identifier)
+
+		_, err = m.Authenticate(ctx, &gen.AuthenticateRequest{Strategy: pigeonType})
+		var e *auth.Error
+		require.ErrorAs(t, err, &e)
+		require.Equal(t, codes.Unauthenticated, e.Code)
+
+		require.NoError(t, m.Refresh(ctx, uint64(7), time.Now()))
+
+		s, ok := auth.As[*pigeon](m)
+		require.True(t, ok)
+		require.Equal(t, uint64(7), s.registered)
+
+		_, err = auth.Mount("smoke_signal", auth.Deps{})
+		require.Error(t, err)
+	})
+
+	t.Run("registration not supported", func(t *testing.T) {
+		other := pigeonRegistration()
+		other.Name, other.Type, other.Register = "homing_pigeon", gen.Strategy(101), nil
+		auth.Register(other)
+
+		m, err := auth.Mount("homing_pigeon", auth.Deps{})
+		require.NoError(t, err)
+		_, err = m.Register(ctx, &gen.RegisterRequest{})
+		require.ErrorIs(t, err, auth.ErrRegistrationNotSupported)
+	})
+
+	t.Run("duplicate name or type panics", func(t *testing.T) {
+		require.Panics(t, func() { auth.Register(r) })
+
+		other := pigeonRegistration()
+		other.Name = "racing_pigeon"
+		require.Panics(t, func() { auth.Register(other) })
+
+		other = pigeonRegistration()
+		other.Type = gen.Strategy(102)
+		require.Panics(t, func() { auth.Register(other) })
+	})
+
+	t.Run("incomplete registration panics", func(t *testing.T) {
+		require.Panics(t, func() {
+			auth.Register(auth.Registration[*pigeon, uint64, uint64]{
+				Info: auth.Info{Name: "owl", Type: gen.Strategy(103)},
+			})
+		})
+	})
+}
+
+func TestIdentifierKind(t *testing.T) {
+	require.NoError(t, auth.IdentifierString.Check("a@b.c"))
+	require.Error(t, auth.IdentifierString.Check(uint64(1)))
+	require.NoError(t, auth.IdentifierUint64.Check(uint64(1)))
+	require.Error(t, auth.IdentifierUint64.Check(1))
+
+	n, err := auth.IdentifierUint64.Parse("9999999999999999")
+	require.NoError(t, err)
+	require.Equal(t, uint64(9999999999999999), n)
+	_, err = auth.IdentifierUint64.Parse("a@b.c")
+	require.Error(t, err)
+
+	s, err := auth.IdentifierString.Parse("a@b.c")
+	require.NoError(t, err)
+	require.Equal(t, "a@b.c", s)
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("wrong password")
+	err := auth.NewError(codes.InvalidArgument, cause, "incorrect credentials")
+
+	var e *auth.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, codes.InvalidArgument, e.Code)
+	require.Equal(t, "incorrect credentials", e.Message)
+	require.ErrorIs(t, err, cause)
+}
@@ -0,0 +1,136 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/revokedtoken"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	tracer = otel.Tracer("revocation")
+
+	ErrRevoked = errors.New("revocation: token has been revoked")
+)
+
+// Store keeps the IDs of revoked tokens until the tokens would have expired.
+// Revoked IDs are persisted so that every instance rejects them, and cached in memory
+// so that a known revoked ID does not cost a query. IDs that are not revoked are not cached,
+// as another instance may revoke them at any time.
+type Store struct {
+	db    *sql.DB
+	cache *cache
+}
+
+// NewStore returns a new [Store].
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db, cache: newCache()}
+}
+
+// Revoke the token ID until expiresAt.
+func (x *Store) Revoke(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "Revoke")
+	defer span.End()
+	span.SetAttributes(attribute.String("jti", jti.String()))
+
+	if err := revokedtoken.Insert(ctx, x.db, jti, expiresAt); err != nil {
+		return fmt.Errorf("revocation: inserting token ID, %w", err)
+	}
+	x.cache.add(jti, expiresAt)
+	return nil
+}
+
+// Check returns [ErrRevoked] if the token ID was revoked,
+// any other error indicates an internal error.
+func (x *Store) Check(ctx context.Context, jti uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "Check")
+	defer span.End()
+	span.SetAttributes(attribute.String("jti", jti.String()))
+
+	if x.cache.has(jti, time.Now()) {
+		return ErrRevoked
+	}
+	e, err := revokedtoken.Read(ctx, x.db, jti)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return nil
+		}
+		return fmt.Errorf("revocation: reading token ID, %w", err)
+	}
+	x.cache.add(jti, e.ExpiresAt)
+	return ErrRevoked
+}
+
+// Purge deletes the IDs of revoked tokens that have expired.
+func (x *Store) Purge(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "Purge")
+	defer span.End()
+
+	now := time.Now()
+	x.cache.purge(now)
+	n, err := revokedtoken.DeleteExpired(ctx, x.db, now)
+	if err != nil {
+		return fmt.Errorf("revocation: deleting expired token IDs, %w", err)
+	}
+	span.SetAttributes(attribute.Int64("purged", n))
+	return nil
+}
+
+// PurgeEvery calls [Store.Purge] on every interval until the context is done.
+func (x *Store) PurgeEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := x.Purge(ctx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "revocation: purging expired token IDs", "err", err)
+			}
+		}
+	}
+}
+
+// cache holds revoked token IDs and when they can be forgotten.
+type cache struct {
+	mu      sync.RWMutex
+	entries map[uuid.UUID]time.Time
+}
+
+func newCache() *cache {
+	return &cache{entries: make(map[uuid.UUID]time.Time)}
+}
+
+func (x *cache) add(jti uuid.UUID, expiresAt time.Time) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.entries[jti] = expiresAt
+}
+
+func (x *cache) has(jti uuid.UUID, now time.Time) bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	expiresAt, ok := x.entries[jti]
+	return ok && now.Before(expiresAt)
+}
+
+func (x *cache) purge(now time.Time) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for jti, expiresAt := range x.entries {
+		if !now.Before(expiresAt) {
+			delete(x.entries, jti)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package revocation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+
+	t.Run("has added ID until it expires", func(t *testing.T) {
+		c := newCache()
+		jti := uuid.New()
+		c.add(jti, now.Add(time.Minute))
+
+		require.True(t, c.has(jti, now))
+		require.False(t, c.has(jti, now.Add(time.Minute)))
+	})
+
+	t.Run("does not have unknown ID", func(t *testing.T) {
+		require.False(t, newCache().has(uuid.New(), now))
+	})
+
+	t.Run("purge forgets expired IDs only", func(t *testing.T) {
+		c := newCache()
+		expired, active := uuid.New(), uuid.New()
+		c.add(expired, now.Add(-time.Minute))
+		c.add(active, now.Add(time.Minute))
+
+		c.purge(now)
+
+		require.NotContains(t, c.entries, expired)
+		require.Contains(t, c.entries, active)
+	})
+}
@@ -0,0 +1,44 @@
+//go:build testdb
+// +build testdb
+
+package revocation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth/revocation"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/revokedtoken"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(revokedtoken.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("revoked ID is rejected by every store", func(t *testing.T) {
+		jti := uuid.New()
+		require.NoError(t, revocation.NewStore(db).Revoke(ctx, jti, time.Now().Add(time.Hour)))
+
+		require.ErrorIs(t, revocation.NewStore(db).Check(ctx, jti), revocation.ErrRevoked)
+	})
+
+	t.Run("unknown ID is accepted", func(t *testing.T) {
+		require.NoError(t, revocation.NewStore(db).Check(ctx, uuid.New()))
+	})
+
+	t.Run("purge deletes expired IDs", func(t *testing.T) {
+		s := revocation.NewStore(db)
+		jti := uuid.New()
+		require.NoError(t, s.Revoke(ctx, jti, time.Now().Add(-time.Minute)))
+
+		require.NoError(t, s.Purge(ctx))
+
+		_, err := revokedtoken.Read(ctx, db, jti)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+}
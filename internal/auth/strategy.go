@@ -4,36 +4,30 @@ import (
 	"context"
 	"errors"
 
-	"github.com/Salam4nder/identity/internal/auth/strategy/credentials"
-	"github.com/Salam4nder/identity/internal/auth/strategy/personalnumber"
 	"github.com/Salam4nder/identity/proto/gen"
 )
 
-type Strategy interface {
+// Strategy registers and authenticates users with the typed inputs and outputs of the strategy.
+type Strategy[In, Out any] interface {
 	// Register an entry with the configured strategy.
-	// Outputs from this method are stored in the
-	// returned context.
-	Register(context.Context) (context.Context, error)
+	Register(context.Context, In) (Out, error)
 	// Authenticate the user with the configured strategy.
-	Authenticate(context.Context) error
+	Authenticate(context.Context, In) (Out, error)
 }
 
 const (
-	StrategyCredentials    = "credentials"
-	StrategyPersonalNumber = "personal_number"
-)
-
-var (
-	_ Strategy = (*credentials.Strategy)(nil)
-	_ Strategy = (*personalnumber.Strategy)(nil)
+	StrategyCredentials       = "credentials"
+	StrategyPersonalNumber    = "personal_number"
+	StrategyWebAuthn          = "webauthn"
+	StrategyMagicLink         = "magic_link"
+	StrategyFederated         = "federated"
+	StrategyClientCredentials = "client_credentials"
 )
 
+// StrategyFromString returns the type of a registered strategy by its name.
 func StrategyFromString(s string) (gen.Strategy, error) {
-	switch s {
-	case StrategyCredentials:
-		return gen.Strategy_TypeCredentials, nil
-	case StrategyPersonalNumber:
-		return gen.Strategy_TypePersonalNumber, nil
+	if i, ok := LookupName(s); ok {
+		return i.Type, nil
 	}
 	return gen.Strategy_TypeNoStrategy, errors.New("auth: unsupported strategy")
 }
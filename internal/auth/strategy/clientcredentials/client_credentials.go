@@ -0,0 +1,165 @@
+package clientcredentials
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/client"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// secretSize is the amount of random bytes in a client secret.
+const secretSize = 32
+
+var (
+	tracer = otel.Tracer("clientcredentials")
+
+	ErrInvalidClient            = errors.New("clientcredentials: client does not exist or secret is incorrect")
+	ErrClientNotFound           = errors.New("clientcredentials: client not found")
+	ErrInvalidInput             = errors.New("clientcredentials: invalid client")
+	ErrRegistrationNotSupported = errors.New("clientcredentials: clients are created with CreateClient")
+)
+
+type (
+	// Strategy implements the [Strategy] interface and signs machine identities in
+	// with the ID and secret of a registered client, see RFC 6749 section 4.4.
+	Strategy struct {
+		db  *sql.DB
+		cfg config.Clients
+	}
+
+	// Input is the ID and secret of a client on [Strategy.Authenticate].
+	Input struct {
+		ClientID, Secret string
+	}
+)
+
+// New creates a new [Strategy] for authentication.
+func New(db *sql.DB, cfg config.Clients) (*Strategy, error) {
+	if cfg.DefaultTokenTTL <= 0 || cfg.MaxTokenTTL < cfg.DefaultTokenTTL {
+		return nil, errors.New("clientcredentials: default token TTL must be positive and at most the maximum")
+	}
+	return &Strategy{db: db, cfg: cfg}, nil
+}
+
+// Register is not supported, clients are created with [Strategy.Create].
+func (x *Strategy) Register(context.Context, Input) (*client.Client, error) {
+	return nil, ErrRegistrationNotSupported
+}
+
+// Authenticate verifies the secret of a client, see [Strategy.Verify].
+func (x *Strategy) Authenticate(ctx context.Context, in Input) (*client.Client, error) {
+	ctx, span := tracer.Start(ctx, "Authenticate")
+	defer span.End()
+
+	return x.Verify(ctx, in.ClientID, in.Secret)
+}
+
+// Verify returns the client with the given ID if the secret is its current one.
+// Possible errors are [ErrInvalidClient] and a wrapped error indicating an internal error.
+func (x *Strategy) Verify(ctx context.Context, clientID, secret string) (*client.Client, error) {
+	ctx, span := tracer.Start(ctx, "Verify")
+	defer span.End()
+	span.SetAttributes(attribute.String("client_id", clientID))
+
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return nil, ErrInvalidClient
+	}
+	c, err := client.Read(ctx, x.db, id)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return nil, ErrInvalidClient
+		}
+		return nil, fmt.Errorf("clientcredentials: reading client, %w", err)
+	}
+	if subtle.ConstantTimeCompare(client.HashSecret(secret), c.SecretHash) != 1 {
+		return nil, ErrInvalidClient
+	}
+
+	return c, nil
+}
+
+// Create registers a new client allowed to request the given scopes and returns it with its secret,
+// which is only ever returned here. A zero token lifetime uses the configured default.
+// Possible errors are [ErrInvalidInput] and a wrapped error indicating an internal error.
+func (x *Strategy) Create(
+	ctx context.Context,
+	name string,
+	scopes []string,
+	tokenTTL time.Duration,
+) (*client.Client, string, error) {
+	ctx, span := tracer.Start(ctx, "Create")
+	defer span.End()
+	span.SetAttributes(attribute.String("name", name))
+
+	if name == "" {
+		return nil, "", fmt.Errorf("%w, name is empty", ErrInvalidInput)
+	}
+	for _, s := range scopes {
+		if s == "" || strings.ContainsAny(s, " \t\n") {
+			return nil, "", fmt.Errorf("%w, scope %q", ErrInvalidInput, s)
+		}
+	}
+	if tokenTTL == 0 {
+		tokenTTL = x.cfg.DefaultTokenTTL
+	}
+	if tokenTTL < time.Second || tokenTTL > x.cfg.MaxTokenTTL {
+		return nil, "", fmt.Errorf("%w, token lifetime must be between 1s and %s", ErrInvalidInput, x.cfg.MaxTokenTTL)
+	}
+
+	secret, err := random.Token(secretSize)
+	if err != nil {
+		return nil, "", fmt.Errorf("clientcredentials: generating secret, %w", err)
+	}
+	c := client.Client{
+		ID:         uuid.New(),
+		Name:       name,
+		SecretHash: client.HashSecret(secret),
+		Scopes:     scopes,
+		TokenTTL:   tokenTTL.Truncate(time.Second),
+		CreatedAt:  time.Now(),
+	}
+	if err = client.Insert(ctx, x.db, c); err != nil {
+		return nil, "", fmt.Errorf("clientcredentials: inserting client, %w", err)
+	}
+
+	return &c, secret, nil
+}
+
+// Rotate replaces the secret of a client and returns the new one, the previous secret stops working immediately.
+// Tokens issued before stay valid until they expire.
+// Possible errors are [ErrClientNotFound] and a wrapped error indicating an internal error.
+func (x *Strategy) Rotate(ctx context.Context, clientID string) (string, error) {
+	ctx, span := tracer.Start(ctx, "Rotate")
+	defer span.End()
+	span.SetAttributes(attribute.String("client_id", clientID))
+
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return "", ErrClientNotFound
+	}
+
+	secret, err := random.Token(secretSize)
+	if err != nil {
+		return "", fmt.Errorf("clientcredentials: generating secret, %w", err)
+	}
+	if err = client.UpdateSecret(ctx, x.db, id, client.HashSecret(secret), time.Now()); err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return "", ErrClientNotFound
+		}
+		return "", fmt.Errorf("clientcredentials: updating secret, %w", err)
+	}
+
+	return secret, nil
+}
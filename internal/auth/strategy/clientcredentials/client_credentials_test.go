@@ -0,0 +1,102 @@
+//go:build testdb
+// +build testdb
+
+package clientcredentials_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth/strategy/clientcredentials"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/client"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newStrategy(t *testing.T) *clientcredentials.Strategy {
+	t.Helper()
+
+	db, cleanup := database.SetupTestConn(client.Tablename)
+	t.Cleanup(cleanup)
+	s, err := clientcredentials.New(db, config.Clients{
+		AdminScope:      "clients:admin",
+		DefaultTokenTTL: 15 * time.Minute,
+		MaxTokenTTL:     time.Hour,
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func TestClientCredentials(t *testing.T) {
+	ctx := context.Background()
+	s := newStrategy(t)
+
+	t.Run("OK", func(t *testing.T) {
+		c, secret, err := s.Create(ctx, random.String(10), []string{"reports:read"}, 0)
+		require.NoError(t, err)
+		require.Equal(t, 15*time.Minute, c.TokenTTL)
+
+		got, err := s.Verify(ctx, c.ID.String(), secret)
+		require.NoError(t, err)
+		require.Equal(t, []string{"reports:read"}, got.Scopes)
+
+		got, err = s.Authenticate(ctx, clientcredentials.Input{
+			ClientID: c.ID.String(),
+			Secret:   secret,
+		})
+		require.NoError(t, err)
+		require.Equal(t, c.ID, got.ID)
+	})
+
+	t.Run("incorrect secret", func(t *testing.T) {
+		c, _, err := s.Create(ctx, random.String(10), nil, time.Minute)
+		require.NoError(t, err)
+
+		_, err = s.Verify(ctx, c.ID.String(), random.String(43))
+		require.ErrorIs(t, err, clientcredentials.ErrInvalidClient)
+	})
+
+	t.Run("unknown client", func(t *testing.T) {
+		_, err := s.Verify(ctx, uuid.NewString(), random.String(43))
+		require.ErrorIs(t, err, clientcredentials.ErrInvalidClient)
+
+		_, err = s.Verify(ctx, "not-a-uuid", random.String(43))
+		require.ErrorIs(t, err, clientcredentials.ErrInvalidClient)
+	})
+
+	t.Run("rotated secret replaces the previous one", func(t *testing.T) {
+		c, old, err := s.Create(ctx, random.String(10), nil, 0)
+		require.NoError(t, err)
+
+		secret, err := s.Rotate(ctx, c.ID.String())
+		require.NoError(t, err)
+
+		_, err = s.Verify(ctx, c.ID.String(), old)
+		require.ErrorIs(t, err, clientcredentials.ErrInvalidClient)
+		_, err = s.Verify(ctx, c.ID.String(), secret)
+		require.NoError(t, err)
+
+		_, err = s.Rotate(ctx, uuid.NewString())
+		require.ErrorIs(t, err, clientcredentials.ErrClientNotFound)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, _, err := s.Create(ctx, "", nil, 0)
+		require.ErrorIs(t, err, clientcredentials.ErrInvalidInput)
+
+		_, _, err = s.Create(ctx, random.String(10), []string{"two scopes"}, 0)
+		require.ErrorIs(t, err, clientcredentials.ErrInvalidInput)
+
+		_, _, err = s.Create(ctx, random.String(10), nil, 2*time.Hour)
+		require.ErrorIs(t, err, clientcredentials.ErrInvalidInput)
+	})
+
+	t.Run("register is not supported", func(t *testing.T) {
+		_, err := s.Register(ctx, clientcredentials.Input{})
+		require.ErrorIs(t, err, clientcredentials.ErrRegistrationNotSupported)
+	})
+}
@@ -0,0 +1,52 @@
+package clientcredentials
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/database/client"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/grpc/codes"
+)
+
+var _ auth.Strategy[Input, *client.Client] = (*Strategy)(nil)
+
+func init() {
+	auth.Register(auth.Registration[*Strategy, Input, *client.Client]{
+		Info: auth.Info{
+			Name:       auth.StrategyClientCredentials,
+			Type:       gen.Strategy_TypeClientCredentials,
+			Identifier: auth.IdentifierString,
+		},
+		New: func(d auth.Deps) (*Strategy, error) {
+			return New(d.DB, d.Config.Clients)
+		},
+		Register: func(context.Context, *Strategy, *gen.RegisterRequest) (auth.Registered, error) {
+			return auth.Registered{}, auth.NewError(codes.InvalidArgument, ErrRegistrationNotSupported, "clients are created with CreateClient")
+		},
+		Authenticate: authenticate,
+	})
+}
+
+// authenticate signs a client in for at most its scopes and with its token lifetime.
+// Clients authenticate again instead of refreshing, so they are issued no refresh token.
+func authenticate(ctx context.Context, s *Strategy, req *gen.AuthenticateRequest) (auth.Identity, error) {
+	c, err := s.Authenticate(ctx, Input{
+		ClientID: req.GetClientCredentials().GetClientId(),
+		Secret:   req.GetClientCredentials().GetClientSecret(),
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidClient) {
+			return auth.Identity{}, auth.NewError(codes.Unauthenticated, err, "incorrect client")
+		}
+		return auth.Identity{}, err
+	}
+	return auth.Identity{
+		Strategy:   gen.Strategy_TypeClientCredentials,
+		Identifier: c.ID.String(),
+		// Never nil, clients without scopes are allowed none rather than the ones of the strategy.
+		Scopes:   append([]string{}, c.Scopes...),
+		TokenTTL: c.TokenTTL,
+	}, nil
+}
@@ -6,43 +6,53 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 	"unicode/utf8"
 
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/config"
 	"github.com/Salam4nder/identity/internal/database"
 	"github.com/Salam4nder/identity/internal/database/credentials"
 	tokendb "github.com/Salam4nder/identity/internal/database/token"
+	totpdb "github.com/Salam4nder/identity/internal/database/totp"
 	"github.com/Salam4nder/identity/internal/email"
 	"github.com/Salam4nder/identity/internal/token"
+	pkggrpc "github.com/Salam4nder/identity/pkg/grpc"
 	"github.com/Salam4nder/identity/pkg/password"
 	"github.com/Salam4nder/identity/pkg/validation"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	tracer = otel.Tracer("strategy")
 
-	inputKey  ctxKey
-	outputKey ctxKey
-
 	ErrUserNotFound      = errors.New("credentials: user does not exist")
 	ErrUserNotVerified   = errors.New("credentials: user is not verified")
 	ErrTokenDoesNotExist = errors.New("credentials: token does not exist")
+	ErrTokenExpired      = errors.New("credentials: token has expired")
+	ErrUserVerified      = errors.New("credentials: user already verified")
 	ErrIncorrectPassword = errors.New("credentials: incorrect password")
+	ErrInvalidPassword   = errors.New("credentials: invalid password")
+	ErrInvalidEmail      = errors.New("credentials: invalid email")
+	ErrEmailTaken        = errors.New("credentials: email is already in use")
+	ErrTokenInvalidated  = errors.New("credentials: token was issued before the password changed")
 )
 
 type (
-	ctxKey int
-
 	// Strategy implements the [Strategy] interface and has everything
 	// to be able to [Register] and [Authenticate] with credentials.
 	Strategy struct {
 		db       *sql.DB
 		natsConn *nats.Conn
+		reset    config.PasswordReset
+		verify   config.Verification
+		change   config.EmailChange
+		policy   password.Policy
+		lockout  *lockout.Guard
 	}
 
 	Input struct {
@@ -51,48 +61,56 @@ type (
 
 	Output struct {
 		Email string
+		// Created reports whether [Strategy.Register] created a user, it did not if the email is taken.
+		Created bool
 	}
 )
 
-// New creates a new [Strategy] for authentication.
-func New(db *sql.DB, natsConn *nats.Conn) *Strategy {
-	return &Strategy{db: db, natsConn: natsConn}
-}
-
-func NewContext(ctx context.Context, c *Input) context.Context {
-	return context.WithValue(ctx, inputKey, c)
-}
-
-func FromContext(ctx context.Context) (*Output, error) {
-	c, ok := ctx.Value(outputKey).(*Output)
-	if !ok {
-		return nil, errors.New("credentials: getting credentials output from context")
-	}
-	return c, nil
+// New creates a new [Strategy] for authentication, accepting new passwords that satisfy the policy.
+// Failed logins are not limited if guard is nil.
+func New(
+	db *sql.DB,
+	natsConn *nats.Conn,
+	reset config.PasswordReset,
+	verify config.Verification,
+	change config.EmailChange,
+	policy password.Policy,
+	guard *lockout.Guard,
+) *Strategy {
+	return &Strategy{db: db, natsConn: natsConn, reset: reset, verify: verify, change: change, policy: policy, lockout: guard}
 }
 
 // Register will handles registration with the credentials strategy.
 // It will insert a new [credentials.Entry] into the credentials table
 // and send an email to the registered user.
-func (x *Strategy) Register(ctx context.Context) (context.Context, error) {
+// If the email is taken, the owner is emailed instead and the registration succeeds all the same,
+// so that callers can not learn which emails are registered.
+// Possible errors are [ErrInvalidEmail], [ErrInvalidPassword] wrapping a [password.PolicyError]
+// and a wrapped error indicating an internal error.
+func (x *Strategy) Register(ctx context.Context, cred Input) (Output, error) {
 	ctx, span := tracer.Start(ctx, "Register")
 	defer span.End()
 
-	cred, err := fromContext(ctx)
-	if err != nil {
-		return ctx, err
-	}
 	span.SetAttributes(
 		attribute.String("email", cred.Email),
 		attribute.Int("password length", utf8.RuneCountInString((cred.Password))),
 	)
 
-	p, err := password.FromString(cred.Password)
+	if err := validation.Email(cred.Email); err != nil {
+		return Output{}, fmt.Errorf("%w, %w", ErrInvalidEmail, err)
+	}
+	p, err := x.newPassword(cred.Password, cred.Email)
 	if err != nil {
-		return ctx, fmt.Errorf("credentials: creating password from string, %w", err)
+		return Output{}, err
 	}
-	if err = validation.Email(cred.Email); err != nil {
-		return ctx, fmt.Errorf("credentials: validating email, %w", err)
+
+	switch _, err = credentials.ReadByEmail(ctx, x.db, cred.Email); {
+	case err == nil:
+		// Take as long as hashing the password of a new user would.
+		password.CompareDummy(string(p))
+		return x.emailTaken(ctx, cred.Email)
+	case !errors.As(err, &database.NotFoundError{}):
+		return Output{}, fmt.Errorf("credentials: reading by email, %w", err)
 	}
 
 	tx, err := x.db.BeginTx(ctx, nil)
@@ -112,84 +130,191 @@ func (x *Strategy) Register(ctx context.Context) (context.Context, error) {
 		Password:  p,
 		CreatedAt: time.Now(),
 	}); err != nil {
-		return ctx, err
-	}
-
-	t := token.NewEmailVerificationToken(id.String())
-	if err = tokendb.Insert(ctx, tx, t); err != nil {
-		return ctx, err
+		if errors.As(err, &database.DuplicateEntryError{}) {
+			// Registered concurrently since it was read.
+			return x.emailTaken(ctx, cred.Email)
+		}
+		return Output{}, err
 	}
 
-	if err = email.Ingest(ctx, x.natsConn, email.Email{
-		To:      cred.Email,
-		From:    email.TestFrom,
-		Subject: email.TestSubject,
-		Body:    email.Verification("https://example.com", t),
-	}); err != nil {
-		return ctx, err
+	if err = x.sendVerification(ctx, tx, id, cred.Email); err != nil {
+		return Output{}, err
 	}
 
 	if err = tx.Commit(); err != nil {
 		slog.ErrorContext(ctx, "credentials: commit failed", "err", err)
-		return ctx, err
+		return Output{}, err
 	}
 
-	return newContext(ctx, &Output{Email: cred.Email}), nil
+	return Output{Email: cred.Email, Created: true}, nil
+}
+
+// emailTaken notifies the owner of a registered email that someone tried to register it
+// and reports the registration as successful.
+func (x *Strategy) emailTaken(ctx context.Context, emailAddr string) (Output, error) {
+	slog.InfoContext(ctx, "credentials: registration requested for taken email")
+	if err := email.Ingest(ctx, x.natsConn, email.Email{
+		To:      emailAddr,
+		From:    email.TestFrom,
+		Subject: email.AccountExistsSubject,
+		Body:    email.AccountExistsBody,
+	}); err != nil {
+		return Output{}, fmt.Errorf("credentials: notifying owner of taken email, %w", err)
+	}
+	return Output{Email: emailAddr}, nil
 }
 
 // Authenticate will authenticate a user.
-// Possible errors are [ErrIncorrectPassword], [ErrUserNotFound], [ErrUserNotVerified] and a wrapped error
-// indicating an internal error.
-func (x *Strategy) Authenticate(ctx context.Context) error {
+// Password hashes made with another algorithm or other parameters than the current
+// [password.Hasher] uses are replaced on success, which does not count as a password change.
+// With a lockout guard, failed logins are counted per account and client IP and further attempts
+// are delayed or locked, the owner of a locked account is notified by email. The failures of
+// users with a second factor are only forgotten once it passes as well.
+// Unknown emails take as long as incorrect passwords and whether the user is verified
+// is only checked after the password, callers should report the three errors alike.
+// Possible errors are [ErrIncorrectPassword], [ErrUserNotFound], [ErrUserNotVerified],
+// [lockout.LockedError] and a wrapped error indicating an internal error.
+func (x *Strategy) Authenticate(ctx context.Context, cred Input) (Output, error) {
 	ctx, span := tracer.Start(ctx, "Authenticate")
 	defer span.End()
 
-	cred, err := fromContext(ctx)
-	if err != nil {
-		return err
-	}
 	span.SetAttributes(
 		attribute.String("email", cred.Email),
 		attribute.Int("password length", utf8.RuneCountInString((cred.Password))),
 	)
 
-	p, err := password.FromString(cred.Password)
+	if x.lockout == nil {
+		if _, err := x.authenticate(ctx, cred); err != nil {
+			return Output{}, err
+		}
+		return Output{Email: cred.Email}, nil
+	}
+
+	ip := pkggrpc.MetadataFromContext(ctx).ClientIP
+	if err := x.lockout.Check(ctx, cred.Email, ip); err != nil {
+		return Output{}, err
+	}
+
+	e, err := x.authenticate(ctx, cred)
+	switch {
+	case err == nil:
+		// The failures of users with a second factor are forgotten once it passes, see [mfa.TOTP.Verify].
+		if x.secondFactor(ctx, e.ID) {
+			break
+		}
+		if err := x.lockout.Succeed(ctx, cred.Email); err != nil {
+			slog.ErrorContext(ctx, "credentials: forgetting failed logins", "err", err)
+		}
+	case errors.Is(err, ErrIncorrectPassword), errors.Is(err, ErrUserNotFound):
+		x.failed(ctx, cred.Email, ip)
+	}
 	if err != nil {
-		return ErrIncorrectPassword
+		return Output{}, err
+	}
+	return Output{Email: cred.Email}, nil
+}
+
+// secondFactor reports whether the user has a confirmed TOTP secret.
+// Errors are logged and reported as a second factor, so that failed logins are not forgotten.
+func (x *Strategy) secondFactor(ctx context.Context, id uuid.UUID) bool {
+	t, err := totpdb.Read(ctx, x.db, id)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return false
+		}
+		slog.ErrorContext(ctx, "credentials: reading totp secret", "err", err)
+		return true
+	}
+	return t.ConfirmedAt != nil
+}
+
+// failed records a failed login and notifies the owner of the account if it got locked.
+// Errors are logged, so that the login fails for its own reason.
+func (x *Strategy) failed(ctx context.Context, emailAddr, ip string) {
+	until, err := x.lockout.Fail(ctx, emailAddr, ip)
+	if err != nil {
+		slog.ErrorContext(ctx, "credentials: recording failed login", "err", err)
+		return
+	}
+	if until.IsZero() {
+		return
 	}
 
+	if _, err = credentials.ReadByEmail(ctx, x.db, emailAddr); err != nil {
+		if !errors.As(err, &database.NotFoundError{}) {
+			slog.ErrorContext(ctx, "credentials: reading locked user", "err", err)
+		}
+		return
+	}
+	if err = email.Ingest(ctx, x.natsConn, email.Email{
+		To:      emailAddr,
+		From:    email.TestFrom,
+		Subject: email.LockoutSubject,
+		Body:    email.Lockout(until),
+	}); err != nil {
+		slog.ErrorContext(ctx, "credentials: notifying locked user", "err", err)
+	}
+}
+
+// authenticate compares the password of the user with their hash and returns the user.
+func (x *Strategy) authenticate(ctx context.Context, cred Input) (*credentials.Entry, error) {
+	// The policy may have changed since the password was set, only its bounds apply.
+	if utf8.RuneCountInString(cred.Password) < password.MinChars || len(cred.Password) > password.MaxBytes {
+		return nil, ErrIncorrectPassword
+	}
+	p := password.SafeString(cred.Password)
+
 	e, err := credentials.ReadByEmail(ctx, x.db, cred.Email)
 	if err != nil {
 		if errors.As(err, &database.NotFoundError{}) {
-			return ErrUserNotFound
+			// Take as long as a wrong password would, so that the time does not tell unknown emails apart.
+			password.CompareDummy(string(p))
+			return nil, ErrUserNotFound
 		}
-		return fmt.Errorf("credentials: reading by email, %w", err)
+		return nil, fmt.Errorf("credentials: reading by email, %w", err)
 	}
 
+	if err = password.Compare(e.PasswordHash, string(p)); err != nil {
+		if errors.Is(err, password.ErrMismatchedHashAndPassword) {
+			return nil, ErrIncorrectPassword
+		}
+		return nil, fmt.Errorf("credentials: comparing password hash, %w", err)
+	}
+
+	// Only tell whether the user is verified to whoever knows their password.
 	if e.VerifiedAt == nil || e.VerifiedAt.IsZero() {
-		return ErrUserNotVerified
+		return nil, ErrUserNotVerified
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(e.PasswordHash), []byte(p)); err != nil {
-		return fmt.Errorf("credentials: comparing password hash, %w", err)
+	if password.NeedsRehash(e.PasswordHash) {
+		// The user is signed in with the old hash regardless, it is replaced on the next try.
+		if err = credentials.UpdatePasswordHash(ctx, x.db, e.ID, p); err != nil {
+			slog.ErrorContext(ctx, "credentials: rehashing password", "err", err)
+		}
 	}
 
-	return nil
+	return e, nil
 }
 
+// VerifyEmail verifies the owner of a verification token issued less than the configured TTL ago.
+// Possible errors are [ErrTokenDoesNotExist], [ErrTokenExpired], [ErrUserVerified] and a wrapped error
+// indicating an internal error.
 func (x *Strategy) VerifyEmail(ctx context.Context, tokenInput string) error {
 	ctx, span := tracer.Start(ctx, "VerifyEmail")
 	defer span.End()
 
-	t, err := tokendb.Get(ctx, x.db, tokenInput)
+	t, err := tokendb.Read(ctx, x.db, tokenInput)
 	if err != nil {
-		if errors.As(err, &database.NotFoundError{}) {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
 			return ErrTokenDoesNotExist
 		}
-		return fmt.Errorf("credentials: getting token, %w", err)
+		return fmt.Errorf("credentials: reading token, %w", err)
+	}
+	if time.Since(t.CreatedAt) > x.verify.TTL {
+		return ErrTokenExpired
 	}
 
-	id, _, err := token.ParseVerificationToken(t)
+	id, _, err := token.ParseVerificationToken(t.Token)
 	if err != nil {
 		return fmt.Errorf("credentials: pasring verification token, %w", err)
 	}
@@ -199,7 +324,7 @@ func (x *Strategy) VerifyEmail(ctx context.Context, tokenInput string) error {
 		return fmt.Errorf("credentials: reading user by email, %w", err)
 	}
 	if c.VerifiedAt != nil && !c.VerifiedAt.IsZero() {
-		return errors.New("credentials: user already verified")
+		return ErrUserVerified
 	}
 
 	tx, err := x.db.BeginTx(ctx, nil)
@@ -216,7 +341,7 @@ func (x *Strategy) VerifyEmail(ctx context.Context, tokenInput string) error {
 		return fmt.Errorf("credentials: verifying user, %w", err)
 	}
 
-	if err = tokendb.Delete(ctx, tx, t); err != nil {
+	if err = tokendb.Delete(ctx, tx, t.Token); err != nil {
 		return fmt.Errorf("credentials: deleting token, %w", err)
 	}
 
@@ -227,14 +352,395 @@ func (x *Strategy) VerifyEmail(ctx context.Context, tokenInput string) error {
 	return nil
 }
 
-func fromContext(ctx context.Context) (*Input, error) {
-	c, ok := ctx.Value(inputKey).(*Input)
-	if !ok {
-		return nil, errors.New("credentials: getting credentials input from context")
+// ResendVerification replaces the outstanding verification token of the user with the given email
+// and emails them a new one. Unknown and already verified emails are silently ignored
+// so that callers can not learn which emails are registered.
+func (x *Strategy) ResendVerification(ctx context.Context, emailAddr string) error {
+	ctx, span := tracer.Start(ctx, "ResendVerification")
+	defer span.End()
+	span.SetAttributes(attribute.String("email", emailAddr))
+
+	e, err := credentials.ReadByEmail(ctx, x.db, emailAddr)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
+			slog.InfoContext(ctx, "credentials: verification requested for unknown email")
+			return nil
+		}
+		return fmt.Errorf("credentials: reading by email, %w", err)
+	}
+	if e.VerifiedAt != nil && !e.VerifiedAt.IsZero() {
+		slog.InfoContext(ctx, "credentials: verification requested for verified email")
+		return nil
+	}
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("credentials: beginning transaction, %w", err)
+	}
+	defer func() {
+		if err != nil {
+			slog.ErrorContext(ctx, "credentials: error occurred, rolling back", "err", err)
+			if err := tx.Rollback(); err != nil {
+				slog.ErrorContext(ctx, "credentials: failed rollback", "err", err)
+			}
+		}
+	}()
+
+	if err = tokendb.DeleteByPrefix(ctx, tx, e.ID.String()); err != nil {
+		return fmt.Errorf("credentials: deleting outstanding tokens, %w", err)
+	}
+
+	if err = x.sendVerification(ctx, tx, e.ID, e.Email); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("credentials: committing transaction, %w", err)
+	}
+
+	return nil
+}
+
+// RequestPasswordReset emails a password reset link to the user with the given email.
+// Unknown emails are silently ignored so that callers can not
+// learn which emails are registered.
+func (x *Strategy) RequestPasswordReset(ctx context.Context, emailAddr string) error {
+	ctx, span := tracer.Start(ctx, "RequestPasswordReset")
+	defer span.End()
+	span.SetAttributes(attribute.String("email", emailAddr))
+
+	e, err := credentials.ReadByEmail(ctx, x.db, emailAddr)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
+			slog.InfoContext(ctx, "credentials: password reset requested for unknown email")
+			return nil
+		}
+		return fmt.Errorf("credentials: reading by email, %w", err)
+	}
+
+	t := token.NewPasswordResetToken(e.ID.String())
+	if err = tokendb.InsertPasswordReset(ctx, x.db, t, time.Now().Add(x.reset.TTL)); err != nil {
+		return fmt.Errorf("credentials: inserting password reset token, %w", err)
 	}
-	return c, nil
+
+	return email.Ingest(ctx, x.natsConn, email.Email{
+		To:      e.Email,
+		From:    email.TestFrom,
+		Subject: email.PasswordResetSubject,
+		Body:    email.PasswordReset(x.reset.Origin, t, x.reset.TTL),
+	})
 }
 
-func newContext(ctx context.Context, o *Output) context.Context {
-	return context.WithValue(ctx, outputKey, o)
+// ResetPassword consumes a password reset token and replaces the password of its owner.
+// Refresh tokens issued before the reset are rejected by [Strategy.ValidateRefresh] afterwards.
+// The token is not consumed if the new password is rejected.
+// Possible errors are [ErrTokenDoesNotExist], [ErrInvalidPassword] wrapping a [password.PolicyError]
+// and a wrapped error indicating an internal error.
+func (x *Strategy) ResetPassword(ctx context.Context, tokenInput, newPassword string) error {
+	ctx, span := tracer.Start(ctx, "ResetPassword")
+	defer span.End()
+	span.SetAttributes(attribute.Int("password length", utf8.RuneCountInString(newPassword)))
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("credentials: beginning transaction, %w", err)
+	}
+	defer func() {
+		if err != nil {
+			slog.ErrorContext(ctx, "credentials: error occurred, rolling back", "err", err)
+			if err := tx.Rollback(); err != nil {
+				slog.ErrorContext(ctx, "credentials: failed rollback", "err", err)
+			}
+		}
+	}()
+
+	t, err := tokendb.TakePasswordReset(ctx, tx, tokenInput)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
+			return ErrTokenDoesNotExist
+		}
+		return fmt.Errorf("credentials: taking password reset token, %w", err)
+	}
+
+	id, err := token.ParsePasswordResetToken(t)
+	if err != nil {
+		return fmt.Errorf("credentials: parsing password reset token, %w", err)
+	}
+
+	e, err := credentials.Read(ctx, x.db, id)
+	if err != nil {
+		return fmt.Errorf("credentials: reading user, %w", err)
+	}
+
+	if err = x.replacePassword(ctx, tx, e, newPassword); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("credentials: committing transaction, %w", err)
+	}
+
+	return nil
+}
+
+// ValidateRefresh returns [ErrTokenInvalidated] if a refresh token issued at issuedAt
+// predates the last password change of the user with the given email.
+func (x *Strategy) ValidateRefresh(ctx context.Context, emailAddr string, issuedAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "ValidateRefresh")
+	defer span.End()
+	span.SetAttributes(attribute.String("email", emailAddr))
+
+	e, err := credentials.ReadByEmail(ctx, x.db, emailAddr)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("credentials: reading by email, %w", err)
+	}
+
+	// Token timestamps only have second precision.
+	if e.PasswordChangedAt != nil && issuedAt.Before(e.PasswordChangedAt.Truncate(time.Second)) {
+		return ErrTokenInvalidated
+	}
+
+	return nil
+}
+
+// ChangePassword replaces the password of a user after checking their current one.
+// Refresh tokens issued before the change are rejected by [Strategy.ValidateRefresh] afterwards.
+// Possible errors are [ErrUserNotFound], [ErrIncorrectPassword], [lockout.LockedError], [ErrInvalidPassword]
+// wrapping a [password.PolicyError] and a wrapped error indicating an internal error.
+func (x *Strategy) ChangePassword(ctx context.Context, emailAddr, currentPassword, newPassword string) error {
+	ctx, span := tracer.Start(ctx, "ChangePassword")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("email", emailAddr),
+		attribute.Int("password length", utf8.RuneCountInString(newPassword)),
+	)
+
+	e, err := x.checkPassword(ctx, emailAddr, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("credentials: beginning transaction, %w", err)
+	}
+	defer func() {
+		if err != nil {
+			slog.ErrorContext(ctx, "credentials: error occurred, rolling back", "err", err)
+			if err := tx.Rollback(); err != nil {
+				slog.ErrorContext(ctx, "credentials: failed rollback", "err", err)
+			}
+		}
+	}()
+
+	if err = x.replacePassword(ctx, tx, e, newPassword); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("credentials: committing transaction, %w", err)
+	}
+
+	return nil
+}
+
+// ChangeEmail starts an email change after checking the current password of the user.
+// A verification email is sent to the new address and the current one stays active
+// until the change is confirmed with [Strategy.ConfirmEmailChange].
+// Possible errors are [ErrUserNotFound], [ErrIncorrectPassword], [lockout.LockedError], [ErrInvalidEmail],
+// [ErrEmailTaken] and a wrapped error indicating an internal error.
+func (x *Strategy) ChangeEmail(ctx context.Context, emailAddr, currentPassword, newEmail string) error {
+	ctx, span := tracer.Start(ctx, "ChangeEmail")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("email", emailAddr),
+		attribute.String("new email", newEmail),
+	)
+
+	e, err := x.checkPassword(ctx, emailAddr, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	if err = validation.Email(newEmail); err != nil {
+		return fmt.Errorf("%w, %w", ErrInvalidEmail, err)
+	}
+
+	if _, err = credentials.ReadByEmail(ctx, x.db, newEmail); err == nil {
+		return ErrEmailTaken
+	} else if !errors.As(err, &database.NotFoundError{}) {
+		return fmt.Errorf("credentials: reading by email, %w", err)
+	}
+
+	t := token.NewEmailVerificationToken(e.ID.String())
+	if err = credentials.InsertEmailChange(ctx, x.db, credentials.EmailChange{
+		Token:     t,
+		ID:        e.ID,
+		Email:     newEmail,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(x.change.TTL),
+	}); err != nil {
+		return fmt.Errorf("credentials: inserting email change, %w", err)
+	}
+
+	return email.Ingest(ctx, x.natsConn, email.Email{
+		To:      newEmail,
+		From:    email.TestFrom,
+		Subject: email.EmailChangeSubject,
+		Body:    email.Verification(x.change.Origin, t),
+	})
+}
+
+// ConfirmEmailChange replaces the email of a user with the address the given token was sent to
+// less than the configured TTL ago.
+// Possible errors are [ErrTokenDoesNotExist], [ErrEmailTaken] and a wrapped error
+// indicating an internal error.
+func (x *Strategy) ConfirmEmailChange(ctx context.Context, tokenInput string) error {
+	ctx, span := tracer.Start(ctx, "ConfirmEmailChange")
+	defer span.End()
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("credentials: beginning transaction, %w", err)
+	}
+	defer func() {
+		if err != nil {
+			slog.ErrorContext(ctx, "credentials: error occurred, rolling back", "err", err)
+			if err := tx.Rollback(); err != nil {
+				slog.ErrorContext(ctx, "credentials: failed rollback", "err", err)
+			}
+		}
+	}()
+
+	change, err := credentials.TakeEmailChange(ctx, tx, tokenInput)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
+			return ErrTokenDoesNotExist
+		}
+		return fmt.Errorf("credentials: taking email change, %w", err)
+	}
+	span.SetAttributes(attribute.String("new email", change.Email))
+
+	if err = credentials.Update(ctx, tx, credentials.UpdateParams{ID: change.ID, Email: change.Email}); err != nil {
+		if errors.As(err, &database.DuplicateEntryError{}) {
+			return ErrEmailTaken
+		}
+		return fmt.Errorf("credentials: updating email, %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("credentials: committing transaction, %w", err)
+	}
+
+	return nil
+}
+
+// sendVerification stores a new verification token for the user and emails it to them.
+func (x *Strategy) sendVerification(ctx context.Context, db database.Querier, id uuid.UUID, to string) error {
+	t := token.NewEmailVerificationToken(id.String())
+	if err := tokendb.Insert(ctx, db, t); err != nil {
+		return err
+	}
+
+	return email.Ingest(ctx, x.natsConn, email.Email{
+		To:      to,
+		From:    email.TestFrom,
+		Subject: email.TestSubject,
+		Body:    email.Verification(x.verify.Origin, t),
+	})
+}
+
+// newPassword creates a password of the user with the given email that satisfies the policy,
+// which bans the local part of the email.
+func (x *Strategy) newPassword(s, emailAddr string) (password.SafeString, error) {
+	local, _, _ := strings.Cut(emailAddr, "@")
+	p, err := x.policy.New(s, local)
+	if err != nil {
+		return "", fmt.Errorf("%w, %w", ErrInvalidPassword, err)
+	}
+	return p, nil
+}
+
+// replacePassword replaces the password of a user with one that satisfies the policy
+// and is none of the previous ones it keeps, archiving the current one.
+func (x *Strategy) replacePassword(ctx context.Context, db database.Querier, e *credentials.Entry, s string) error {
+	p, err := x.newPassword(s, e.Email)
+	if err != nil {
+		return err
+	}
+
+	if x.policy.History > 0 {
+		hashes := []string{e.PasswordHash}
+		if x.policy.History > 1 {
+			previous, err := credentials.ListPasswordHistory(ctx, db, e.ID, x.policy.History-1)
+			if err != nil {
+				return fmt.Errorf("credentials: listing password history, %w", err)
+			}
+			hashes = append(hashes, previous...)
+		}
+		if err = x.policy.CheckHistory(s, hashes); err != nil {
+			if errors.As(err, &password.PolicyError{}) {
+				return fmt.Errorf("%w, %w", ErrInvalidPassword, err)
+			}
+			return fmt.Errorf("credentials: checking password history, %w", err)
+		}
+	}
+	if x.policy.History > 1 {
+		if err = credentials.ArchivePasswordHash(ctx, db, e.ID, x.policy.History-1); err != nil {
+			return fmt.Errorf("credentials: archiving password hash, %w", err)
+		}
+	}
+
+	if err = credentials.UpdatePassword(ctx, db, e.ID, p); err != nil {
+		return fmt.Errorf("credentials: updating password, %w", err)
+	}
+	return nil
+}
+
+// checkPassword returns the user with the given email if the current password is theirs.
+// With a lockout guard, incorrect passwords count as failed logins of the account and client IP
+// as in [Strategy.Authenticate], so that an access token does not allow guessing the password.
+func (x *Strategy) checkPassword(ctx context.Context, emailAddr, currentPassword string) (*credentials.Entry, error) {
+	if x.lockout == nil {
+		return x.comparePassword(ctx, emailAddr, currentPassword)
+	}
+
+	ip := pkggrpc.MetadataFromContext(ctx).ClientIP
+	if err := x.lockout.Check(ctx, emailAddr, ip); err != nil {
+		return nil, err
+	}
+
+	e, err := x.comparePassword(ctx, emailAddr, currentPassword)
+	switch {
+	case err == nil:
+		if err := x.lockout.Succeed(ctx, emailAddr); err != nil {
+			slog.ErrorContext(ctx, "credentials: forgetting failed logins", "err", err)
+		}
+	case errors.Is(err, ErrIncorrectPassword):
+		x.failed(ctx, emailAddr, ip)
+	}
+	return e, err
+}
+
+// comparePassword reads the user with the given email and compares their password hash.
+func (x *Strategy) comparePassword(ctx context.Context, emailAddr, currentPassword string) (*credentials.Entry, error) {
+	e, err := credentials.ReadByEmail(ctx, x.db, emailAddr)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("credentials: reading by email, %w", err)
+	}
+
+	if err = password.Compare(e.PasswordHash, currentPassword); err != nil {
+		if errors.Is(err, password.ErrMismatchedHashAndPassword) {
+			return nil, ErrIncorrectPassword
+		}
+		return nil, fmt.Errorf("credentials: comparing password hash, %w", err)
+	}
+
+	return e, nil
 }
@@ -0,0 +1,218 @@
+//go:build testdb
+// +build testdb
+
+package credentials_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/auth/strategy/credentials"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	credentialsdb "github.com/Salam4nder/identity/internal/database/credentials"
+	"github.com/Salam4nder/identity/internal/database/loginattempt"
+	totpdb "github.com/Salam4nder/identity/internal/database/totp"
+	"github.com/Salam4nder/identity/pkg/password"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentialsdb.HistoryTablename)
+	t.Cleanup(cleanup)
+
+	s := credentials.New(db, nil, config.PasswordReset{}, config.Verification{}, config.EmailChange{}, password.Policy{
+		MinChars:     10,
+		RequireDigit: true,
+		History:      3,
+	}, nil)
+
+	email := "jane.doe@" + random.String(8) + ".com"
+	require.NoError(t, credentialsdb.Insert(ctx, db, credentialsdb.InsertParams{
+		ID:        uuid.New(),
+		Email:     email,
+		Password:  password.SafeString("first-Passw0rd"),
+		CreatedAt: time.Now(),
+	}))
+
+	violations := func(t *testing.T, err error) []password.Rule {
+		t.Helper()
+		require.ErrorIs(t, err, credentials.ErrInvalidPassword)
+		var e password.PolicyError
+		require.ErrorAs(t, err, &e)
+		rules := make([]password.Rule, 0, len(e.Violations))
+		for _, v := range e.Violations {
+			rules = append(rules, v.Rule)
+		}
+		return rules
+	}
+
+	t.Run("policy violations", func(t *testing.T) {
+		err := s.ChangePassword(ctx, email, "first-Passw0rd", "jane.doe-password")
+		require.Equal(t, []password.Rule{password.RuleDigit, password.RuleBannedWord}, violations(t, err))
+	})
+
+	t.Run("current password is reused", func(t *testing.T) {
+		err := s.ChangePassword(ctx, email, "first-Passw0rd", "first-Passw0rd")
+		require.Equal(t, []password.Rule{password.RuleReused}, violations(t, err))
+	})
+
+	t.Run("previous passwords are reused", func(t *testing.T) {
+		require.NoError(t, s.ChangePassword(ctx, email, "first-Passw0rd", "second-Passw0rd"))
+		require.NoError(t, s.ChangePassword(ctx, email, "second-Passw0rd", "third-Passw0rd"))
+
+		err := s.ChangePassword(ctx, email, "third-Passw0rd", "first-Passw0rd")
+		require.Equal(t, []password.Rule{password.RuleReused}, violations(t, err))
+
+		require.NoError(t, s.ChangePassword(ctx, email, "third-Passw0rd", "fourth-Passw0rd"))
+		require.NoError(t, s.ChangePassword(ctx, email, "fourth-Passw0rd", "first-Passw0rd"))
+	})
+}
+
+func TestAuthenticateLockout(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(loginattempt.Tablename)
+	t.Cleanup(cleanup)
+
+	s := credentials.New(db, nil, config.PasswordReset{}, config.Verification{}, config.EmailChange{}, password.DefaultPolicy, lockout.New(db, lockout.Policy{
+		Window:    time.Hour,
+		BaseDelay: time.Minute,
+		MaxDelay:  time.Hour,
+		Account:   lockout.Thresholds{DelayAfter: 2},
+	}))
+
+	ID := uuid.New()
+	email := random.Email()
+	require.NoError(t, credentialsdb.Insert(ctx, db, credentialsdb.InsertParams{
+		ID:        ID,
+		Email:     email,
+		Password:  password.SafeString("first-Passw0rd"),
+		CreatedAt: time.Now(),
+	}))
+	require.NoError(t, credentialsdb.Verify(ctx, db, ID))
+
+	_, err := s.Authenticate(ctx, credentials.Input{Email: email, Password: "first-Passw0rd"})
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = s.Authenticate(ctx, credentials.Input{Email: email, Password: "wrong-Passw0rd"})
+		require.ErrorIs(t, err, credentials.ErrIncorrectPassword)
+	}
+
+	_, err = s.Authenticate(ctx, credentials.Input{Email: email, Password: "first-Passw0rd"})
+	var locked *lockout.LockedError
+	require.ErrorAs(t, err, &locked)
+	require.Positive(t, locked.RetryAfter)
+}
+
+func TestCheckPasswordLockout(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(loginattempt.Tablename)
+	t.Cleanup(cleanup)
+
+	s := credentials.New(db, nil, config.PasswordReset{}, config.Verification{}, config.EmailChange{}, password.DefaultPolicy, lockout.New(db, lockout.Policy{
+		Window:    time.Hour,
+		BaseDelay: time.Minute,
+		MaxDelay:  time.Hour,
+		Account:   lockout.Thresholds{DelayAfter: 2},
+	}))
+
+	ID := uuid.New()
+	email := random.Email()
+	require.NoError(t, credentialsdb.Insert(ctx, db, credentialsdb.InsertParams{
+		ID:        ID,
+		Email:     email,
+		Password:  password.SafeString("first-Passw0rd"),
+		CreatedAt: time.Now(),
+	}))
+	require.NoError(t, credentialsdb.Verify(ctx, db, ID))
+
+	require.ErrorIs(t, s.ChangePassword(ctx, email, "wrong-Passw0rd", "second-Passw0rd"), credentials.ErrIncorrectPassword)
+	require.ErrorIs(t, s.ChangeEmail(ctx, email, "wrong-Passw0rd", random.Email()), credentials.ErrIncorrectPassword)
+
+	var locked *lockout.LockedError
+	require.ErrorAs(t, s.ChangePassword(ctx, email, "first-Passw0rd", "second-Passw0rd"), &locked)
+	require.Positive(t, locked.RetryAfter)
+	_, err := s.Authenticate(ctx, credentials.Input{Email: email, Password: "first-Passw0rd"})
+	require.ErrorAs(t, err, &locked)
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentialsdb.Tablename)
+	t.Cleanup(cleanup)
+
+	s := credentials.New(db, nil, config.PasswordReset{}, config.Verification{}, config.EmailChange{}, password.DefaultPolicy, nil)
+
+	ID := uuid.New()
+	email := random.Email()
+	require.NoError(t, credentialsdb.Insert(ctx, db, credentialsdb.InsertParams{
+		ID:        ID,
+		Email:     email,
+		Password:  password.SafeString("first-Passw0rd"),
+		CreatedAt: time.Now(),
+	}))
+
+	t.Run("unknown email", func(t *testing.T) {
+		_, err := s.Authenticate(ctx, credentials.Input{Email: random.Email(), Password: "first-Passw0rd"})
+		require.ErrorIs(t, err, credentials.ErrUserNotFound)
+	})
+
+	t.Run("unverified user with incorrect password", func(t *testing.T) {
+		_, err := s.Authenticate(ctx, credentials.Input{Email: email, Password: "wrong-Passw0rd"})
+		require.ErrorIs(t, err, credentials.ErrIncorrectPassword)
+	})
+
+	t.Run("unverified user", func(t *testing.T) {
+		_, err := s.Authenticate(ctx, credentials.Input{Email: email, Password: "first-Passw0rd"})
+		require.ErrorIs(t, err, credentials.ErrUserNotVerified)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		require.NoError(t, credentialsdb.Verify(ctx, db, ID))
+		out, err := s.Authenticate(ctx, credentials.Input{Email: email, Password: "first-Passw0rd"})
+		require.NoError(t, err)
+		require.Equal(t, email, out.Email)
+	})
+}
+
+func TestAuthenticateLockoutSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(loginattempt.Tablename)
+	t.Cleanup(cleanup)
+
+	s := credentials.New(db, nil, config.PasswordReset{}, config.Verification{}, config.EmailChange{}, password.DefaultPolicy, lockout.New(db, lockout.Policy{
+		Window:    time.Hour,
+		BaseDelay: time.Minute,
+		MaxDelay:  time.Hour,
+		Account:   lockout.Thresholds{DelayAfter: 3},
+	}))
+
+	ID := uuid.New()
+	email := random.Email()
+	require.NoError(t, credentialsdb.Insert(ctx, db, credentialsdb.InsertParams{
+		ID:        ID,
+		Email:     email,
+		Password:  password.SafeString("first-Passw0rd"),
+		CreatedAt: time.Now(),
+	}))
+	require.NoError(t, credentialsdb.Verify(ctx, db, ID))
+	require.NoError(t, totpdb.Upsert(ctx, db, ID, []byte("sealed")))
+	require.NoError(t, totpdb.Confirm(ctx, db, ID, 1))
+
+	_, err := s.Authenticate(ctx, credentials.Input{Email: email, Password: "wrong-Passw0rd"})
+	require.ErrorIs(t, err, credentials.ErrIncorrectPassword)
+
+	// The second factor has not passed yet.
+	_, err = s.Authenticate(ctx, credentials.Input{Email: email, Password: "first-Passw0rd"})
+	require.NoError(t, err)
+
+	e, err := loginattempt.Read(ctx, db, lockout.ScopeAccount, email)
+	require.NoError(t, err)
+	require.Equal(t, 1, e.Failures)
+}
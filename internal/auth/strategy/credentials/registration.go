@@ -0,0 +1,138 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/pkg/password"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+var _ auth.Strategy[Input, Output] = (*Strategy)(nil)
+
+func init() {
+	auth.Register(auth.Registration[*Strategy, Input, Output]{
+		Info: auth.Info{
+			Name:       auth.StrategyCredentials,
+			Type:       gen.Strategy_TypeCredentials,
+			Identifier: auth.IdentifierString,
+			MFA:        true,
+		},
+		New: func(d auth.Deps) (*Strategy, error) {
+			p, err := policy(d.Config.Password)
+			if err != nil {
+				return nil, err
+			}
+			return New(d.DB, d.NATS, d.Config.PasswordReset, d.Config.Verification, d.Config.EmailChange, p, lockout.FromConfig(d.DB, d.Config.Lockout)), nil
+		},
+		Register:     register,
+		Authenticate: authenticate,
+		Refresh:      refresh,
+	})
+}
+
+// policy returns the configured password policy, [password.DefaultPolicy] if there is none,
+// rejecting the passwords of the breaches file if one is configured.
+func policy(c config.Password) (password.Policy, error) {
+	p := password.DefaultPolicy
+	if c.Policy != nil {
+		p = password.Policy{
+			MinChars:      c.Policy.MinChars,
+			MaxBytes:      c.Policy.MaxBytes,
+			RequireUpper:  c.Policy.RequireUpper,
+			RequireLower:  c.Policy.RequireLower,
+			RequireDigit:  c.Policy.RequireDigit,
+			RequireSymbol: c.Policy.RequireSymbol,
+			MinScore:      c.Policy.MinScore,
+			BannedWords:   c.Policy.BannedWords,
+			History:       c.Policy.History,
+		}
+	}
+
+	if c.BreachesFile != "" {
+		breaches, err := password.LoadBreaches(c.BreachesFile)
+		if err != nil {
+			return p, fmt.Errorf("credentials: loading breaches, %w", err)
+		}
+		p.Breaches = breaches
+	}
+	return p, nil
+}
+
+func input(in *gen.CredentialsInput) Input {
+	return Input{Email: in.GetEmail(), Password: in.GetPassword()}
+}
+
+func register(ctx context.Context, s *Strategy, req *gen.RegisterRequest) (auth.Registered, error) {
+	out, err := s.Register(ctx, input(req.GetCredentials()))
+	if err != nil {
+		var policyErr password.PolicyError
+		switch {
+		case errors.As(err, &policyErr):
+			e := &auth.Error{Code: codes.InvalidArgument, Message: "password does not satisfy the policy", Err: err}
+			for _, v := range policyErr.Violations {
+				e.Fields = append(e.Fields, &errdetails.BadRequest_FieldViolation{
+					Field:       "credentials.password",
+					Description: fmt.Sprintf("%s: %s", v.Rule, v.Description),
+				})
+			}
+			return auth.Registered{}, e
+		case errors.Is(err, ErrInvalidEmail):
+			e := &auth.Error{Code: codes.InvalidArgument, Message: "invalid email", Err: err}
+			e.Fields = append(e.Fields, &errdetails.BadRequest_FieldViolation{
+				Field:       "credentials.email",
+				Description: "must be a valid email address",
+			})
+			return auth.Registered{}, e
+		default:
+			return auth.Registered{}, err
+		}
+	}
+	return auth.Registered{
+		Response: &gen.RegisterResponse{
+			Data: &gen.RegisterResponse_Credentials{Credentials: &gen.CredentialsOutput{Email: out.Email}},
+		},
+		Created: out.Created,
+	}, nil
+}
+
+func authenticate(ctx context.Context, s *Strategy, req *gen.AuthenticateRequest) (auth.Identity, error) {
+	out, err := s.Authenticate(ctx, input(req.GetCredentials()))
+	if err != nil {
+		var locked *lockout.LockedError
+		switch {
+		case errors.As(err, &locked):
+			return auth.Identity{}, &auth.Error{
+				Code:       codes.ResourceExhausted,
+				Message:    "too many failed attempts, retry later",
+				Err:        err,
+				RetryAfter: locked.RetryAfter,
+			}
+		case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrIncorrectPassword), errors.Is(err, ErrUserNotVerified):
+			// Alike, so that callers can not learn which emails are registered.
+			return auth.Identity{}, auth.NewError(codes.InvalidArgument, err, "incorrect email or password")
+		default:
+			return auth.Identity{}, err
+		}
+	}
+	return auth.Identity{Strategy: gen.Strategy_TypeCredentials, Identifier: out.Email}, nil
+}
+
+// refresh rejects refresh tokens issued before the password of the user changed.
+func refresh(ctx context.Context, s *Strategy, identifier any, issuedAt time.Time) error {
+	email, _ := identifier.(string)
+	if err := s.ValidateRefresh(ctx, email, issuedAt); err != nil {
+		if errors.Is(err, ErrTokenInvalidated) || errors.Is(err, ErrUserNotFound) {
+			return auth.NewError(codes.Unauthenticated, err, "incorrect token")
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package federated
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/credentials"
+	federateddb "github.com/Salam4nder/identity/internal/database/federated"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// stateSize and nonceSize are the number of random bytes of the state and the nonce of a sign-in.
+	stateSize = 32
+	nonceSize = 16
+)
+
+var (
+	tracer = otel.Tracer("federated")
+
+	ErrUnknownProvider          = errors.New("federated: provider is not configured")
+	ErrSessionNotFound          = errors.New("federated: sign-in does not exist or has expired")
+	ErrUpstream                 = errors.New("federated: upstream provider did not sign the user in")
+	ErrRegistrationNotSupported = errors.New("federated: users are created on their first sign-in")
+)
+
+type (
+	// Strategy implements the [Strategy] interface and signs users in through upstream
+	// OpenID Connect and OAuth 2.0 providers. A sign-in is started with [Strategy.Begin]
+	// and completed with [Strategy.Authenticate] once the provider redirected back.
+	Strategy struct {
+		db        *sql.DB
+		ttl       time.Duration
+		upstreams map[string]*upstream
+	}
+
+	// Input is the state and the code the provider redirected back with.
+	Input struct {
+		State, Code string
+	}
+
+	// Login is the local identity a completed sign-in resolved to.
+	Login struct {
+		// ID of the federated identity.
+		ID uuid.UUID
+		// Email reported by the provider, or the one of the linked credentials user.
+		Email string
+		// Linked is set if the identity is linked to a credentials user, who should be signed in instead.
+		Linked bool
+	}
+)
+
+// New creates a new [Strategy] for authentication.
+func New(db *sql.DB, cfg config.Federated) (*Strategy, error) {
+	if cfg.SessionTTL <= 0 {
+		return nil, errors.New("federated: session TTL must be positive")
+	}
+
+	upstreams := make(map[string]*upstream, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		u, err := newUpstream(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := upstreams[p.Name]; ok {
+			return nil, fmt.Errorf("federated: duplicate provider %s", p.Name)
+		}
+		upstreams[p.Name] = u
+	}
+	return &Strategy{db: db, ttl: cfg.SessionTTL, upstreams: upstreams}, nil
+}
+
+// Register is not supported, users are created on their first sign-in.
+func (x *Strategy) Register(context.Context, Input) (*Login, error) {
+	return nil, ErrRegistrationNotSupported
+}
+
+// Authenticate completes a sign-in, see [Strategy.Complete].
+func (x *Strategy) Authenticate(ctx context.Context, in Input) (*Login, error) {
+	ctx, span := tracer.Start(ctx, "Authenticate")
+	defer span.End()
+
+	return x.Complete(ctx, in.State, in.Code)
+}
+
+// Begin starts a sign-in at the provider with the given name. It returns the URL the user is sent to
+// and the state the provider redirects back with, which must be passed to [Strategy.Complete].
+// Possible errors are [ErrUnknownProvider] and a wrapped error indicating an internal error.
+func (x *Strategy) Begin(ctx context.Context, provider string) (string, string, error) {
+	ctx, span := tracer.Start(ctx, "Begin")
+	defer span.End()
+	span.SetAttributes(attribute.String("provider", provider))
+
+	u, ok := x.upstreams[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	state, err := random.Token(stateSize)
+	if err != nil {
+		return "", "", fmt.Errorf("federated: generating state, %w", err)
+	}
+	nonce, err := random.Token(nonceSize)
+	if err != nil {
+		return "", "", fmt.Errorf("federated: generating nonce, %w", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := u.authCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+	if err = federateddb.InsertSession(ctx, x.db, federateddb.Session{
+		State:        state,
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(x.ttl),
+	}); err != nil {
+		return "", "", fmt.Errorf("federated: storing session, %w", err)
+	}
+
+	return authURL, state, nil
+}
+
+// Complete redeems the code of a sign-in started with [Strategy.Begin] and returns the local identity
+// of the user, creating it on their first sign-in. Providers with linkByEmail link new identities
+// to the credentials user with the same verified email.
+// Possible errors are [ErrSessionNotFound], [ErrUnknownProvider], [ErrUpstream] and a wrapped error
+// indicating an internal error.
+func (x *Strategy) Complete(ctx context.Context, state, code string) (*Login, error) {
+	ctx, span := tracer.Start(ctx, "Complete")
+	defer span.End()
+
+	s, err := federateddb.TakeSession(ctx, x.db, state)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("federated: taking session, %w", err)
+	}
+	span.SetAttributes(attribute.String("provider", s.Provider))
+
+	u, ok := x.upstreams[s.Provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	c, err := u.exchange(ctx, code, s.CodeVerifier, s.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return x.signIn(ctx, u.cfg, c)
+}
+
+// signIn returns the identity of a user at a provider, creating and possibly linking it on their first sign-in.
+func (x *Strategy) signIn(ctx context.Context, cfg config.FederatedProvider, c *claims) (*Login, error) {
+	var email *string
+	if c.Email != "" {
+		email = &c.Email
+	}
+
+	i, err := federateddb.ReadIdentity(ctx, x.db, cfg.Name, c.Subject)
+	switch {
+	case err == nil:
+		if err = federateddb.UpdateLogin(ctx, x.db, i.ID, email, time.Now()); err != nil {
+			return nil, fmt.Errorf("federated: updating identity, %w", err)
+		}
+	case errors.As(err, &database.NotFoundError{}):
+		i = &federateddb.Identity{
+			ID:        uuid.New(),
+			Provider:  cfg.Name,
+			Subject:   c.Subject,
+			Email:     email,
+			CreatedAt: time.Now(),
+		}
+		if cfg.LinkByEmail && c.EmailVerified && c.Email != "" {
+			if i.CredentialsID, err = x.linkableCredentials(ctx, c.Email); err != nil {
+				return nil, err
+			}
+		}
+		if err = federateddb.InsertIdentity(ctx, x.db, *i); err != nil {
+			return nil, fmt.Errorf("federated: inserting identity, %w", err)
+		}
+		slog.InfoContext(ctx, "federated: created identity", "provider", cfg.Name, "linked", i.CredentialsID != nil)
+	default:
+		return nil, fmt.Errorf("federated: reading identity, %w", err)
+	}
+
+	if i.CredentialsID == nil {
+		return &Login{ID: i.ID, Email: c.Email}, nil
+	}
+	linked, err := credentials.Read(ctx, x.db, *i.CredentialsID)
+	if err != nil {
+		return nil, fmt.Errorf("federated: reading linked credentials, %w", err)
+	}
+	return &Login{ID: i.ID, Email: linked.Email, Linked: true}, nil
+}
+
+// linkableCredentials returns the ID of the verified credentials user with the given email, if any.
+func (x *Strategy) linkableCredentials(ctx context.Context, email string) (*uuid.UUID, error) {
+	e, err := credentials.ReadByEmail(ctx, x.db, email)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("federated: reading credentials by email, %w", err)
+	}
+	if e.VerifiedAt == nil || e.VerifiedAt.IsZero() {
+		return nil, nil
+	}
+	return &e.ID, nil
+}
@@ -0,0 +1,128 @@
+//go:build testdb
+// +build testdb
+
+package federated
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/credentials"
+	federateddb "github.com/Salam4nder/identity/internal/database/federated"
+	"github.com/Salam4nder/identity/pkg/password"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newStrategy(t *testing.T, providers ...config.FederatedProvider) *Strategy {
+	t.Helper()
+
+	db, cleanup := database.SetupTestConn(federateddb.IdentitiesTablename)
+	t.Cleanup(cleanup)
+	s, err := New(db, config.Federated{SessionTTL: time.Minute, Providers: providers})
+	require.NoError(t, err)
+	return s
+}
+
+// login signs the current user of the IdP in at the given provider.
+func login(t *testing.T, ctx context.Context, s *Strategy, x *idp, provider string) *Login {
+	t.Helper()
+
+	authURL, state, err := s.Begin(ctx, provider)
+	require.NoError(t, err)
+	redirected, code := x.redirect(t, authURL)
+	require.Equal(t, state, redirected)
+
+	l, err := s.Complete(ctx, state, code)
+	require.NoError(t, err)
+	return l
+}
+
+func TestStrategy(t *testing.T) {
+	ctx := context.Background()
+	x := newIdP(t)
+	linking := x.oidcProvider("linking")
+	linking.LinkByEmail = true
+	s := newStrategy(t, x.oidcProvider("idp"), linking, x.oauthProvider("github"))
+
+	t.Run("First sign-in creates an identity", func(t *testing.T) {
+		email := random.Email()
+		x.signIn(user{subject: random.String(16), email: email, emailVerified: true})
+
+		first := login(t, ctx, s, x, "idp")
+		require.Equal(t, email, first.Email)
+		require.False(t, first.Linked)
+
+		again := login(t, ctx, s, x, "idp")
+		require.Equal(t, first.ID, again.ID)
+	})
+
+	t.Run("Same subject at another provider is another identity", func(t *testing.T) {
+		subject := "4242"
+		x.signIn(user{subject: subject, email: random.Email()})
+		github := login(t, ctx, s, x, "github")
+
+		x.signIn(user{subject: subject, email: random.Email()})
+		require.NotEqual(t, github.ID, login(t, ctx, s, x, "idp").ID)
+	})
+
+	t.Run("Verified email links to the credentials user", func(t *testing.T) {
+		id, email := uuid.New(), random.Email()
+		require.NoError(t, credentials.Insert(ctx, s.db, credentials.InsertParams{
+			ID:        id,
+			Email:     email,
+			Password:  password.SafeString(random.String(10)),
+			CreatedAt: time.Now(),
+		}))
+		require.NoError(t, credentials.Verify(ctx, s.db, id))
+
+		x.signIn(user{subject: random.String(16), email: email, emailVerified: false})
+		require.False(t, login(t, ctx, s, x, "linking").Linked)
+
+		x.signIn(user{subject: random.String(16), email: email, emailVerified: true})
+		linked := login(t, ctx, s, x, "linking")
+		require.True(t, linked.Linked)
+		require.Equal(t, email, linked.Email)
+
+		x.signIn(user{subject: random.String(16), email: email, emailVerified: true})
+		require.False(t, login(t, ctx, s, x, "idp").Linked)
+	})
+
+	t.Run("State can not be reused", func(t *testing.T) {
+		x.signIn(user{subject: random.String(16), email: random.Email()})
+		authURL, state, err := s.Begin(ctx, "idp")
+		require.NoError(t, err)
+		_, code := x.redirect(t, authURL)
+
+		_, err = s.Complete(ctx, state, code)
+		require.NoError(t, err)
+		_, err = s.Complete(ctx, state, code)
+		require.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("Unknown provider", func(t *testing.T) {
+		_, _, err := s.Begin(ctx, "unknown")
+		require.ErrorIs(t, err, ErrUnknownProvider)
+	})
+
+	t.Run("Authenticate completes the sign-in", func(t *testing.T) {
+		x.signIn(user{subject: random.String(16), email: random.Email()})
+		authURL, state, err := s.Begin(ctx, "idp")
+		require.NoError(t, err)
+		_, code := x.redirect(t, authURL)
+
+		_, err = s.Authenticate(ctx, Input{State: state, Code: code})
+		require.NoError(t, err)
+		_, err = s.Authenticate(ctx, Input{State: state, Code: code})
+		require.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("Register is not supported", func(t *testing.T) {
+		_, err := s.Register(ctx, Input{})
+		require.ErrorIs(t, err, ErrRegistrationNotSupported)
+	})
+}
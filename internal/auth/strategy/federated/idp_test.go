@@ -0,0 +1,215 @@
+package federated
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/pkg/random"
+)
+
+const (
+	clientID     = "identity"
+	clientSecret = "identity-secret"
+	redirectURL  = "https://identity.example.com/callback"
+)
+
+// user is signed in at the stand-in IdP without asking.
+type user struct {
+	subject       string
+	email         string
+	emailVerified bool
+}
+
+// issued is what a code of the stand-in IdP was issued for.
+type issued struct {
+	user      user
+	nonce     string
+	challenge string
+}
+
+// idp is a local stand-in for an upstream OpenID Connect provider. Its user info endpoint
+// answers like a GitHub style OAuth 2.0 provider, with a numeric id instead of a sub claim.
+type idp struct {
+	srv    *httptest.Server
+	signer *token.JWTMaker
+
+	mu    sync.Mutex
+	user  user
+	codes map[string]issued
+	// nonce overrides the nonce of issued ID tokens if set.
+	nonce string
+	// keys are published as the JWKS, ID tokens are signed by signer, which tests may swap.
+	keys *token.JWTMaker
+}
+
+func newIdP(t *testing.T) *idp {
+	t.Helper()
+
+	signer, err := token.BootstrapJWTMaker(time.Minute, time.Hour, token.JWTAlgorithmEdDSA, []byte(random.String(ed25519.SeedSize)))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err.Error())
+	}
+	x := &idp{signer: signer, keys: signer, codes: make(map[string]issued)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", x.discovery)
+	mux.Handle("GET /jwks", token.JWKSHandler(x.keys))
+	mux.HandleFunc("GET /authorize", x.authorize)
+	mux.HandleFunc("POST /token", x.token)
+	mux.HandleFunc("GET /userinfo", x.userinfo)
+	x.srv = httptest.NewServer(mux)
+	t.Cleanup(x.srv.Close)
+	return x
+}
+
+// signIn makes the given user the one signed in at the IdP.
+func (x *idp) signIn(u user) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.user = u
+}
+
+func (x *idp) oidcProvider(name string) config.FederatedProvider {
+	return config.FederatedProvider{
+		Name:         name,
+		Issuer:       x.srv.URL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"email"},
+	}
+}
+
+func (x *idp) oauthProvider(name string) config.FederatedProvider {
+	return config.FederatedProvider{
+		Name:         name,
+		AuthURL:      x.srv.URL + "/authorize",
+		TokenURL:     x.srv.URL + "/token",
+		UserInfoURL:  x.srv.URL + "/userinfo",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Claims:       config.Claims{Subject: "id"},
+	}
+}
+
+// redirect follows an authorization URL the way a browser would and returns the state and the code
+// the IdP redirected back with.
+func (x *idp) redirect(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+
+	c := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := c.Get(authURL)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err.Error())
+	}
+	resp.Body.Close()
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc.Query().Get("state"), loc.Query().Get("code")
+}
+
+func (x *idp) discovery(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"issuer":                                x.srv.URL,
+		"authorization_endpoint":                x.srv.URL + "/authorize",
+		"token_endpoint":                        x.srv.URL + "/token",
+		"userinfo_endpoint":                     x.srv.URL + "/userinfo",
+		"jwks_uri":                              x.srv.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{x.keys.Algorithm()},
+	})
+}
+
+func (x *idp) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != clientID || q.Get("redirect_uri") != redirectURL || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	code := random.String(32)
+	x.mu.Lock()
+	x.codes[code] = issued{user: x.user, nonce: q.Get("nonce"), challenge: q.Get("code_challenge")}
+	x.mu.Unlock()
+
+	http.Redirect(w, r, fmt.Sprintf("%s?code=%s&state=%s", redirectURL, code, url.QueryEscape(q.Get("state"))), http.StatusFound)
+}
+
+func (x *idp) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+	if id != clientID || secret != clientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	x.mu.Lock()
+	c, ok := x.codes[r.FormValue("code")]
+	delete(x.codes, r.FormValue("code"))
+	nonce, signer := x.nonce, x.signer
+	x.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != c.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+	if nonce == "" {
+		nonce = c.nonce
+	}
+
+	idToken, err := signer.SignClaims(map[string]any{
+		"iss":            x.srv.URL,
+		"aud":            clientID,
+		"sub":            c.user.subject,
+		"email":          c.user.email,
+		"email_verified": c.user.emailVerified,
+		"nonce":          nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		// The access token is the subject, so that the user info endpoint knows the user.
+		"access_token": c.user.subject,
+		"token_type":   "Bearer",
+		"id_token":     string(idToken),
+	})
+}
+
+func (x *idp) userinfo(w http.ResponseWriter, r *http.Request) {
+	subject := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	x.mu.Lock()
+	email := x.user.email
+	x.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	// The subject is written as a JSON number, like the user IDs of GitHub.
+	_, _ = fmt.Fprintf(w, `{"id": %s, "email": %q}`, subject, email)
+}
@@ -0,0 +1,53 @@
+package federated
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/grpc/codes"
+)
+
+var _ auth.Strategy[Input, *Login] = (*Strategy)(nil)
+
+func init() {
+	auth.Register(auth.Registration[*Strategy, Input, *Login]{
+		Info: auth.Info{
+			Name:       auth.StrategyFederated,
+			Type:       gen.Strategy_TypeFederated,
+			Identifier: auth.IdentifierString,
+		},
+		New: func(d auth.Deps) (*Strategy, error) {
+			return New(d.DB, d.Config.Federated)
+		},
+		Register: func(context.Context, *Strategy, *gen.RegisterRequest) (auth.Registered, error) {
+			return auth.Registered{}, auth.NewError(codes.InvalidArgument, ErrRegistrationNotSupported, "federated users register by signing in")
+		},
+		Authenticate: authenticate,
+	})
+}
+
+// authenticate completes a sign-in. Linked identities sign in to the credentials user,
+// including its second factor.
+func authenticate(ctx context.Context, s *Strategy, req *gen.AuthenticateRequest) (auth.Identity, error) {
+	login, err := s.Authenticate(ctx, Input{
+		State: req.GetFederated().GetState(),
+		Code:  req.GetFederated().GetCode(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSessionNotFound), errors.Is(err, ErrUpstream):
+			return auth.Identity{}, auth.NewError(codes.Unauthenticated, err, err.Error())
+		case errors.Is(err, ErrUnknownProvider):
+			return auth.Identity{}, auth.NewError(codes.InvalidArgument, err, err.Error())
+		default:
+			return auth.Identity{}, err
+		}
+	}
+
+	if login.Linked {
+		return auth.Identity{Strategy: gen.Strategy_TypeCredentials, Identifier: login.Email}, nil
+	}
+	return auth.Identity{Strategy: gen.Strategy_TypeFederated, Identifier: login.ID.String()}, nil
+}
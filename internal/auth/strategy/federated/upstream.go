@@ -0,0 +1,191 @@
+package federated
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// claims are the mapped claims of a user at an upstream provider.
+type claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// upstream is a configured OpenID Connect or OAuth 2.0 provider.
+type upstream struct {
+	cfg    config.FederatedProvider
+	claims config.Claims
+
+	// mu guards provider, which is discovered on first use
+	// so that an unavailable provider does not stop the service from starting.
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func newUpstream(cfg config.FederatedProvider) (*upstream, error) {
+	if cfg.Name == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("federated: providers need a name, a client ID and a redirect URL")
+	}
+	if cfg.Issuer == "" && (cfg.AuthURL == "" || cfg.TokenURL == "" || cfg.UserInfoURL == "") {
+		return nil, fmt.Errorf("federated: provider %s needs an issuer or auth, token and user info URLs", cfg.Name)
+	}
+
+	c := cfg.Claims
+	if c.Subject == "" {
+		c.Subject = "sub"
+	}
+	if c.Email == "" {
+		c.Email = "email"
+	}
+	if c.EmailVerified == "" {
+		c.EmailVerified = "email_verified"
+	}
+	return &upstream{cfg: cfg, claims: c}, nil
+}
+
+// oauth returns the OAuth 2.0 configuration of the provider and, for OpenID Connect providers, the discovered provider.
+func (x *upstream) oauth(ctx context.Context) (*oauth2.Config, *oidc.Provider, error) {
+	conf := &oauth2.Config{
+		ClientID:     x.cfg.ClientID,
+		ClientSecret: x.cfg.ClientSecret,
+		RedirectURL:  x.cfg.RedirectURL,
+		Scopes:       x.cfg.Scopes,
+		Endpoint:     oauth2.Endpoint{AuthURL: x.cfg.AuthURL, TokenURL: x.cfg.TokenURL},
+	}
+	if x.cfg.Issuer == "" {
+		return conf, nil, nil
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.provider == nil {
+		p, err := oidc.NewProvider(ctx, x.cfg.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("federated: discovering %s, %w", x.cfg.Name, err)
+		}
+		x.provider = p
+	}
+	conf.Endpoint = x.provider.Endpoint()
+	conf.Scopes = append([]string{oidc.ScopeOpenID}, x.cfg.Scopes...)
+	return conf, x.provider, nil
+}
+
+// authCodeURL returns the URL users are sent to for signing in at the provider.
+func (x *upstream) authCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	conf, p, err := x.oauth(ctx)
+	if err != nil {
+		return "", err
+	}
+	opts := []oauth2.AuthCodeOption{oauth2.S256ChallengeOption(verifier)}
+	if p != nil {
+		opts = append(opts, oidc.Nonce(nonce))
+	}
+	return conf.AuthCodeURL(state, opts...), nil
+}
+
+// exchange redeems the code the provider redirected back with and returns the mapped claims of the user,
+// from the verified ID token of OpenID Connect providers or the user info endpoint of OAuth 2.0 providers.
+// Returns [ErrUpstream] if the provider rejects the code or its response can not be trusted.
+func (x *upstream) exchange(ctx context.Context, code, verifier, nonce string) (*claims, error) {
+	conf, p, err := x.oauth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := conf.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w, exchanging code, %w", ErrUpstream, err)
+	}
+
+	var raw map[string]any
+	if p != nil {
+		s, ok := t.Extra("id_token").(string)
+		if !ok {
+			return nil, fmt.Errorf("%w, no ID token", ErrUpstream)
+		}
+		idToken, err := p.Verifier(&oidc.Config{ClientID: x.cfg.ClientID}).Verify(ctx, s)
+		if err != nil {
+			return nil, fmt.Errorf("%w, verifying ID token, %w", ErrUpstream, err)
+		}
+		if idToken.Nonce != nonce {
+			return nil, fmt.Errorf("%w, ID token nonce does not match", ErrUpstream)
+		}
+		if err = idToken.Claims(&raw); err != nil {
+			return nil, fmt.Errorf("%w, reading ID token claims, %w", ErrUpstream, err)
+		}
+	} else {
+		if raw, err = x.userInfo(ctx, conf, t); err != nil {
+			return nil, err
+		}
+	}
+
+	return x.mapClaims(raw)
+}
+
+// userInfo reads the claims of the user from the user info endpoint of an OAuth 2.0 provider.
+func (x *upstream) userInfo(ctx context.Context, conf *oauth2.Config, t *oauth2.Token) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, x.cfg.UserInfoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("federated: creating user info request, %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := conf.Client(ctx, t).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w, requesting user info, %w", ErrUpstream, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w, user info responded with %s", ErrUpstream, resp.Status)
+	}
+
+	// Numbers are kept as they are, subjects such as GitHub user IDs do not always fit in a float64.
+	d := json.NewDecoder(resp.Body)
+	d.UseNumber()
+	var raw map[string]any
+	if err = d.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w, decoding user info, %w", ErrUpstream, err)
+	}
+	return raw, nil
+}
+
+// mapClaims reads the configured claims. Returns [ErrUpstream] if there is no subject.
+func (x *upstream) mapClaims(raw map[string]any) (*claims, error) {
+	c := &claims{
+		Subject: stringClaim(raw[x.claims.Subject]),
+		Email:   stringClaim(raw[x.claims.Email]),
+	}
+	if c.Subject == "" {
+		return nil, fmt.Errorf("%w, no %s claim", ErrUpstream, x.claims.Subject)
+	}
+	switch v := raw[x.claims.EmailVerified].(type) {
+	case bool:
+		c.EmailVerified = v
+	case string:
+		c.EmailVerified, _ = strconv.ParseBool(v)
+	}
+	return c, nil
+}
+
+func stringClaim(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,144 @@
+package federated
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestUpstream_OIDC(t *testing.T) {
+	x := newIdP(t)
+	u, err := newUpstream(x.oidcProvider("idp"))
+	require.NoError(t, err)
+
+	x.signIn(user{subject: "alice", email: "alice@example.com", emailVerified: true})
+
+	t.Run("Claims of the verified ID token are mapped", func(t *testing.T) {
+		verifier := oauth2.GenerateVerifier()
+		authURL, err := u.authCodeURL(context.Background(), "state", "nonce", verifier)
+		require.NoError(t, err)
+		require.Contains(t, authURL, "scope=openid+email")
+
+		state, code := x.redirect(t, authURL)
+		require.Equal(t, "state", state)
+
+		c, err := u.exchange(context.Background(), code, verifier, "nonce")
+		require.NoError(t, err)
+		require.Equal(t, &claims{Subject: "alice", Email: "alice@example.com", EmailVerified: true}, c)
+	})
+
+	t.Run("Code verifier must match the challenge", func(t *testing.T) {
+		authURL, err := u.authCodeURL(context.Background(), "state", "nonce", oauth2.GenerateVerifier())
+		require.NoError(t, err)
+		_, code := x.redirect(t, authURL)
+
+		_, err = u.exchange(context.Background(), code, oauth2.GenerateVerifier(), "nonce")
+		require.ErrorIs(t, err, ErrUpstream)
+	})
+
+	t.Run("Nonce of the ID token must match", func(t *testing.T) {
+		x.mu.Lock()
+		x.nonce = "replayed"
+		x.mu.Unlock()
+		t.Cleanup(func() {
+			x.mu.Lock()
+			x.nonce = ""
+			x.mu.Unlock()
+		})
+
+		verifier := oauth2.GenerateVerifier()
+		authURL, err := u.authCodeURL(context.Background(), "state", "nonce", verifier)
+		require.NoError(t, err)
+		_, code := x.redirect(t, authURL)
+
+		_, err = u.exchange(context.Background(), code, verifier, "nonce")
+		require.ErrorIs(t, err, ErrUpstream)
+	})
+
+	t.Run("ID token must be signed by the issuer", func(t *testing.T) {
+		x.mu.Lock()
+		signer := x.signer
+		x.signer = newIdP(t).signer
+		x.mu.Unlock()
+		t.Cleanup(func() {
+			x.mu.Lock()
+			x.signer = signer
+			x.mu.Unlock()
+		})
+
+		verifier := oauth2.GenerateVerifier()
+		authURL, err := u.authCodeURL(context.Background(), "state", "nonce", verifier)
+		require.NoError(t, err)
+		_, code := x.redirect(t, authURL)
+
+		_, err = u.exchange(context.Background(), code, verifier, "nonce")
+		require.ErrorIs(t, err, ErrUpstream)
+	})
+}
+
+func TestUpstream_OAuth2(t *testing.T) {
+	x := newIdP(t)
+	u, err := newUpstream(x.oauthProvider("github"))
+	require.NoError(t, err)
+
+	t.Run("Numeric subject keeps its precision", func(t *testing.T) {
+		x.signIn(user{subject: "9007199254740993", email: "bob@example.com"})
+
+		verifier := oauth2.GenerateVerifier()
+		authURL, err := u.authCodeURL(context.Background(), "state", "nonce", verifier)
+		require.NoError(t, err)
+		require.NotContains(t, authURL, "nonce=")
+
+		_, code := x.redirect(t, authURL)
+		c, err := u.exchange(context.Background(), code, verifier, "nonce")
+		require.NoError(t, err)
+		require.Equal(t, &claims{Subject: "9007199254740993", Email: "bob@example.com"}, c)
+	})
+
+	t.Run("Missing subject is rejected", func(t *testing.T) {
+		u, err := newUpstream(config.FederatedProvider{
+			Name:         "github",
+			AuthURL:      x.srv.URL + "/authorize",
+			TokenURL:     x.srv.URL + "/token",
+			UserInfoURL:  x.srv.URL + "/userinfo",
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Claims:       config.Claims{Subject: "login"},
+		})
+		require.NoError(t, err)
+		x.signIn(user{subject: "1", email: "bob@example.com"})
+
+		verifier := oauth2.GenerateVerifier()
+		authURL, err := u.authCodeURL(context.Background(), "state", "nonce", verifier)
+		require.NoError(t, err)
+		_, code := x.redirect(t, authURL)
+
+		_, err = u.exchange(context.Background(), code, verifier, "nonce")
+		require.ErrorIs(t, err, ErrUpstream)
+	})
+}
+
+func TestNewUpstream(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  config.FederatedProvider
+	}{
+		{name: "No name", cfg: config.FederatedProvider{ClientID: "id", RedirectURL: redirectURL, Issuer: "https://idp.example.com"}},
+		{name: "No client ID", cfg: config.FederatedProvider{Name: "idp", RedirectURL: redirectURL, Issuer: "https://idp.example.com"}},
+		{name: "No redirect URL", cfg: config.FederatedProvider{Name: "idp", ClientID: "id", Issuer: "https://idp.example.com"}},
+		{name: "No issuer nor endpoints", cfg: config.FederatedProvider{Name: "idp", ClientID: "id", RedirectURL: redirectURL, AuthURL: "https://idp.example.com/authorize"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newUpstream(tc.cfg)
+			require.Error(t, err)
+		})
+	}
+
+	u, err := newUpstream(config.FederatedProvider{Name: "idp", ClientID: "id", RedirectURL: redirectURL, Issuer: "https://idp.example.com"})
+	require.NoError(t, err)
+	require.Equal(t, config.Claims{Subject: "sub", Email: "email", EmailVerified: "email_verified"}, u.claims)
+}
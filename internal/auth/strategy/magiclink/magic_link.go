@@ -0,0 +1,199 @@
+package magiclink
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/magiclink"
+	"github.com/Salam4nder/identity/internal/email"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/pkg/validation"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tokenSize is the amount of random bytes in a sign-in token.
+const tokenSize = 32
+
+var (
+	tracer = otel.Tracer("magiclink")
+
+	ErrInvalidEmail = errors.New("magiclink: invalid email")
+	ErrInvalidToken = errors.New("magiclink: token is invalid, expired or already used")
+)
+
+type (
+	// Strategy implements the [Strategy] interface and has everything
+	// to be able to [Register] and [Authenticate] with one-time sign-in links.
+	Strategy struct {
+		db       *sql.DB
+		natsConn *nats.Conn
+		cfg      config.MagicLink
+	}
+
+	// Input is the email on [Strategy.Register] and the
+	// token from the sign-in link on [Strategy.Authenticate].
+	Input struct {
+		Email, Token string
+	}
+
+	Output struct {
+		Email string
+		// Created reports whether [Strategy.Register] created a user, it did not if the email is taken.
+		Created bool
+	}
+)
+
+// New creates a new [Strategy] for authentication.
+func New(db *sql.DB, natsConn *nats.Conn, cfg config.MagicLink) *Strategy {
+	return &Strategy{db: db, natsConn: natsConn, cfg: cfg}
+}
+
+// Register inserts a new passwordless user and emails them their first sign-in link.
+// If the email is taken, its owner is emailed a sign-in link instead and the registration
+// succeeds all the same, so that callers can not learn which emails are registered.
+// Possible errors are [ErrInvalidEmail] and a wrapped error indicating an internal error.
+func (x *Strategy) Register(ctx context.Context, in Input) (Output, error) {
+	ctx, span := tracer.Start(ctx, "Register")
+	defer span.End()
+	span.SetAttributes(attribute.String("email", in.Email))
+
+	if err := validation.Email(in.Email); err != nil {
+		return Output{}, fmt.Errorf("%w, %w", ErrInvalidEmail, err)
+	}
+
+	switch _, err := magiclink.ReadUserByEmail(ctx, x.db, in.Email); {
+	case err == nil:
+		return x.emailTaken(ctx, in.Email)
+	case !errors.As(err, &database.NotFoundError{}):
+		return Output{}, fmt.Errorf("magiclink: reading user, %w", err)
+	}
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Output{}, fmt.Errorf("magiclink: beginning transaction, %w", err)
+	}
+	defer func() {
+		if err != nil {
+			slog.ErrorContext(ctx, "magiclink: error occurred, rolling back", "err", err)
+			if err := tx.Rollback(); err != nil {
+				slog.ErrorContext(ctx, "magiclink: failed rollback", "err", err)
+			}
+		}
+	}()
+
+	id := uuid.New()
+	if err = magiclink.InsertUser(ctx, tx, magiclink.User{
+		ID:        id,
+		Email:     in.Email,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		if errors.As(err, &database.DuplicateEntryError{}) {
+			// Registered concurrently since it was read.
+			return x.emailTaken(ctx, in.Email)
+		}
+		return Output{}, err
+	}
+
+	if err = x.sendLink(ctx, tx, id, in.Email); err != nil {
+		return Output{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return Output{}, fmt.Errorf("magiclink: committing transaction, %w", err)
+	}
+
+	return Output{Email: in.Email, Created: true}, nil
+}
+
+// emailTaken emails a sign-in link to the owner of a registered email, as registering does,
+// and reports the registration as successful.
+func (x *Strategy) emailTaken(ctx context.Context, emailAddr string) (Output, error) {
+	slog.InfoContext(ctx, "magiclink: registration requested for taken email")
+	if err := x.RequestLink(ctx, emailAddr); err != nil {
+		return Output{}, err
+	}
+	return Output{Email: emailAddr}, nil
+}
+
+// RequestLink emails a new sign-in link to the user with the given email.
+// Unknown emails are silently ignored so that callers can not
+// learn which emails are registered.
+func (x *Strategy) RequestLink(ctx context.Context, emailAddr string) error {
+	ctx, span := tracer.Start(ctx, "RequestLink")
+	defer span.End()
+	span.SetAttributes(attribute.String("email", emailAddr))
+
+	u, err := magiclink.ReadUserByEmail(ctx, x.db, emailAddr)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
+			slog.InfoContext(ctx, "magiclink: link requested for unknown email")
+			return nil
+		}
+		return fmt.Errorf("magiclink: reading user, %w", err)
+	}
+
+	return x.sendLink(ctx, x.db, u.ID, u.Email)
+}
+
+// Authenticate redeems the token of a sign-in link.
+// Possible errors are [ErrInvalidToken] and a wrapped error indicating an internal error.
+func (x *Strategy) Authenticate(ctx context.Context, in Input) (Output, error) {
+	ctx, span := tracer.Start(ctx, "Authenticate")
+	defer span.End()
+
+	e, err := x.Redeem(ctx, in.Token)
+	if err != nil {
+		return Output{}, err
+	}
+	return Output{Email: e}, nil
+}
+
+// Redeem consumes the token of a sign-in link and returns the email of its owner.
+// Possible errors are [ErrInvalidToken] and a wrapped error indicating an internal error.
+func (x *Strategy) Redeem(ctx context.Context, token string) (string, error) {
+	ctx, span := tracer.Start(ctx, "Redeem")
+	defer span.End()
+
+	id, err := magiclink.TakeToken(ctx, x.db, token)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
+			return "", ErrInvalidToken
+		}
+		return "", fmt.Errorf("magiclink: taking token, %w", err)
+	}
+
+	u, err := magiclink.ReadUser(ctx, x.db, id)
+	if err != nil {
+		return "", fmt.Errorf("magiclink: reading user, %w", err)
+	}
+	span.SetAttributes(attribute.String("email", u.Email))
+
+	return u.Email, nil
+}
+
+func (x *Strategy) sendLink(ctx context.Context, db database.Querier, id uuid.UUID, to string) error {
+	t, err := random.Token(tokenSize)
+	if err != nil {
+		return fmt.Errorf("magiclink: generating token, %w", err)
+	}
+
+	if err = magiclink.InsertToken(ctx, db, t, id, time.Now().Add(x.cfg.TTL)); err != nil {
+		return err
+	}
+
+	return email.Ingest(ctx, x.natsConn, email.Email{
+		To:      to,
+		From:    email.TestFrom,
+		Subject: email.MagicLinkSubject,
+		Body:    email.MagicLink(x.cfg.Origin, t, x.cfg.TTL),
+	})
+}
@@ -0,0 +1,58 @@
+package magiclink
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+var _ auth.Strategy[Input, Output] = (*Strategy)(nil)
+
+func init() {
+	auth.Register(auth.Registration[*Strategy, Input, Output]{
+		Info: auth.Info{
+			Name:       auth.StrategyMagicLink,
+			Type:       gen.Strategy_TypeMagicLink,
+			Identifier: auth.IdentifierString,
+		},
+		New: func(d auth.Deps) (*Strategy, error) {
+			return New(d.DB, d.NATS, d.Config.MagicLink), nil
+		},
+		Register:     register,
+		Authenticate: authenticate,
+	})
+}
+
+func register(ctx context.Context, s *Strategy, req *gen.RegisterRequest) (auth.Registered, error) {
+	out, err := s.Register(ctx, Input{Email: req.GetMagicLink().GetEmail()})
+	if err != nil {
+		if errors.Is(err, ErrInvalidEmail) {
+			e := &auth.Error{Code: codes.InvalidArgument, Message: "invalid email", Err: err}
+			e.Fields = append(e.Fields, &errdetails.BadRequest_FieldViolation{
+				Field:       "magic_link.email",
+				Description: "must be a valid email address",
+			})
+			return auth.Registered{}, e
+		}
+		return auth.Registered{}, err
+	}
+	return auth.Registered{
+		Response: &gen.RegisterResponse{Data: &gen.RegisterResponse_MagicLink{MagicLink: &gen.MagicLink{Email: out.Email}}},
+		Created:  out.Created,
+	}, nil
+}
+
+func authenticate(ctx context.Context, s *Strategy, req *gen.AuthenticateRequest) (auth.Identity, error) {
+	out, err := s.Authenticate(ctx, Input{Token: req.GetMagicLink().GetToken()})
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return auth.Identity{}, auth.NewError(codes.Unauthenticated, err, "incorrect token")
+		}
+		return auth.Identity{}, err
+	}
+	return auth.Identity{Strategy: gen.Strategy_TypeMagicLink, Identifier: out.Email}, nil
+}
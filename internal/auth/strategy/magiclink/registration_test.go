@@ -0,0 +1,30 @@
+package magiclink_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/strategy/magiclink"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRegisterInvalidEmail(t *testing.T) {
+	m, err := auth.Mount(auth.StrategyMagicLink, auth.Deps{Config: &config.Application{}})
+	require.NoError(t, err)
+
+	_, err = m.Register(context.Background(), &gen.RegisterRequest{
+		Strategy: gen.Strategy_TypeMagicLink,
+		Data:     &gen.RegisterRequest_MagicLink{MagicLink: &gen.MagicLink{Email: "not an email"}},
+	})
+	var e *auth.Error
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, codes.InvalidArgument, e.Code)
+	require.ErrorIs(t, err, magiclink.ErrInvalidEmail)
+	require.Len(t, e.Fields, 1)
+	require.Equal(t, "magic_link.email", e.Fields[0].GetField())
+}
@@ -15,84 +15,50 @@ import (
 var (
 	tracer = otel.Tracer("personalnumber")
 
-	inputKey  ctxKey
-	outputKey ctxKey
-
 	ErrNumberNotFound = errors.New("personalnumber: number not found")
 )
 
 // Strategy implements the [Strategy] interface and has everything
 // to be able to [Register], [Authenticate] and [Revoke]
 // with a personal number.
-type (
-	ctxKey int
-
-	Strategy struct {
-		db *sql.DB
-	}
-)
+type Strategy struct {
+	db *sql.DB
+}
 
 func New(db *sql.DB) *Strategy {
 	return &Strategy{db: db}
 }
 
-func NewContext(ctx context.Context, n uint64) context.Context {
-	return context.WithValue(ctx, inputKey, n)
-}
-
-func FromContext(ctx context.Context) (uint64, error) {
-	c, ok := ctx.Value(outputKey).(uint64)
-	if !ok {
-		return 0, errors.New("personalnumber: getting personal_number from context")
-	}
-	return c, nil
-}
-
-func (x *Strategy) Register(ctx context.Context) (context.Context, error) {
+// Register generates a new personal number and returns it, the input is not used.
+func (x *Strategy) Register(ctx context.Context, _ uint64) (uint64, error) {
 	ctx, span := tracer.Start(ctx, "Register")
 	defer span.End()
 
 	n, err := random.UINT64()
 	if err != nil {
-		return ctx, err
+		return 0, err
 	}
 	span.SetAttributes(attribute.Int64("generated_number", int64(n)))
 
 	if err := personalnumber.Insert(ctx, x.db, n); err != nil {
-		return ctx, err
+		return 0, err
 	}
 
-	return newContext(ctx, n), nil
+	return n, nil
 }
 
-func (x *Strategy) Authenticate(ctx context.Context) error {
+// Authenticate checks that the personal number exists and returns it.
+// Possible errors are [ErrNumberNotFound] and an error indicating an internal error.
+func (x *Strategy) Authenticate(ctx context.Context, n uint64) (uint64, error) {
 	ctx, span := tracer.Start(ctx, "Authenticate")
 	defer span.End()
-
-	n, err := fromContext(ctx)
-	if err != nil {
-		return err
-	}
 	span.SetAttributes(attribute.Int64("number", int64(n)))
 
-	_, err = personalnumber.Get(ctx, x.db, n)
-	if err != nil {
+	if _, err := personalnumber.Get(ctx, x.db, n); err != nil {
 		if errors.As(err, &database.NotFoundError{}) {
-			return ErrNumberNotFound
+			return 0, ErrNumberNotFound
 		}
-		return err
-	}
-	return nil
-}
-
-func newContext(ctx context.Context, n uint64) context.Context {
-	return context.WithValue(ctx, outputKey, n)
-}
-
-func fromContext(ctx context.Context) (uint64, error) {
-	v, ok := ctx.Value(inputKey).(uint64)
-	if !ok {
-		return 0, errors.New("personalnumber: getting number from context")
+		return 0, err
 	}
-	return v, nil
+	return n, nil
 }
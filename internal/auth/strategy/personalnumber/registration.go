@@ -0,0 +1,49 @@
+package personalnumber
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/grpc/codes"
+)
+
+var _ auth.Strategy[uint64, uint64] = (*Strategy)(nil)
+
+func init() {
+	auth.Register(auth.Registration[*Strategy, uint64, uint64]{
+		Info: auth.Info{
+			Name:       auth.StrategyPersonalNumber,
+			Type:       gen.Strategy_TypePersonalNumber,
+			Identifier: auth.IdentifierUint64,
+		},
+		New: func(d auth.Deps) (*Strategy, error) {
+			return New(d.DB), nil
+		},
+		Register:     register,
+		Authenticate: authenticate,
+	})
+}
+
+func register(ctx context.Context, s *Strategy, _ *gen.RegisterRequest) (auth.Registered, error) {
+	n, err := s.Register(ctx, 0)
+	if err != nil {
+		return auth.Registered{}, err
+	}
+	return auth.Registered{
+		Response: &gen.RegisterResponse{Data: &gen.RegisterResponse_Number{Number: &gen.PersonalNumber{Number: n}}},
+		Created:  true,
+	}, nil
+}
+
+func authenticate(ctx context.Context, s *Strategy, req *gen.AuthenticateRequest) (auth.Identity, error) {
+	n, err := s.Authenticate(ctx, req.GetNumber().GetNumber())
+	if err != nil {
+		if errors.Is(err, ErrNumberNotFound) {
+			return auth.Identity{}, auth.NewError(codes.InvalidArgument, err, err.Error())
+		}
+		return auth.Identity{}, err
+	}
+	return auth.Identity{Strategy: gen.Strategy_TypePersonalNumber, Identifier: n}, nil
+}
@@ -0,0 +1,12 @@
+// Package strategy registers the built-in strategies with [auth.Register].
+// Import it for its side effects wherever strategies are mounted or looked up by name.
+package strategy
+
+import (
+	_ "github.com/Salam4nder/identity/internal/auth/strategy/clientcredentials"
+	_ "github.com/Salam4nder/identity/internal/auth/strategy/credentials"
+	_ "github.com/Salam4nder/identity/internal/auth/strategy/federated"
+	_ "github.com/Salam4nder/identity/internal/auth/strategy/magiclink"
+	_ "github.com/Salam4nder/identity/internal/auth/strategy/personalnumber"
+	_ "github.com/Salam4nder/identity/internal/auth/strategy/webauthn"
+)
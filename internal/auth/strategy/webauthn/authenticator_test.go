@@ -0,0 +1,150 @@
+//go:build testdb
+// +build testdb
+
+package webauthn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+var b64 = base64.RawURLEncoding
+
+// authenticator is a software authenticator producing "none" attestations
+// with an ES256 key, so the full ceremony can be exercised offline.
+type authenticator struct {
+	origin       string
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	aaguid       []byte
+	signCount    uint32
+}
+
+func newAuthenticator(t *testing.T, origin string) *authenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	credentialID := make([]byte, 32)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+	aaguid := make([]byte, 16)
+	_, err = rand.Read(aaguid)
+	require.NoError(t, err)
+
+	return &authenticator{
+		origin:       origin,
+		key:          key,
+		credentialID: credentialID,
+		aaguid:       aaguid,
+	}
+}
+
+// create answers the options returned by BeginRegistration like navigator.credentials.create() would.
+func (x *authenticator) create(t *testing.T, options []byte) []byte {
+	t.Helper()
+
+	var creation protocol.CredentialCreation
+	require.NoError(t, json.Unmarshal(options, &creation))
+
+	clientData := x.clientData(t, "webauthn.create", creation.Response.Challenge)
+
+	coseKey, err := cbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: x.key.X.FillBytes(make([]byte, 32)),
+		-3: x.key.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+
+	authData := x.authData(creation.Response.RelyingParty.ID, flagUserPresent|flagUserVerified|flagAttested)
+	authData = append(authData, x.aaguid...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(x.credentialID)))
+	authData = append(authData, x.credentialID...)
+	authData = append(authData, coseKey...)
+
+	attestationObject, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	require.NoError(t, err)
+
+	b, err := json.Marshal(map[string]any{
+		"id":    b64.EncodeToString(x.credentialID),
+		"rawId": b64.EncodeToString(x.credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64.EncodeToString(clientData),
+			"attestationObject": b64.EncodeToString(attestationObject),
+		},
+	})
+	require.NoError(t, err)
+	return b
+}
+
+// get answers the options returned by BeginLogin like navigator.credentials.get() would.
+func (x *authenticator) get(t *testing.T, options []byte) []byte {
+	t.Helper()
+
+	var assertion protocol.CredentialAssertion
+	require.NoError(t, json.Unmarshal(options, &assertion))
+
+	x.signCount++
+	clientData := x.clientData(t, "webauthn.get", assertion.Response.Challenge)
+	authData := x.authData(assertion.Response.RelyingPartyID, flagUserPresent|flagUserVerified)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, x.key, digest[:])
+	require.NoError(t, err)
+
+	b, err := json.Marshal(map[string]any{
+		"id":    b64.EncodeToString(x.credentialID),
+		"rawId": b64.EncodeToString(x.credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64.EncodeToString(clientData),
+			"authenticatorData": b64.EncodeToString(authData),
+			"signature":         b64.EncodeToString(signature),
+		},
+	})
+	require.NoError(t, err)
+	return b
+}
+
+func (x *authenticator) clientData(t *testing.T, typ string, challenge []byte) []byte {
+	t.Helper()
+
+	b, err := json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": b64.EncodeToString(challenge),
+		"origin":    x.origin,
+	})
+	require.NoError(t, err)
+	return b
+}
+
+func (x *authenticator) authData(rpID string, flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	b := append([]byte{}, rpIDHash[:]...)
+	b = append(b, flags)
+	return binary.BigEndian.AppendUint32(b, x.signCount)
+}
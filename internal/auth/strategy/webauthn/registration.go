@@ -0,0 +1,69 @@
+package webauthn
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/grpc/codes"
+)
+
+var _ auth.Strategy[Input, Output] = (*Strategy)(nil)
+
+func init() {
+	auth.Register(auth.Registration[*Strategy, Input, Output]{
+		Info: auth.Info{
+			Name:       auth.StrategyWebAuthn,
+			Type:       gen.Strategy_TypeWebAuthn,
+			Identifier: auth.IdentifierString,
+		},
+		New: func(d auth.Deps) (*Strategy, error) {
+			return New(d.DB, d.Config.WebAuthn)
+		},
+		Register:     register,
+		Authenticate: authenticate,
+	})
+}
+
+func input(in *gen.WebAuthnInput) Input {
+	return Input{
+		Name:      in.GetName(),
+		SessionID: in.GetSessionId(),
+		Response:  in.GetResponse(),
+	}
+}
+
+func register(ctx context.Context, s *Strategy, req *gen.RegisterRequest) (auth.Registered, error) {
+	out, err := s.Register(ctx, input(req.GetWebauthn()))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNameTaken):
+			return auth.Registered{}, auth.NewError(codes.AlreadyExists, err, "name is already taken")
+		case errors.Is(err, ErrSessionNotFound), errors.Is(err, ErrVerificationFailed):
+			return auth.Registered{}, auth.NewError(codes.InvalidArgument, err, err.Error())
+		default:
+			return auth.Registered{}, err
+		}
+	}
+	return auth.Registered{
+		Response: &gen.RegisterResponse{Data: &gen.RegisterResponse_Webauthn{Webauthn: &gen.WebAuthnOutput{Name: out.Name}}},
+		Created:  true,
+	}, nil
+}
+
+func authenticate(ctx context.Context, s *Strategy, req *gen.AuthenticateRequest) (auth.Identity, error) {
+	out, err := s.Authenticate(ctx, input(req.GetWebauthn()))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound),
+			errors.Is(err, ErrSessionNotFound),
+			errors.Is(err, ErrVerificationFailed),
+			errors.Is(err, ErrClonedCredential):
+			return auth.Identity{}, auth.NewError(codes.Unauthenticated, err, err.Error())
+		default:
+			return auth.Identity{}, err
+		}
+	}
+	return auth.Identity{Strategy: gen.Strategy_TypeWebAuthn, Identifier: out.Name}, nil
+}
@@ -0,0 +1,36 @@
+package webauthn
+
+import (
+	gowebauthn "github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+var _ gowebauthn.User = (*user)(nil)
+
+// user adapts a passkey user to the [gowebauthn.User] interface.
+type user struct {
+	id          uuid.UUID
+	name        string
+	credentials []gowebauthn.Credential
+}
+
+func (x *user) WebAuthnID() []byte {
+	b := x.id
+	return b[:]
+}
+
+func (x *user) WebAuthnName() string {
+	return x.name
+}
+
+func (x *user) WebAuthnDisplayName() string {
+	return x.name
+}
+
+func (x *user) WebAuthnCredentials() []gowebauthn.Credential {
+	return x.credentials
+}
+
+func (x *user) WebAuthnIcon() string {
+	return ""
+}
@@ -0,0 +1,344 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	webauthndb "github.com/Salam4nder/identity/internal/database/webauthn"
+	"github.com/go-webauthn/webauthn/protocol"
+	gowebauthn "github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// sessionTimeout bounds how long a ceremony may take between begin and finish.
+const sessionTimeout = 5 * time.Minute
+
+var (
+	tracer = otel.Tracer("webauthn")
+
+	ErrUserNotFound       = errors.New("webauthn: user does not exist")
+	ErrNameTaken          = errors.New("webauthn: name is already taken")
+	ErrSessionNotFound    = errors.New("webauthn: session does not exist or has expired")
+	ErrVerificationFailed = errors.New("webauthn: verifying authenticator response failed")
+	ErrClonedCredential   = errors.New("webauthn: sign counter did not increase, credential may be cloned")
+)
+
+type (
+	// Strategy implements the [Strategy] interface and has everything
+	// to be able to [Register] and [Authenticate] with passkeys.
+	// Both are the finishing halves of a ceremony started with
+	// [Strategy.BeginRegistration] or [Strategy.BeginLogin].
+	Strategy struct {
+		db       *sql.DB
+		webAuthn *gowebauthn.WebAuthn
+	}
+
+	// Input is the response of the authenticator to a started ceremony.
+	Input struct {
+		Name      string
+		SessionID string
+		// Response is the JSON serialized PublicKeyCredential returned by
+		// navigator.credentials.create() or navigator.credentials.get().
+		Response []byte
+	}
+
+	Output struct {
+		Name string
+	}
+
+	// session is stored between the begin and the finish of a ceremony.
+	session struct {
+		Name string                 `json:"name"`
+		Data gowebauthn.SessionData `json:"data"`
+	}
+)
+
+// New creates a new [Strategy] for authentication.
+func New(db *sql.DB, cfg config.WebAuthn) (*Strategy, error) {
+	w, err := gowebauthn.New(&gowebauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		Timeouts: gowebauthn.TimeoutsConfig{
+			Login: gowebauthn.TimeoutConfig{
+				Enforce:    true,
+				Timeout:    sessionTimeout,
+				TimeoutUVD: sessionTimeout,
+			},
+			Registration: gowebauthn.TimeoutConfig{
+				Enforce:    true,
+				Timeout:    sessionTimeout,
+				TimeoutUVD: sessionTimeout,
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: creating relying party, %w", err)
+	}
+	return &Strategy{db: db, webAuthn: w}, nil
+}
+
+// BeginRegistration starts a registration ceremony for a new passkey user with the given name.
+// It returns the JSON serialized PublicKeyCredentialCreationOptions for the client
+// and the ID of the session that must be passed to [Strategy.Register].
+func (x *Strategy) BeginRegistration(ctx context.Context, name string) ([]byte, string, error) {
+	ctx, span := tracer.Start(ctx, "BeginRegistration")
+	defer span.End()
+	span.SetAttributes(attribute.String("name", name))
+
+	if name == "" {
+		return nil, "", database.NewInputError(ctx, errors.New("webauthn: name is empty"), "name", name)
+	}
+	_, err := webauthndb.ReadUserByName(ctx, x.db, name)
+	if err == nil {
+		return nil, "", ErrNameTaken
+	}
+	if !errors.As(err, &database.NotFoundError{}) {
+		return nil, "", fmt.Errorf("webauthn: reading user, %w", err)
+	}
+
+	u := &user{id: uuid.New(), name: name}
+	creation, data, err := x.webAuthn.BeginRegistration(
+		u,
+		gowebauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("webauthn: beginning registration, %w", err)
+	}
+
+	return x.begin(ctx, name, creation, data)
+}
+
+// Register finishes a registration ceremony. It will insert a new user and
+// their credential public key, sign counter and AAGUID.
+func (x *Strategy) Register(ctx context.Context, in Input) (Output, error) {
+	ctx, span := tracer.Start(ctx, "Register")
+	defer span.End()
+	span.SetAttributes(attribute.String("name", in.Name))
+
+	s, err := x.takeSession(ctx, in)
+	if err != nil {
+		return Output{}, err
+	}
+	id, err := uuid.FromBytes(s.Data.UserID)
+	if err != nil {
+		return Output{}, fmt.Errorf("webauthn: parsing user id, %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(in.Response))
+	if err != nil {
+		return Output{}, fmt.Errorf("%w, %w", ErrVerificationFailed, err)
+	}
+	u := &user{id: id, name: s.Name}
+	cred, err := x.webAuthn.CreateCredential(u, s.Data, parsed)
+	if err != nil {
+		return Output{}, fmt.Errorf("%w, %w", ErrVerificationFailed, err)
+	}
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Output{}, fmt.Errorf("webauthn: beginning transaction, %w", err)
+	}
+	defer func() {
+		if err != nil {
+			slog.ErrorContext(ctx, "webauthn: error occurred, rolling back", "err", err)
+			if err := tx.Rollback(); err != nil {
+				slog.ErrorContext(ctx, "webauthn: failed rollback", "err", err)
+			}
+		}
+	}()
+
+	now := time.Now()
+	if err = webauthndb.InsertUser(ctx, tx, webauthndb.User{
+		ID:        id,
+		Name:      s.Name,
+		CreatedAt: now,
+	}); err != nil {
+		if errors.As(err, &database.DuplicateEntryError{}) {
+			return Output{}, ErrNameTaken
+		}
+		return Output{}, err
+	}
+
+	transports := make([]string, 0, len(cred.Transport))
+	for _, t := range cred.Transport {
+		transports = append(transports, string(t))
+	}
+	if err = webauthndb.InsertCredential(ctx, tx, webauthndb.Credential{
+		ID:              cred.ID,
+		UserID:          id,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		AAGUID:          cred.Authenticator.AAGUID,
+		SignCount:       cred.Authenticator.SignCount,
+		Transports:      transports,
+		BackupEligible:  cred.Flags.BackupEligible,
+		BackupState:     cred.Flags.BackupState,
+		CreatedAt:       now,
+	}); err != nil {
+		return Output{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return Output{}, fmt.Errorf("webauthn: committing transaction, %w", err)
+	}
+
+	return Output{Name: s.Name}, nil
+}
+
+// BeginLogin starts an assertion ceremony for the passkey user with the given name.
+// It returns the JSON serialized PublicKeyCredentialRequestOptions for the client
+// and the ID of the session that must be passed to [Strategy.Authenticate].
+func (x *Strategy) BeginLogin(ctx context.Context, name string) ([]byte, string, error) {
+	ctx, span := tracer.Start(ctx, "BeginLogin")
+	defer span.End()
+	span.SetAttributes(attribute.String("name", name))
+
+	u, err := x.readUser(ctx, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	assertion, data, err := x.webAuthn.BeginLogin(u)
+	if err != nil {
+		return nil, "", fmt.Errorf("webauthn: beginning login, %w", err)
+	}
+
+	return x.begin(ctx, name, assertion, data)
+}
+
+// Authenticate finishes an assertion ceremony.
+// Possible errors are [ErrUserNotFound], [ErrSessionNotFound], [ErrVerificationFailed],
+// [ErrClonedCredential] and a wrapped error indicating an internal error.
+func (x *Strategy) Authenticate(ctx context.Context, in Input) (Output, error) {
+	ctx, span := tracer.Start(ctx, "Authenticate")
+	defer span.End()
+	span.SetAttributes(attribute.String("name", in.Name))
+
+	s, err := x.takeSession(ctx, in)
+	if err != nil {
+		return Output{}, err
+	}
+	u, err := x.readUser(ctx, s.Name)
+	if err != nil {
+		return Output{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(in.Response))
+	if err != nil {
+		return Output{}, fmt.Errorf("%w, %w", ErrVerificationFailed, err)
+	}
+	cred, err := x.webAuthn.ValidateLogin(u, s.Data, parsed)
+	if err != nil {
+		return Output{}, fmt.Errorf("%w, %w", ErrVerificationFailed, err)
+	}
+	if cred.Authenticator.CloneWarning {
+		return Output{}, ErrClonedCredential
+	}
+
+	if err = webauthndb.UpdateCredentialUsage(
+		ctx,
+		x.db,
+		cred.ID,
+		cred.Authenticator.SignCount,
+		cred.Flags.BackupState,
+	); err != nil {
+		return Output{}, fmt.Errorf("webauthn: updating credential, %w", err)
+	}
+
+	return Output{Name: s.Name}, nil
+}
+
+func (x *Strategy) begin(ctx context.Context, name string, options any, data *gowebauthn.SessionData) ([]byte, string, error) {
+	b, err := json.Marshal(options)
+	if err != nil {
+		return nil, "", fmt.Errorf("webauthn: marshalling options, %w", err)
+	}
+	s, err := json.Marshal(session{Name: name, Data: *data})
+	if err != nil {
+		return nil, "", fmt.Errorf("webauthn: marshalling session, %w", err)
+	}
+
+	expiresAt := data.Expires
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(sessionTimeout)
+	}
+	id := uuid.New()
+	if err = webauthndb.InsertSession(ctx, x.db, id, s, expiresAt); err != nil {
+		return nil, "", fmt.Errorf("webauthn: storing session, %w", err)
+	}
+
+	return b, id.String(), nil
+}
+
+func (x *Strategy) takeSession(ctx context.Context, in Input) (*session, error) {
+	id, err := uuid.Parse(in.SessionID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	b, err := webauthndb.TakeSession(ctx, x.db, id)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("webauthn: reading session, %w", err)
+	}
+
+	var s session
+	if err = json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("webauthn: unmarshalling session, %w", err)
+	}
+	if s.Name != in.Name {
+		return nil, ErrSessionNotFound
+	}
+	return &s, nil
+}
+
+func (x *Strategy) readUser(ctx context.Context, name string) (*user, error) {
+	u, err := webauthndb.ReadUserByName(ctx, x.db, name)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("webauthn: reading user, %w", err)
+	}
+
+	creds, err := webauthndb.ListCredentials(ctx, x.db, u.ID)
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: listing credentials, %w", err)
+	}
+
+	res := &user{id: u.ID, name: u.Name, credentials: make([]gowebauthn.Credential, 0, len(creds))}
+	for _, c := range creds {
+		transports := make([]protocol.AuthenticatorTransport, 0, len(c.Transports))
+		for _, t := range c.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(t))
+		}
+		res.credentials = append(res.credentials, gowebauthn.Credential{
+			ID:              c.ID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: gowebauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: gowebauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,127 @@
+//go:build testdb
+// +build testdb
+
+package webauthn_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Salam4nder/identity/internal/auth/strategy/webauthn"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	webauthndb "github.com/Salam4nder/identity/internal/database/webauthn"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+const origin = "http://localhost:8080"
+
+func newStrategy(t *testing.T) (*webauthn.Strategy, func()) {
+	t.Helper()
+
+	db, cleanup := database.SetupTestConn(webauthndb.UsersTablename)
+	s, err := webauthn.New(db, config.WebAuthn{
+		RPID:          "localhost",
+		RPDisplayName: "Identity",
+		RPOrigins:     []string{origin},
+	})
+	require.NoError(t, err)
+	return s, cleanup
+}
+
+func register(t *testing.T, ctx context.Context, s *webauthn.Strategy, a *authenticator, name string) {
+	t.Helper()
+
+	options, sessionID, err := s.BeginRegistration(ctx, name)
+	require.NoError(t, err)
+
+	out, err := s.Register(ctx, webauthn.Input{
+		Name:      name,
+		SessionID: sessionID,
+		Response:  a.create(t, options),
+	})
+	require.NoError(t, err)
+	require.Equal(t, name, out.Name)
+}
+
+func TestCeremony(t *testing.T) {
+	ctx := context.Background()
+	s, cleanup := newStrategy(t)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		a := newAuthenticator(t, origin)
+		name := random.String(10)
+		register(t, ctx, s, a, name)
+
+		options, sessionID, err := s.BeginLogin(ctx, name)
+		require.NoError(t, err)
+
+		in := webauthn.Input{
+			Name:      name,
+			SessionID: sessionID,
+			Response:  a.get(t, options),
+		}
+		out, err := s.Authenticate(ctx, in)
+		require.NoError(t, err)
+		require.Equal(t, name, out.Name)
+
+		t.Run("session can not be replayed", func(t *testing.T) {
+			_, err := s.Authenticate(ctx, in)
+			require.ErrorIs(t, err, webauthn.ErrSessionNotFound)
+		})
+	})
+
+	t.Run("name taken", func(t *testing.T) {
+		name := random.String(10)
+		register(t, ctx, s, newAuthenticator(t, origin), name)
+
+		_, _, err := s.BeginRegistration(ctx, name)
+		require.ErrorIs(t, err, webauthn.ErrNameTaken)
+	})
+
+	t.Run("wrong origin", func(t *testing.T) {
+		name := random.String(10)
+		options, sessionID, err := s.BeginRegistration(ctx, name)
+		require.NoError(t, err)
+
+		_, err = s.Register(ctx, webauthn.Input{
+			Name:      name,
+			SessionID: sessionID,
+			Response:  newAuthenticator(t, "https://evil.com").create(t, options),
+		})
+		require.ErrorIs(t, err, webauthn.ErrVerificationFailed)
+	})
+
+	t.Run("cloned authenticator", func(t *testing.T) {
+		a := newAuthenticator(t, origin)
+		name := random.String(10)
+		register(t, ctx, s, a, name)
+
+		options, sessionID, err := s.BeginLogin(ctx, name)
+		require.NoError(t, err)
+		_, err = s.Authenticate(ctx, webauthn.Input{
+			Name:      name,
+			SessionID: sessionID,
+			Response:  a.get(t, options),
+		})
+		require.NoError(t, err)
+
+		// A clone starts from the counter value of the original.
+		a.signCount = 0
+		options, sessionID, err = s.BeginLogin(ctx, name)
+		require.NoError(t, err)
+		_, err = s.Authenticate(ctx, webauthn.Input{
+			Name:      name,
+			SessionID: sessionID,
+			Response:  a.get(t, options),
+		})
+		require.ErrorIs(t, err, webauthn.ErrClonedCredential)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, _, err := s.BeginLogin(ctx, random.String(10))
+		require.ErrorIs(t, err, webauthn.ErrUserNotFound)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,14 +13,34 @@ const ApplicationName = "identity"
 
 // Application is the application configuration.
 type Application struct {
-	Environment  string   `yaml:"environment"`
-	SymmetricKey string   `yaml:"symmetricKey"`
-	Strategies   []string `yaml:"strategies"`
+	Environment            string        `yaml:"environment"`
+	TokenFormat            string        `yaml:"tokenFormat"`
+	TokenPurpose           string        `yaml:"tokenPurpose"`
+	SymmetricKey           string        `yaml:"symmetricKey"`
+	PreviousSymmetricKeys  []PreviousKey `yaml:"previousSymmetricKeys"`
+	AsymmetricKey          string        `yaml:"asymmetricKey"`
+	PreviousAsymmetricKeys []PreviousKey `yaml:"previousAsymmetricKeys"`
+	EncryptionKey          string        `yaml:"encryptionKey"`
+	Strategies             []string      `yaml:"strategies"`
 	// AccessDuration  time.Duration `yaml:"accessDuration"`
 	// RefreshDuration time.Duration `yaml:"refreshDuration"`
-	PSQL   Postgres `yaml:"postgres"`
-	NATS   NATS     `yaml:"nats"`
-	Server Server   `yaml:"server"`
+	PSQL          Postgres      `yaml:"postgres"`
+	NATS          NATS          `yaml:"nats"`
+	Server        Server        `yaml:"server"`
+	WebAuthn      WebAuthn      `yaml:"webauthn"`
+	MagicLink     MagicLink     `yaml:"magicLink"`
+	PasswordReset PasswordReset `yaml:"passwordReset"`
+	Verification  Verification  `yaml:"verification"`
+	EmailChange   EmailChange   `yaml:"emailChange"`
+	Scopes        Scopes        `yaml:"scopes"`
+	JWT           JWT           `yaml:"jwt"`
+	TokenExchange TokenExchange `yaml:"tokenExchange"`
+	OIDC          OIDC          `yaml:"oidc"`
+	Federated     Federated     `yaml:"federated"`
+	Clients       Clients       `yaml:"clients"`
+	APIKeys       APIKeys       `yaml:"apiKeys"`
+	Password      Password      `yaml:"password"`
+	Lockout       Lockout       `yaml:"lockout"`
 }
 
 // New returns a new application configuration
@@ -64,6 +85,238 @@ type Server struct {
 	GRPCPort string `yaml:"port"`
 }
 
+// WebAuthn holds the relying party configuration of the webauthn strategy.
+type WebAuthn struct {
+	RPID          string   `yaml:"rpID"`
+	RPDisplayName string   `yaml:"rpDisplayName"`
+	RPOrigins     []string `yaml:"rpOrigins"`
+}
+
+// MagicLink holds the configuration of the magic_link strategy.
+type MagicLink struct {
+	// Origin is prepended to the token to build the link sent by email.
+	Origin string `yaml:"origin"`
+	// TTL is how long a sign-in link can be redeemed.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// PasswordReset holds the configuration of password resets.
+type PasswordReset struct {
+	// Origin is prepended to the token to build the link sent by email.
+	Origin string `yaml:"origin"`
+	// TTL is how long a reset token can be used.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// EmailChange holds the configuration of email changes.
+type EmailChange struct {
+	// Origin is prepended to the token to build the link sent to the new address.
+	Origin string `yaml:"origin"`
+	// TTL is how long a change can be confirmed from the new address.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// Verification holds the configuration of email verifications.
+type Verification struct {
+	// Origin is prepended to the token to build the link sent by email.
+	Origin string `yaml:"origin"`
+	// TTL is how long a verification token can be used.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// PreviousKey is a key tokens were issued with before the current one.
+type PreviousKey struct {
+	Key string `yaml:"key"`
+	// RetireAt is when tokens issued with the key stop being accepted. It is required, usually the time
+	// the key was replaced plus the refresh token lifetime, or right away if the key is compromised.
+	RetireAt time.Time `yaml:"retireAt"`
+}
+
+// PreviousKeyFile is a PEM encoded key file tokens were signed with before the current one.
+type PreviousKeyFile struct {
+	File string `yaml:"file"`
+	// RetireAt is when tokens signed with the key stop being accepted, as for [PreviousKey].
+	RetireAt time.Time `yaml:"retireAt"`
+}
+
+// JWT holds the configuration of JWTs, used with the jwt token format.
+type JWT struct {
+	// Algorithm is EdDSA, signing with the asymmetric key, or RS256.
+	Algorithm string `yaml:"algorithm"`
+	// RSAKeyFile is the path of the PEM encoded RSA private key RS256 tokens are signed with.
+	RSAKeyFile string `yaml:"rsaKeyFile"`
+	// PreviousRSAKeyFiles are accepted until they retire.
+	PreviousRSAKeyFiles []PreviousKeyFile `yaml:"previousRSAKeyFiles"`
+}
+
+// Scopes holds the policy deciding which scopes and audiences tokens are issued for.
+type Scopes struct {
+	// Default scopes are granted when none are requested, as far as they are allowed.
+	Default []string `yaml:"default"`
+	// Allowed scopes keyed by strategy name.
+	Allowed map[string][]string `yaml:"allowed"`
+	// Audiences tokens can be requested for.
+	Audiences []string `yaml:"audiences"`
+}
+
+// TokenExchange holds the clients allowed to exchange access tokens of users.
+type TokenExchange struct {
+	Clients []ExchangeClient `yaml:"clients"`
+}
+
+// ExchangeClient is a service that exchanges tokens, with a policy per audience.
+type ExchangeClient struct {
+	ID       string           `yaml:"id"`
+	Secret   string           `yaml:"secret"`
+	Policies []ExchangePolicy `yaml:"policies"`
+}
+
+// ExchangePolicy decides what a client can exchange tokens for.
+type ExchangePolicy struct {
+	Audience string `yaml:"audience"`
+	// Scopes the exchanged tokens may carry, at most the ones of the exchanged token.
+	Scopes []string `yaml:"scopes"`
+	// Delegation adds an act claim naming the client, otherwise the client impersonates the user.
+	Delegation bool `yaml:"delegation"`
+}
+
+// OIDC holds the configuration of the OpenID Connect provider.
+type OIDC struct {
+	// Issuer is the public URL of the provider, its endpoints are served below it.
+	Issuer string `yaml:"issuer"`
+	// Addr is the address the provider listens on, empty disables it.
+	Addr string `yaml:"addr"`
+	// CodeTTL is how long an authorization code can be redeemed.
+	CodeTTL time.Duration `yaml:"codeTTL"`
+	// IDTokenTTL is how long ID tokens are valid.
+	IDTokenTTL time.Duration `yaml:"idTokenTTL"`
+	// SubjectKey keys the HMAC deriving subjects from identifiers,
+	// so that emails and personal numbers are not handed to clients as subjects,
+	// and the anti-CSRF tokens of the login form.
+	SubjectKey string       `yaml:"subjectKey"`
+	Clients    []OIDCClient `yaml:"clients"`
+}
+
+// OIDCClient is a relying party of the OpenID Connect provider.
+type OIDCClient struct {
+	ID string `yaml:"id"`
+	// Secret authenticates the client at the token endpoint, public clients have none.
+	Secret string `yaml:"secret"`
+	// RedirectURIs codes may be sent to, compared exactly.
+	RedirectURIs []string `yaml:"redirectURIs"`
+}
+
+// Federated holds the upstream providers users of the federated strategy sign in with.
+type Federated struct {
+	// SessionTTL is how long a sign-in started at a provider can be completed.
+	SessionTTL time.Duration       `yaml:"sessionTTL"`
+	Providers  []FederatedProvider `yaml:"providers"`
+}
+
+// FederatedProvider is an upstream OpenID Connect or OAuth 2.0 provider.
+type FederatedProvider struct {
+	// Name identifies the provider in requests, such as google or github.
+	Name string `yaml:"name"`
+	// Issuer is discovered for OpenID Connect providers, whose ID tokens are verified.
+	Issuer string `yaml:"issuer"`
+	// AuthURL, TokenURL and UserInfoURL are used for OAuth 2.0 providers without an issuer,
+	// the claims are read from the user info endpoint.
+	AuthURL      string   `yaml:"authURL"`
+	TokenURL     string   `yaml:"tokenURL"`
+	UserInfoURL  string   `yaml:"userInfoURL"`
+	ClientID     string   `yaml:"clientID"`
+	ClientSecret string   `yaml:"clientSecret"`
+	RedirectURL  string   `yaml:"redirectURL"`
+	Scopes       []string `yaml:"scopes"`
+	Claims       Claims   `yaml:"claims"`
+	// LinkByEmail signs users in to the credentials account with the same verified email, if any.
+	// Only enable it for providers that verify emails.
+	LinkByEmail bool `yaml:"linkByEmail"`
+}
+
+// Claims maps the claims of an upstream provider, the OpenID Connect names are used for empty ones.
+type Claims struct {
+	Subject       string `yaml:"subject"`
+	Email         string `yaml:"email"`
+	EmailVerified string `yaml:"emailVerified"`
+}
+
+// Clients holds the configuration of the machine identities of the client_credentials strategy.
+type Clients struct {
+	// AdminScope is the scope of the access tokens that can create clients and rotate their secrets.
+	AdminScope string `yaml:"adminScope"`
+	// DefaultTokenTTL is the lifetime of access tokens of clients created without one.
+	DefaultTokenTTL time.Duration `yaml:"defaultTokenTTL"`
+	// MaxTokenTTL caps the lifetime of access tokens of clients.
+	MaxTokenTTL time.Duration `yaml:"maxTokenTTL"`
+}
+
+// APIKeys holds the configuration of the long-lived API keys of users.
+type APIKeys struct {
+	// DefaultTTL is the lifetime of keys created without one.
+	DefaultTTL time.Duration `yaml:"defaultTTL"`
+	// MaxTTL caps the lifetime of keys.
+	MaxTTL time.Duration `yaml:"maxTTL"`
+}
+
+// Password holds the configuration of password hashing.
+type Password struct {
+	// Hasher is the algorithm new passwords are hashed with, argon2id or bcrypt.
+	// Hashes of the other one are replaced when their users sign in.
+	Hasher   string   `yaml:"hasher"`
+	Argon2id Argon2id `yaml:"argon2id"`
+	// BcryptCost is the cost of bcrypt hashes, the bcrypt default if zero.
+	BcryptCost int `yaml:"bcryptCost"`
+	// Policy decides which new passwords are accepted, the password package default if nil.
+	Policy *PasswordPolicy `yaml:"policy"`
+	// BreachesFile is built by cmd/breaches, new passwords found in it are rejected. Empty disables it.
+	BreachesFile string `yaml:"breachesFile"`
+}
+
+// PasswordPolicy holds the rules new passwords must satisfy.
+type PasswordPolicy struct {
+	MinChars      int  `yaml:"minChars"`
+	MaxBytes      int  `yaml:"maxBytes"`
+	RequireUpper  bool `yaml:"requireUpper"`
+	RequireLower  bool `yaml:"requireLower"`
+	RequireDigit  bool `yaml:"requireDigit"`
+	RequireSymbol bool `yaml:"requireSymbol"`
+	// MinScore is the minimum zxcvbn score from 0 to 4, zero disables it.
+	MinScore int `yaml:"minScore"`
+	// BannedWords can not be part of passwords, the email local part of the user is always banned.
+	BannedWords []string `yaml:"bannedWords"`
+	// History is how many of the most recent passwords, the current one included, can not be reused.
+	History int `yaml:"history"`
+}
+
+// Argon2id holds the cost parameters of argon2id, zero ones are the password package defaults.
+type Argon2id struct {
+	// Memory in KiB.
+	Memory      uint32 `yaml:"memory"`
+	Iterations  uint32 `yaml:"iterations"`
+	Parallelism uint8  `yaml:"parallelism"`
+}
+
+// Lockout holds when failed logins of the credentials strategy block further attempts.
+type Lockout struct {
+	// Enabled turns on counting failed logins.
+	Enabled bool `yaml:"enabled"`
+	// Window after which failed logins are forgotten.
+	Window time.Duration `yaml:"window"`
+	// BaseDelay is the first delay, which doubles with every further failure up to MaxDelay.
+	BaseDelay time.Duration    `yaml:"baseDelay"`
+	MaxDelay  time.Duration    `yaml:"maxDelay"`
+	Account   LockoutThreshold `yaml:"account"`
+	IP        LockoutThreshold `yaml:"ip"`
+}
+
+// LockoutThreshold holds after how many failed logins attempts are delayed or locked, zero disables them.
+type LockoutThreshold struct {
+	DelayAfter int           `yaml:"delayAfter"`
+	LockAfter  int           `yaml:"lockAfter"`
+	Duration   time.Duration `yaml:"duration"`
+}
+
 // Addr returns the PSQL connection string.
 func (x *Postgres) Addr() string {
 	return fmt.Sprintf(
@@ -0,0 +1,231 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("apikey")
+
+const Tablename = "api_keys"
+
+// Key defines an entry in the api_keys table. Keys are only stored hashed,
+// the plain key is handed to its owner once.
+type Key struct {
+	ID      uuid.UUID `db:"id"`
+	KeyHash []byte    `db:"key_hash"`
+	// Prefix is the start of the plain key, so that owners can tell their keys apart.
+	Prefix string `db:"prefix"`
+	Name   string `db:"name"`
+	// Strategy and Identifier are the ones of the owner.
+	Strategy   int32      `db:"strategy"`
+	Identifier string     `db:"identifier"`
+	Scopes     []string   `db:"scopes"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+}
+
+// HashKey returns the hash of a key as it is stored. Keys are random
+// and long enough that a plain SHA-256 hash can not be reversed.
+func HashKey(key string) []byte {
+	h := sha256.Sum256([]byte(key))
+	return h[:]
+}
+
+// Insert a new [Key].
+// Returns [database.InputError] if the hash, name or identifier is empty,
+// [database.DuplicateEntryError] on duplicate entry, [database.RowsAffectedError] or [database.OperationFailedError].
+func Insert(ctx context.Context, db database.Querier, k Key) error {
+	ctx, span := tracer.Start(ctx, "Insert")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("id", k.ID.String()),
+		attribute.String("prefix", k.Prefix),
+	)
+
+	if len(k.KeyHash) == 0 {
+		return database.NewInputError(ctx, errors.New("apikey: key hash is empty"), "key_hash", "")
+	}
+	if k.Name == "" {
+		return database.NewInputError(ctx, errors.New("apikey: name is empty"), "name", k.Name)
+	}
+	if k.Identifier == "" {
+		return database.NewInputError(ctx, errors.New("apikey: identifier is empty"), "identifier", k.Identifier)
+	}
+
+	query := `
+    INSERT INTO api_keys (id, key_hash, prefix, name, strategy, identifier, scopes, created_at, expires_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(
+		ctx,
+		query,
+		k.ID,
+		k.KeyHash,
+		k.Prefix,
+		k.Name,
+		k.Strategy,
+		k.Identifier,
+		pq.Array(k.Scopes),
+		k.CreatedAt,
+		k.ExpiresAt,
+	)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "api_key")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// ReadByHash reads the [Key] with the given hash, expired or not.
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func ReadByHash(ctx context.Context, db database.Querier, keyHash []byte) (*Key, error) {
+	ctx, span := tracer.Start(ctx, "ReadByHash")
+	defer span.End()
+
+	query := `
+    SELECT id, key_hash, prefix, name, strategy, identifier, scopes, created_at, expires_at, last_used_at
+    FROM api_keys
+    WHERE key_hash = $1
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	k, err := scan(db.QueryRowContext(ctx, query, keyHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "api_key", "REDACTED")
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	return k, nil
+}
+
+// List the keys of an owner, newest first.
+// Returns [database.OperationFailedError] on failure.
+func List(ctx context.Context, db *sql.DB, strategy int32, identifier string) ([]Key, error) {
+	ctx, span := tracer.Start(ctx, "List")
+	defer span.End()
+	span.SetAttributes(attribute.Int("strategy", int(strategy)))
+
+	query := `
+    SELECT id, key_hash, prefix, name, strategy, identifier, scopes, created_at, expires_at, last_used_at
+    FROM api_keys
+    WHERE strategy = $1 AND identifier = $2
+    ORDER BY created_at DESC
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	rows, err := db.QueryContext(ctx, query, strategy, identifier)
+	if err != nil {
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	defer rows.Close()
+
+	var keys []Key
+	for rows.Next() {
+		k, err := scan(rows)
+		if err != nil {
+			return nil, database.NewOperationFailedError(ctx, err)
+		}
+		keys = append(keys, *k)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	return keys, nil
+}
+
+// Delete the key with the given ID if it belongs to the owner.
+// Returns [database.NotFoundError] if the owner has no such key, otherwise [database.OperationFailedError].
+func Delete(ctx context.Context, db database.Querier, id uuid.UUID, strategy int32, identifier string) error {
+	ctx, span := tracer.Start(ctx, "Delete")
+	defer span.End()
+	span.SetAttributes(attribute.String("id", id.String()))
+
+	query := `DELETE FROM api_keys WHERE id = $1 AND strategy = $2 AND identifier = $3`
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, id, strategy, identifier)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return database.NewNotFoundError(ctx, sql.ErrNoRows, "api_key", id)
+	}
+
+	return nil
+}
+
+// UpdateLastUsed records when a key was last used.
+// Returns [database.RowsAffectedError] or [database.OperationFailedError].
+func UpdateLastUsed(ctx context.Context, db database.Querier, id uuid.UUID, at time.Time) error {
+	ctx, span := tracer.Start(ctx, "UpdateLastUsed")
+	defer span.End()
+	span.SetAttributes(attribute.String("id", id.String()))
+
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, id, at)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(s scanner) (*Key, error) {
+	var k Key
+	if err := s.Scan(
+		&k.ID,
+		&k.KeyHash,
+		&k.Prefix,
+		&k.Name,
+		&k.Strategy,
+		&k.Identifier,
+		pq.Array(&k.Scopes),
+		&k.CreatedAt,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
@@ -0,0 +1,91 @@
+//go:build testdb
+// +build testdb
+
+package apikey_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/apikey"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(identifier string) apikey.Key {
+	return apikey.Key{
+		ID:         uuid.New(),
+		KeyHash:    apikey.HashKey(random.String(43)),
+		Prefix:     "idk_" + random.String(8),
+		Name:       random.String(10),
+		Strategy:   int32(gen.Strategy_TypeCredentials),
+		Identifier: identifier,
+		Scopes:     []string{"profile"},
+		CreatedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+}
+
+func TestKey(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(apikey.Tablename)
+	t.Cleanup(cleanup)
+
+	owner := random.Email()
+	k := newKey(owner)
+
+	t.Run("OK", func(t *testing.T) {
+		require.NoError(t, apikey.Insert(ctx, db, k))
+
+		got, err := apikey.ReadByHash(ctx, db, k.KeyHash)
+		require.NoError(t, err)
+		require.Equal(t, k.ID, got.ID)
+		require.Equal(t, k.Prefix, got.Prefix)
+		require.Equal(t, k.Scopes, got.Scopes)
+		require.Nil(t, got.LastUsedAt)
+	})
+
+	t.Run("duplicate hash returns error", func(t *testing.T) {
+		dup := k
+		dup.ID = uuid.New()
+		require.ErrorAs(t, apikey.Insert(ctx, db, dup), &database.DuplicateEntryError{})
+	})
+
+	t.Run("empty name returns error", func(t *testing.T) {
+		empty := newKey(owner)
+		empty.Name = ""
+		require.ErrorAs(t, apikey.Insert(ctx, db, empty), &database.InputError{})
+	})
+
+	t.Run("update last used", func(t *testing.T) {
+		require.NoError(t, apikey.UpdateLastUsed(ctx, db, k.ID, time.Now()))
+
+		got, err := apikey.ReadByHash(ctx, db, k.KeyHash)
+		require.NoError(t, err)
+		require.NotNil(t, got.LastUsedAt)
+	})
+
+	t.Run("list keys of owner", func(t *testing.T) {
+		other := newKey(owner)
+		require.NoError(t, apikey.Insert(ctx, db, other))
+		require.NoError(t, apikey.Insert(ctx, db, newKey(random.Email())))
+
+		keys, err := apikey.List(ctx, db, int32(gen.Strategy_TypeCredentials), owner)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		require.Equal(t, other.ID, keys[0].ID)
+	})
+
+	t.Run("delete only keys of owner", func(t *testing.T) {
+		err := apikey.Delete(ctx, db, k.ID, int32(gen.Strategy_TypeCredentials), random.Email())
+		require.ErrorAs(t, err, &database.NotFoundError{})
+
+		require.NoError(t, apikey.Delete(ctx, db, k.ID, int32(gen.Strategy_TypeCredentials), owner))
+		_, err = apikey.ReadByHash(ctx, db, k.KeyHash)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+}
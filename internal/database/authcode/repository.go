@@ -0,0 +1,125 @@
+package authcode
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("authcode")
+
+const Tablename = "authorization_codes"
+
+// Entry defines an entry in the authorization_codes table.
+// Codes are only stored hashed, the plain code is handed to the client once.
+type Entry struct {
+	CodeHash      string    `db:"code_hash"`
+	ClientID      string    `db:"client_id"`
+	RedirectURI   string    `db:"redirect_uri"`
+	CodeChallenge string    `db:"code_challenge"`
+	Nonce         string    `db:"nonce"`
+	Strategy      int32     `db:"strategy"`
+	Identifier    string    `db:"identifier"`
+	Scopes        []string  `db:"scopes"`
+	AuthTime      time.Time `db:"auth_time"`
+	ExpiresAt     time.Time `db:"expires_at"`
+}
+
+// Insert an authorization code [Entry].
+// Returns [database.InputError] if the hash is empty, [database.DuplicateEntryError] if it exists,
+// otherwise [database.OperationFailedError].
+func Insert(ctx context.Context, db database.Querier, e Entry) error {
+	ctx, span := tracer.Start(ctx, "Insert")
+	defer span.End()
+	span.SetAttributes(attribute.String("client_id", e.ClientID))
+
+	if e.CodeHash == "" {
+		return database.NewInputError(ctx, errors.New("authcode: code hash is empty"), "code_hash", e.CodeHash)
+	}
+
+	query := `
+    INSERT INTO authorization_codes (
+        code_hash, client_id, redirect_uri, code_challenge, nonce,
+        strategy, identifier, scopes, auth_time, expires_at
+    )
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(
+		ctx,
+		query,
+		e.CodeHash,
+		e.ClientID,
+		e.RedirectURI,
+		e.CodeChallenge,
+		e.Nonce,
+		e.Strategy,
+		e.Identifier,
+		pq.Array(e.Scopes),
+		e.AuthTime,
+		e.ExpiresAt,
+	)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "authorization_code")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// Take deletes and returns the unexpired authorization code [Entry] with the given hash,
+// so that every code can only be redeemed once.
+// Returns [database.NotFoundError] if there is none, otherwise [database.OperationFailedError].
+func Take(ctx context.Context, db database.Querier, codeHash string) (*Entry, error) {
+	ctx, span := tracer.Start(ctx, "Take")
+	defer span.End()
+
+	if codeHash == "" {
+		return nil, database.NewInputError(ctx, errors.New("authcode: code hash is empty"), "code_hash", codeHash)
+	}
+
+	query := `
+    DELETE FROM authorization_codes
+    WHERE code_hash = $1 AND expires_at > $2
+    RETURNING code_hash, client_id, redirect_uri, code_challenge, nonce,
+              strategy, identifier, scopes, auth_time, expires_at
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	var e Entry
+	if err := db.QueryRowContext(ctx, query, codeHash, time.Now()).Scan(
+		&e.CodeHash,
+		&e.ClientID,
+		&e.RedirectURI,
+		&e.CodeChallenge,
+		&e.Nonce,
+		&e.Strategy,
+		&e.Identifier,
+		pq.Array(&e.Scopes),
+		&e.AuthTime,
+		&e.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "authorization_code", codeHash)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+
+	return &e, nil
+}
@@ -0,0 +1,81 @@
+//go:build testdb
+// +build testdb
+
+package authcode_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/authcode"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func newEntry(expiresAt time.Time) authcode.Entry {
+	return authcode.Entry{
+		CodeHash:      random.String(43),
+		ClientID:      "app",
+		RedirectURI:   "https://app.example.com/callback",
+		CodeChallenge: random.String(43),
+		Nonce:         random.String(16),
+		Strategy:      1,
+		Identifier:    random.Email(),
+		Scopes:        []string{"openid", "profile"},
+		AuthTime:      time.Now().Truncate(time.Second),
+		ExpiresAt:     expiresAt,
+	}
+}
+
+func TestInsert(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(authcode.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		require.NoError(t, authcode.Insert(ctx, db, newEntry(time.Now().Add(time.Minute))))
+	})
+
+	t.Run("duplicate hash returns error", func(t *testing.T) {
+		e := newEntry(time.Now().Add(time.Minute))
+		require.NoError(t, authcode.Insert(ctx, db, e))
+		err := authcode.Insert(ctx, db, e)
+		require.ErrorAs(t, err, &database.DuplicateEntryError{})
+	})
+
+	t.Run("empty hash returns error", func(t *testing.T) {
+		e := newEntry(time.Now().Add(time.Minute))
+		e.CodeHash = ""
+		require.ErrorAs(t, authcode.Insert(ctx, db, e), &database.InputError{})
+	})
+}
+
+func TestTake(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(authcode.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("code can only be taken once", func(t *testing.T) {
+		e := newEntry(time.Now().Add(time.Minute))
+		require.NoError(t, authcode.Insert(ctx, db, e))
+
+		got, err := authcode.Take(ctx, db, e.CodeHash)
+		require.NoError(t, err)
+		require.Equal(t, e.Identifier, got.Identifier)
+		require.Equal(t, e.Scopes, got.Scopes)
+		require.Equal(t, e.CodeChallenge, got.CodeChallenge)
+
+		_, err = authcode.Take(ctx, db, e.CodeHash)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+
+	t.Run("expired code returns error", func(t *testing.T) {
+		e := newEntry(time.Now().Add(-time.Minute))
+		require.NoError(t, authcode.Insert(ctx, db, e))
+
+		_, err := authcode.Take(ctx, db, e.CodeHash)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+}
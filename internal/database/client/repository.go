@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("client")
+
+const Tablename = "clients"
+
+// Client defines an entry in the clients table, a machine identity
+// signing in with the client credentials strategy.
+type Client struct {
+	ID   uuid.UUID `db:"id"`
+	Name string    `db:"name"`
+	// SecretHash is the SHA-256 hash of the secret, see [HashSecret].
+	SecretHash []byte   `db:"secret_hash"`
+	Scopes     []string `db:"scopes"`
+	// TokenTTL is the lifetime of the access tokens of the client, stored in seconds.
+	TokenTTL  time.Duration `db:"token_ttl_seconds"`
+	CreatedAt time.Time     `db:"created_at"`
+	RotatedAt *time.Time    `db:"rotated_at"`
+}
+
+// HashSecret returns the hash of a client secret as it is stored. Secrets are random
+// and long enough that a plain SHA-256 hash can not be reversed.
+func HashSecret(secret string) []byte {
+	h := sha256.Sum256([]byte(secret))
+	return h[:]
+}
+
+// Insert a new [Client].
+// Returns [database.InputError] if the name or secret hash is empty, [database.DuplicateEntryError]
+// on duplicate entry, [database.RowsAffectedError] or [database.OperationFailedError].
+func Insert(ctx context.Context, db database.Querier, c Client) error {
+	ctx, span := tracer.Start(ctx, "Insert")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("id", c.ID.String()),
+		attribute.String("name", c.Name),
+	)
+
+	if c.Name == "" {
+		return database.NewInputError(ctx, errors.New("client: name is empty"), "name", c.Name)
+	}
+	if len(c.SecretHash) == 0 {
+		return database.NewInputError(ctx, errors.New("client: secret hash is empty"), "secret_hash", "")
+	}
+
+	query := `
+    INSERT INTO clients (id, name, secret_hash, scopes, token_ttl_seconds, created_at)
+    VALUES ($1, $2, $3, $4, $5, $6)
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(
+		ctx,
+		query,
+		c.ID,
+		c.Name,
+		c.SecretHash,
+		pq.Array(c.Scopes),
+		int64(c.TokenTTL/time.Second),
+		c.CreatedAt,
+	)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "client")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// Read a [Client] by ID.
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func Read(ctx context.Context, db database.Querier, id uuid.UUID) (*Client, error) {
+	ctx, span := tracer.Start(ctx, "Read")
+	defer span.End()
+	span.SetAttributes(attribute.String("id", id.String()))
+
+	query := `
+    SELECT id, name, secret_hash, scopes, token_ttl_seconds, created_at, rotated_at
+    FROM clients
+    WHERE id = $1
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	var (
+		c   Client
+		ttl int64
+	)
+	if err := db.QueryRowContext(ctx, query, id).Scan(
+		&c.ID,
+		&c.Name,
+		&c.SecretHash,
+		pq.Array(&c.Scopes),
+		&ttl,
+		&c.CreatedAt,
+		&c.RotatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "client", id)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	c.TokenTTL = time.Duration(ttl) * time.Second
+	return &c, nil
+}
+
+// UpdateSecret replaces the secret hash of a [Client], the previous secret stops working immediately.
+// Returns [database.InputError] if the secret hash is empty, [database.NotFoundError] if entry is not found,
+// otherwise [database.OperationFailedError].
+func UpdateSecret(ctx context.Context, db database.Querier, id uuid.UUID, secretHash []byte, at time.Time) error {
+	ctx, span := tracer.Start(ctx, "UpdateSecret")
+	defer span.End()
+	span.SetAttributes(attribute.String("id", id.String()))
+
+	if len(secretHash) == 0 {
+		return database.NewInputError(ctx, errors.New("client: secret hash is empty"), "secret_hash", "")
+	}
+
+	query := `UPDATE clients SET secret_hash = $2, rotated_at = $3 WHERE id = $1`
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, id, secretHash, at)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return database.NewNotFoundError(ctx, sql.ErrNoRows, "client", id)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
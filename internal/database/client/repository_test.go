@@ -0,0 +1,71 @@
+//go:build testdb
+// +build testdb
+
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/client"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(client.Tablename)
+	t.Cleanup(cleanup)
+
+	c := client.Client{
+		ID:         uuid.New(),
+		Name:       random.String(10),
+		SecretHash: client.HashSecret(random.String(43)),
+		Scopes:     []string{"reports:read", "reports:write"},
+		TokenTTL:   5 * time.Minute,
+		CreatedAt:  time.Now(),
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		require.NoError(t, client.Insert(ctx, db, c))
+
+		got, err := client.Read(ctx, db, c.ID)
+		require.NoError(t, err)
+		require.Equal(t, c.Name, got.Name)
+		require.Equal(t, c.SecretHash, got.SecretHash)
+		require.Equal(t, c.Scopes, got.Scopes)
+		require.Equal(t, c.TokenTTL, got.TokenTTL)
+		require.Nil(t, got.RotatedAt)
+	})
+
+	t.Run("duplicate ID returns error", func(t *testing.T) {
+		require.ErrorAs(t, client.Insert(ctx, db, c), &database.DuplicateEntryError{})
+	})
+
+	t.Run("empty name returns error", func(t *testing.T) {
+		empty := c
+		empty.ID, empty.Name = uuid.New(), ""
+		require.ErrorAs(t, client.Insert(ctx, db, empty), &database.InputError{})
+	})
+
+	t.Run("update secret", func(t *testing.T) {
+		hash := client.HashSecret(random.String(43))
+		require.NoError(t, client.UpdateSecret(ctx, db, c.ID, hash, time.Now()))
+
+		got, err := client.Read(ctx, db, c.ID)
+		require.NoError(t, err)
+		require.Equal(t, hash, got.SecretHash)
+		require.NotNil(t, got.RotatedAt)
+	})
+
+	t.Run("unknown client returns error", func(t *testing.T) {
+		_, err := client.Read(ctx, db, uuid.New())
+		require.ErrorAs(t, err, &database.NotFoundError{})
+
+		err = client.UpdateSecret(ctx, db, uuid.New(), client.HashSecret(random.String(43)), time.Now())
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+}
@@ -0,0 +1,97 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ArchivePasswordHash copies the current password hash of a credential ID into its password history
+// before it is replaced, and deletes all but the keep most recent hashes of the history.
+// Returns [database.RowsAffectedError] if the credential does not exist, otherwise [database.OperationFailedError].
+func ArchivePasswordHash(ctx context.Context, db database.Querier, id uuid.UUID, keep int) error {
+	ctx, span := tracer.Start(ctx, "ArchivePasswordHash")
+	defer span.End()
+
+	if keep < 1 {
+		return database.NewInputError(ctx, errors.New("credentials: keep must be positive"), "keep", keep)
+	}
+
+	query := `
+    INSERT INTO password_history (credentials_id, password_hash)
+    SELECT id, password_hash FROM credentials
+    WHERE id = $1
+    `
+	span.SetAttributes(
+		attribute.String("id", id.String()),
+		attribute.Int("keep", keep),
+		attribute.String("query", query),
+	)
+
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	query = `
+    DELETE FROM password_history
+    WHERE credentials_id = $1 AND id NOT IN (
+        SELECT id FROM password_history
+        WHERE credentials_id = $1
+        ORDER BY id DESC
+        LIMIT $2
+    )
+    `
+	if _, err = db.ExecContext(ctx, query, id, keep); err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+
+	return nil
+}
+
+// ListPasswordHistory returns at most limit previous password hashes of a credential ID, newest first.
+// Returns [database.OperationFailedError] on failure.
+func ListPasswordHistory(ctx context.Context, db database.Querier, id uuid.UUID, limit int) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "ListPasswordHistory")
+	defer span.End()
+
+	query := `
+    SELECT password_hash FROM password_history
+    WHERE credentials_id = $1
+    ORDER BY id DESC
+    LIMIT $2
+    `
+	span.SetAttributes(
+		attribute.String("id", id.String()),
+		attribute.String("query", query),
+	)
+
+	rows, err := db.QueryContext(ctx, query, id, limit)
+	if err != nil {
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, database.NewOperationFailedError(ctx, err)
+		}
+		hashes = append(hashes, h)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,60 @@
+//go:build testdb
+// +build testdb
+
+package credentials_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/credentials"
+	"github.com/Salam4nder/identity/pkg/password"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordHistory(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.HistoryTablename)
+	t.Cleanup(cleanup)
+
+	ID := uuid.New()
+	err := credentials.Insert(ctx, db, credentials.InsertParams{
+		ID:        ID,
+		Email:     random.Email(),
+		Password:  password.SafeString("first-Passw0rd"),
+		CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	t.Run("archives and keeps the most recent", func(t *testing.T) {
+		for _, p := range []string{"second-Passw0rd", "third-Passw0rd"} {
+			require.NoError(t, credentials.ArchivePasswordHash(ctx, db, ID, 2))
+			require.NoError(t, credentials.UpdatePassword(ctx, db, ID, password.SafeString(p)))
+		}
+		require.NoError(t, credentials.ArchivePasswordHash(ctx, db, ID, 2))
+
+		hashes, err := credentials.ListPasswordHistory(ctx, db, ID, 10)
+		require.NoError(t, err)
+		require.Len(t, hashes, 2)
+		require.NoError(t, password.Compare(hashes[0], "third-Passw0rd"))
+		require.NoError(t, password.Compare(hashes[1], "second-Passw0rd"))
+
+		hashes, err = credentials.ListPasswordHistory(ctx, db, ID, 1)
+		require.NoError(t, err)
+		require.Len(t, hashes, 1)
+	})
+
+	t.Run("not found returns error", func(t *testing.T) {
+		err := credentials.ArchivePasswordHash(ctx, db, uuid.New(), 2)
+		require.ErrorAs(t, err, &database.RowsAffectedError{})
+	})
+
+	t.Run("keep must be positive", func(t *testing.T) {
+		err := credentials.ArchivePasswordHash(ctx, db, ID, 0)
+		require.ErrorAs(t, err, &database.InputError{})
+	})
+}
@@ -16,17 +16,22 @@ import (
 
 var tracer = otel.Tracer("credentials")
 
-const Tablename = "credentials"
+const (
+	Tablename             = "credentials"
+	EmailChangesTablename = "email_changes"
+	HistoryTablename      = "password_history"
+)
 
 // Entry defines an entry in the credentials table.
 type Entry struct {
-	ID           uuid.UUID  `db:"id"`
-	FullName     string     `db:"full_name"`
-	Email        string     `db:"email"`
-	PasswordHash string     `db:"password_hash"`
-	CreatedAt    time.Time  `db:"created_at"`
-	UpdatedAt    *time.Time `db:"updated_at"`
-	VerifiedAt   *time.Time `db:"verified_at"`
+	ID                uuid.UUID  `db:"id"`
+	FullName          string     `db:"full_name"`
+	Email             string     `db:"email"`
+	PasswordHash      string     `db:"password_hash"`
+	CreatedAt         time.Time  `db:"created_at"`
+	UpdatedAt         *time.Time `db:"updated_at"`
+	VerifiedAt        *time.Time `db:"verified_at"`
+	PasswordChangedAt *time.Time `db:"password_changed_at"`
 }
 
 // InsertParams defines the parameters for inserts.
@@ -95,7 +100,7 @@ func Read(ctx context.Context, db *sql.DB, id uuid.UUID) (*Entry, error) {
 	}
 
 	query := `
-        SELECT id, email, password_hash, created_at, updated_at, verified_at
+        SELECT id, email, password_hash, created_at, updated_at, verified_at, password_changed_at
         FROM credentials
         WHERE id = $1
         `
@@ -109,6 +114,7 @@ func Read(ctx context.Context, db *sql.DB, id uuid.UUID) (*Entry, error) {
 		&entry.CreatedAt,
 		&entry.UpdatedAt,
 		&entry.VerifiedAt,
+		&entry.PasswordChangedAt,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, database.NewNotFoundError(ctx, err, "credentials", id.String())
@@ -131,7 +137,7 @@ func ReadByEmail(ctx context.Context, db *sql.DB, email string) (*Entry, error)
 	}
 
 	query := `
-        SELECT id, email, password_hash, created_at, updated_at
+        SELECT id, email, password_hash, created_at, updated_at, verified_at, password_changed_at
         FROM credentials
         WHERE email = $1
         `
@@ -147,6 +153,8 @@ func ReadByEmail(ctx context.Context, db *sql.DB, email string) (*Entry, error)
 		&entry.PasswordHash,
 		&entry.CreatedAt,
 		&entry.UpdatedAt,
+		&entry.VerifiedAt,
+		&entry.PasswordChangedAt,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, database.NewNotFoundError(ctx, err, "credentials", email)
@@ -172,7 +180,7 @@ func (x UpdateParams) SpanAttributes() []attribute.KeyValue {
 
 // Update credentials. Returns [database.DuplicateEntryError] on duplicate entry,
 // [database.RowsAffectedError] or [database.OperationFailedError].
-func Update(ctx context.Context, db *sql.DB, params UpdateParams) error {
+func Update(ctx context.Context, db database.Querier, params UpdateParams) error {
 	ctx, span := tracer.Start(ctx, "Update", trace.WithAttributes(params.SpanAttributes()...))
 	defer span.End()
 
@@ -265,3 +273,155 @@ func Verify(ctx context.Context, db database.Querier, id uuid.UUID) error {
 
 	return nil
 }
+
+// UpdatePassword replaces the password hash for a given credential ID
+// and records when the password was changed.
+// Returns [database.RowsAffectedError] or [database.OperationFailedError] on error.
+func UpdatePassword(ctx context.Context, db database.Querier, id uuid.UUID, p password.SafeString) error {
+	ctx, span := tracer.Start(ctx, "UpdatePassword")
+	defer span.End()
+
+	query := `
+    UPDATE credentials
+    SET password_hash = $1, updated_at = $2, password_changed_at = $2
+    WHERE id = $3
+    `
+	span.SetAttributes(
+		attribute.String("id", id.String()),
+		attribute.Int("password_length", len(p)),
+		attribute.String("query", query),
+	)
+
+	res, err := db.ExecContext(ctx, query, p, time.Now(), id)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// UpdatePasswordHash replaces the password hash for a given credential ID without recording
+// a password change, such as when the unchanged password is rehashed with another algorithm.
+// Returns [database.RowsAffectedError] or [database.OperationFailedError] on error.
+func UpdatePasswordHash(ctx context.Context, db database.Querier, id uuid.UUID, p password.SafeString) error {
+	ctx, span := tracer.Start(ctx, "UpdatePasswordHash")
+	defer span.End()
+
+	query := `
+    UPDATE credentials
+    SET password_hash = $1, updated_at = $2
+    WHERE id = $3
+    `
+	span.SetAttributes(
+		attribute.String("id", id.String()),
+		attribute.String("query", query),
+	)
+
+	res, err := db.ExecContext(ctx, query, p, time.Now(), id)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// EmailChange defines an entry in the email_changes table,
+// an email change that awaits confirmation of the new address.
+type EmailChange struct {
+	Token     string    `db:"token"`
+	ID        uuid.UUID `db:"credentials_id"`
+	Email     string    `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// InsertEmailChange stores a pending email change that can be taken until its ExpiresAt.
+// Returns [database.DuplicateEntryError] on duplicate entry,
+// [database.RowsAffectedError] or [database.OperationFailedError].
+func InsertEmailChange(ctx context.Context, db database.Querier, change EmailChange) error {
+	ctx, span := tracer.Start(ctx, "InsertEmailChange")
+	defer span.End()
+
+	if change.Token == "" {
+		return database.NewInputError(ctx, errors.New("credentials: token is empty"), "token", change.Token)
+	}
+	if change.Email == "" {
+		return database.NewInputError(ctx, errors.New("credentials: email is empty"), "email", change.Email)
+	}
+
+	query := `
+    INSERT INTO email_changes (token, credentials_id, email, created_at, expires_at)
+    VALUES ($1, $2, $3, $4, $5)
+    `
+	span.SetAttributes(
+		attribute.String("id", change.ID.String()),
+		attribute.String("email", change.Email),
+		attribute.String("query", query),
+	)
+
+	res, err := db.ExecContext(ctx, query, change.Token, change.ID, change.Email, change.CreatedAt, change.ExpiresAt)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "email_changes")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// TakeEmailChange deletes and returns a pending email change, so that it can only be confirmed once.
+// Expired changes are reported as [database.NotFoundError].
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func TakeEmailChange(ctx context.Context, db database.Querier, token string) (*EmailChange, error) {
+	ctx, span := tracer.Start(ctx, "TakeEmailChange")
+	defer span.End()
+
+	if token == "" {
+		return nil, database.NewInputError(ctx, errors.New("credentials: token is empty"), "token", token)
+	}
+
+	query := `
+    DELETE FROM email_changes
+    WHERE token = $1 AND expires_at > $2
+    RETURNING token, credentials_id, email, created_at, expires_at
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	var change EmailChange
+	if err := db.QueryRowContext(ctx, query, token, time.Now()).Scan(
+		&change.Token,
+		&change.ID,
+		&change.Email,
+		&change.CreatedAt,
+		&change.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "email_changes", token)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+
+	return &change, nil
+}
@@ -15,7 +15,6 @@ import (
 	"github.com/Salam4nder/identity/pkg/random"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func TestInsert(t *testing.T) {
@@ -46,7 +45,7 @@ func TestInsert(t *testing.T) {
 
 		require.NoError(
 			t,
-			bcrypt.CompareHashAndPassword([]byte(got.PasswordHash), []byte(randomParams.Password)),
+			password.Compare(got.PasswordHash, string(randomParams.Password)),
 		)
 	})
 
@@ -264,3 +263,146 @@ func TestVerify(t *testing.T) {
 		require.NotNil(t, cred.VerifiedAt)
 	})
 }
+
+func TestUpdatePassword(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		ID := uuid.New()
+		err := credentials.Insert(ctx, db, credentials.InsertParams{
+			ID:        ID,
+			Email:     random.Email(),
+			Password:  password.SafeString(random.String(15)),
+			CreatedAt: time.Now(),
+		})
+		require.NoError(t, err)
+
+		newPassword := password.SafeString(random.String(15))
+		err = credentials.UpdatePassword(ctx, db, ID, newPassword)
+		require.NoError(t, err)
+
+		cred, err := credentials.Read(ctx, db, ID)
+		require.NoError(t, err)
+		require.NotNil(t, cred.PasswordChangedAt)
+		require.NoError(
+			t,
+			password.Compare(cred.PasswordHash, string(newPassword)),
+		)
+	})
+
+	t.Run("not found returns error", func(t *testing.T) {
+		err := credentials.UpdatePassword(ctx, db, uuid.New(), password.SafeString(random.String(15)))
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.RowsAffectedError{})
+	})
+}
+
+func TestTakeEmailChange(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.EmailChangesTablename)
+	t.Cleanup(cleanup)
+
+	ID := uuid.New()
+	err := credentials.Insert(ctx, db, credentials.InsertParams{
+		ID:        ID,
+		Email:     random.Email(),
+		Password:  password.SafeString(random.String(15)),
+		CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	t.Run("OK and single use", func(t *testing.T) {
+		change := credentials.EmailChange{
+			Token:     random.String(32),
+			ID:        ID,
+			Email:     random.Email(),
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		require.NoError(t, credentials.InsertEmailChange(ctx, db, change))
+
+		got, err := credentials.TakeEmailChange(ctx, db, change.Token)
+		require.NoError(t, err)
+		require.Equal(t, change.ID, got.ID)
+		require.Equal(t, change.Email, got.Email)
+
+		_, err = credentials.TakeEmailChange(ctx, db, change.Token)
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+
+	t.Run("expired returns not found", func(t *testing.T) {
+		change := credentials.EmailChange{
+			Token:     random.String(32),
+			ID:        ID,
+			Email:     random.Email(),
+			CreatedAt: time.Now().Add(-2 * time.Hour),
+			ExpiresAt: time.Now().Add(-time.Hour),
+		}
+		require.NoError(t, credentials.InsertEmailChange(ctx, db, change))
+
+		_, err := credentials.TakeEmailChange(ctx, db, change.Token)
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+
+	t.Run("empty email returns error", func(t *testing.T) {
+		err := credentials.InsertEmailChange(ctx, db, credentials.EmailChange{
+			Token:     random.String(32),
+			ID:        ID,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.InputError{})
+	})
+
+	t.Run("unknown credentials returns error", func(t *testing.T) {
+		err := credentials.InsertEmailChange(ctx, db, credentials.EmailChange{
+			Token:     random.String(32),
+			ID:        uuid.New(),
+			Email:     random.Email(),
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestUpdatePasswordHash(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		ID := uuid.New()
+		p := password.SafeString(random.String(15))
+		err := credentials.Insert(ctx, db, credentials.InsertParams{
+			ID:        ID,
+			Email:     random.Email(),
+			Password:  p,
+			CreatedAt: time.Now(),
+		})
+		require.NoError(t, err)
+
+		before, err := credentials.Read(ctx, db, ID)
+		require.NoError(t, err)
+
+		err = credentials.UpdatePasswordHash(ctx, db, ID, p)
+		require.NoError(t, err)
+
+		cred, err := credentials.Read(ctx, db, ID)
+		require.NoError(t, err)
+		require.Nil(t, cred.PasswordChangedAt)
+		require.NotEqual(t, before.PasswordHash, cred.PasswordHash)
+		require.NoError(t, password.Compare(cred.PasswordHash, string(p)))
+	})
+
+	t.Run("not found returns error", func(t *testing.T) {
+		err := credentials.UpdatePasswordHash(ctx, db, uuid.New(), password.SafeString(random.String(15)))
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.RowsAffectedError{})
+	})
+}
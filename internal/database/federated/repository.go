@@ -0,0 +1,207 @@
+package federated
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("federated")
+
+const (
+	IdentitiesTablename = "federated_identities"
+	SessionsTablename   = "federated_sessions"
+)
+
+// Identity defines an entry in the federated_identities table,
+// the local identity of a user at an upstream provider.
+type Identity struct {
+	ID       uuid.UUID `db:"id"`
+	Provider string    `db:"provider"`
+	Subject  string    `db:"subject"`
+	Email    *string   `db:"email"`
+	// CredentialsID is set if the identity is linked to a credentials user.
+	CredentialsID *uuid.UUID `db:"credentials_id"`
+	CreatedAt     time.Time  `db:"created_at"`
+	LastLoginAt   *time.Time `db:"last_login_at"`
+}
+
+// Session defines an entry in the federated_sessions table,
+// kept between the start of a sign-in at a provider and its callback.
+type Session struct {
+	State        string    `db:"state"`
+	Provider     string    `db:"provider"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// InsertIdentity inserts a new federated [Identity].
+// Returns [database.InputError] if the provider or subject is empty, [database.DuplicateEntryError]
+// if the subject is known, [database.RowsAffectedError] or [database.OperationFailedError].
+func InsertIdentity(ctx context.Context, db database.Querier, i Identity) error {
+	ctx, span := tracer.Start(ctx, "InsertIdentity")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("id", i.ID.String()),
+		attribute.String("provider", i.Provider),
+	)
+
+	if i.Provider == "" {
+		return database.NewInputError(ctx, errors.New("federated: provider is empty"), "provider", i.Provider)
+	}
+	if i.Subject == "" {
+		return database.NewInputError(ctx, errors.New("federated: subject is empty"), "subject", i.Subject)
+	}
+
+	query := `
+    INSERT INTO federated_identities (id, provider, subject, email, credentials_id, created_at, last_login_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $6)
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, i.ID, i.Provider, i.Subject, i.Email, i.CredentialsID, i.CreatedAt)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "federated_identity")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// ReadIdentity reads the federated [Identity] of a subject at a provider.
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func ReadIdentity(ctx context.Context, db database.Querier, provider, subject string) (*Identity, error) {
+	ctx, span := tracer.Start(ctx, "ReadIdentity")
+	defer span.End()
+	span.SetAttributes(attribute.String("provider", provider))
+
+	query := `
+    SELECT id, provider, subject, email, credentials_id, created_at, last_login_at
+    FROM federated_identities
+    WHERE provider = $1 AND subject = $2
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	var i Identity
+	if err := db.QueryRowContext(ctx, query, provider, subject).Scan(
+		&i.ID,
+		&i.Provider,
+		&i.Subject,
+		&i.Email,
+		&i.CredentialsID,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "federated_identity", subject)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	return &i, nil
+}
+
+// UpdateLogin records a sign-in of an [Identity] and the email the provider reported for it.
+// Returns [database.RowsAffectedError] or [database.OperationFailedError].
+func UpdateLogin(ctx context.Context, db database.Querier, id uuid.UUID, email *string, at time.Time) error {
+	ctx, span := tracer.Start(ctx, "UpdateLogin")
+	defer span.End()
+	span.SetAttributes(attribute.String("id", id.String()))
+
+	query := `UPDATE federated_identities SET email = $2, last_login_at = $3 WHERE id = $1`
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, id, email, at)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// InsertSession stores a started sign-in until the provider redirects back.
+// Returns [database.RowsAffectedError] or [database.OperationFailedError].
+func InsertSession(ctx context.Context, db database.Querier, s Session) error {
+	ctx, span := tracer.Start(ctx, "InsertSession")
+	defer span.End()
+	span.SetAttributes(attribute.String("provider", s.Provider))
+
+	if s.State == "" {
+		return database.NewInputError(ctx, errors.New("federated: state is empty"), "state", s.State)
+	}
+
+	query := `
+    INSERT INTO federated_sessions (state, provider, nonce, code_verifier, expires_at)
+    VALUES ($1, $2, $3, $4, $5)
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, s.State, s.Provider, s.Nonce, s.CodeVerifier, s.ExpiresAt)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// TakeSession deletes a session and returns it, so that every sign-in can only be completed once.
+// Expired sessions are reported as [database.NotFoundError].
+func TakeSession(ctx context.Context, db database.Querier, state string) (*Session, error) {
+	ctx, span := tracer.Start(ctx, "TakeSession")
+	defer span.End()
+
+	if state == "" {
+		return nil, database.NewInputError(ctx, errors.New("federated: state is empty"), "state", state)
+	}
+
+	query := `
+    DELETE FROM federated_sessions
+    WHERE state = $1 AND expires_at > $2
+    RETURNING state, provider, nonce, code_verifier, expires_at
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	var s Session
+	if err := db.QueryRowContext(ctx, query, state, time.Now()).Scan(
+		&s.State,
+		&s.Provider,
+		&s.Nonce,
+		&s.CodeVerifier,
+		&s.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "federated_session", state)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	return &s, nil
+}
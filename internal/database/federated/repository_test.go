@@ -0,0 +1,109 @@
+//go:build testdb
+// +build testdb
+
+package federated_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/federated"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentity(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(federated.IdentitiesTablename)
+	t.Cleanup(cleanup)
+
+	email := random.Email()
+	i := federated.Identity{
+		ID:        uuid.New(),
+		Provider:  "google",
+		Subject:   random.String(21),
+		Email:     &email,
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		require.NoError(t, federated.InsertIdentity(ctx, db, i))
+
+		got, err := federated.ReadIdentity(ctx, db, i.Provider, i.Subject)
+		require.NoError(t, err)
+		require.Equal(t, i.ID, got.ID)
+		require.Equal(t, email, *got.Email)
+		require.Nil(t, got.CredentialsID)
+		require.NotNil(t, got.LastLoginAt)
+	})
+
+	t.Run("subject is unique per provider", func(t *testing.T) {
+		dup := i
+		dup.ID = uuid.New()
+		require.ErrorAs(t, federated.InsertIdentity(ctx, db, dup), &database.DuplicateEntryError{})
+
+		dup.Provider = "github"
+		require.NoError(t, federated.InsertIdentity(ctx, db, dup))
+	})
+
+	t.Run("empty subject returns error", func(t *testing.T) {
+		empty := i
+		empty.ID, empty.Subject = uuid.New(), ""
+		require.ErrorAs(t, federated.InsertIdentity(ctx, db, empty), &database.InputError{})
+	})
+
+	t.Run("update login", func(t *testing.T) {
+		newEmail := random.Email()
+		require.NoError(t, federated.UpdateLogin(ctx, db, i.ID, &newEmail, time.Now()))
+
+		got, err := federated.ReadIdentity(ctx, db, i.Provider, i.Subject)
+		require.NoError(t, err)
+		require.Equal(t, newEmail, *got.Email)
+	})
+
+	t.Run("unknown subject returns error", func(t *testing.T) {
+		_, err := federated.ReadIdentity(ctx, db, i.Provider, random.String(21))
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+}
+
+func TestSession(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(federated.SessionsTablename)
+	t.Cleanup(cleanup)
+
+	t.Run("session can only be taken once", func(t *testing.T) {
+		s := federated.Session{
+			State:        random.String(43),
+			Provider:     "google",
+			Nonce:        random.String(22),
+			CodeVerifier: random.String(43),
+			ExpiresAt:    time.Now().Add(time.Minute),
+		}
+		require.NoError(t, federated.InsertSession(ctx, db, s))
+
+		got, err := federated.TakeSession(ctx, db, s.State)
+		require.NoError(t, err)
+		require.Equal(t, s.CodeVerifier, got.CodeVerifier)
+
+		_, err = federated.TakeSession(ctx, db, s.State)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+
+	t.Run("expired session returns error", func(t *testing.T) {
+		s := federated.Session{
+			State:        random.String(43),
+			Provider:     "google",
+			Nonce:        random.String(22),
+			CodeVerifier: random.String(43),
+			ExpiresAt:    time.Now().Add(-time.Minute),
+		}
+		require.NoError(t, federated.InsertSession(ctx, db, s))
+
+		_, err := federated.TakeSession(ctx, db, s.State)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+}
@@ -0,0 +1,132 @@
+package loginattempt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("login_attempt")
+
+const Tablename = "login_attempts"
+
+// Entry defines an entry in the login_attempts table, the recent failed logins of an account or client IP.
+type Entry struct {
+	Scope         string     `db:"scope"`
+	Key           string     `db:"key"`
+	Failures      int        `db:"failures"`
+	LastFailureAt time.Time  `db:"last_failure_at"`
+	LockedUntil   *time.Time `db:"locked_until"`
+}
+
+// Read the [Entry] of a key within a scope.
+// Returns [database.NotFoundError] if the key has no failed logins, otherwise [database.OperationFailedError].
+func Read(ctx context.Context, db database.Querier, scope, key string) (*Entry, error) {
+	ctx, span := tracer.Start(ctx, "Read")
+	defer span.End()
+
+	query := `
+    SELECT scope, key, failures, last_failure_at, locked_until
+    FROM login_attempts
+    WHERE scope = $1 AND key = $2
+    `
+	span.SetAttributes(
+		attribute.String("scope", scope),
+		attribute.String("query", query),
+	)
+
+	var entry Entry
+	if err := db.QueryRowContext(ctx, query, scope, key).Scan(
+		&entry.Scope,
+		&entry.Key,
+		&entry.Failures,
+		&entry.LastFailureAt,
+		&entry.LockedUntil,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "login_attempts", key)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+
+	return &entry, nil
+}
+
+// Fail records a failed login of a key within a scope at the given time and returns the
+// consecutive failures of the key. Failures before windowStart are forgotten and counting starts over.
+// Returns [database.OperationFailedError] on error.
+func Fail(ctx context.Context, db database.Querier, scope, key string, at, windowStart time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "Fail")
+	defer span.End()
+
+	query := `
+    INSERT INTO login_attempts (scope, key, failures, last_failure_at)
+    VALUES ($1, $2, 1, $3)
+    ON CONFLICT (scope, key) DO UPDATE SET
+        failures = CASE WHEN login_attempts.last_failure_at < $4 THEN 1 ELSE login_attempts.failures + 1 END,
+        last_failure_at = $3
+    RETURNING failures
+    `
+	span.SetAttributes(
+		attribute.String("scope", scope),
+		attribute.String("query", query),
+	)
+
+	var failures int
+	if err := db.QueryRowContext(ctx, query, scope, key, at, windowStart).Scan(&failures); err != nil {
+		return 0, database.NewOperationFailedError(ctx, err)
+	}
+
+	return failures, nil
+}
+
+// Lock a key within a scope until the given time.
+// Returns [database.RowsAffectedError] if the key has no failed logins, otherwise [database.OperationFailedError].
+func Lock(ctx context.Context, db database.Querier, scope, key string, until time.Time) error {
+	ctx, span := tracer.Start(ctx, "Lock")
+	defer span.End()
+
+	query := `UPDATE login_attempts SET locked_until = $1 WHERE scope = $2 AND key = $3`
+	span.SetAttributes(
+		attribute.String("scope", scope),
+		attribute.String("query", query),
+	)
+
+	res, err := db.ExecContext(ctx, query, until, scope, key)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// Delete the failed logins of a key within a scope. Deleting a key without any is not an error.
+// Returns [database.OperationFailedError] on error.
+func Delete(ctx context.Context, db database.Querier, scope, key string) error {
+	ctx, span := tracer.Start(ctx, "Delete")
+	defer span.End()
+
+	query := `DELETE FROM login_attempts WHERE scope = $1 AND key = $2`
+	span.SetAttributes(
+		attribute.String("scope", scope),
+		attribute.String("query", query),
+	)
+
+	if _, err := db.ExecContext(ctx, query, scope, key); err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+
+	return nil
+}
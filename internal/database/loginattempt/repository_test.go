@@ -0,0 +1,88 @@
+//go:build testdb
+// +build testdb
+
+package loginattempt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/loginattempt"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFail(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(loginattempt.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("counts consecutive failures", func(t *testing.T) {
+		key := random.Email()
+		now := time.Now()
+		for want := 1; want <= 3; want++ {
+			got, err := loginattempt.Fail(ctx, db, "account", key, now, now.Add(-time.Minute))
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		}
+
+		e, err := loginattempt.Read(ctx, db, "account", key)
+		require.NoError(t, err)
+		require.Equal(t, 3, e.Failures)
+		require.Nil(t, e.LockedUntil)
+	})
+
+	t.Run("starts over outside the window", func(t *testing.T) {
+		key := random.Email()
+		now := time.Now()
+		_, err := loginattempt.Fail(ctx, db, "account", key, now.Add(-time.Hour), now.Add(-2*time.Hour))
+		require.NoError(t, err)
+
+		got, err := loginattempt.Fail(ctx, db, "account", key, now, now.Add(-time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 1, got)
+	})
+
+	t.Run("scopes are separate", func(t *testing.T) {
+		key := random.String(10)
+		now := time.Now()
+		_, err := loginattempt.Fail(ctx, db, "account", key, now, now.Add(-time.Minute))
+		require.NoError(t, err)
+
+		got, err := loginattempt.Fail(ctx, db, "ip", key, now, now.Add(-time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 1, got)
+	})
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(loginattempt.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		key := random.Email()
+		now := time.Now()
+		_, err := loginattempt.Fail(ctx, db, "account", key, now, now.Add(-time.Minute))
+		require.NoError(t, err)
+
+		until := now.Add(time.Hour)
+		require.NoError(t, loginattempt.Lock(ctx, db, "account", key, until))
+
+		e, err := loginattempt.Read(ctx, db, "account", key)
+		require.NoError(t, err)
+		require.NotNil(t, e.LockedUntil)
+		require.WithinDuration(t, until, *e.LockedUntil, time.Millisecond)
+
+		require.NoError(t, loginattempt.Delete(ctx, db, "account", key))
+		_, err = loginattempt.Read(ctx, db, "account", key)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+
+	t.Run("unknown key returns error", func(t *testing.T) {
+		err := loginattempt.Lock(ctx, db, "account", random.Email(), time.Now())
+		require.ErrorAs(t, err, &database.RowsAffectedError{})
+	})
+}
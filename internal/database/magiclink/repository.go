@@ -0,0 +1,170 @@
+package magiclink
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("magic_link")
+
+const (
+	UsersTablename  = "magic_link_users"
+	TokensTablename = "magic_link_tokens"
+)
+
+// User defines an entry in the magic_link_users table.
+type User struct {
+	ID        uuid.UUID `db:"id"`
+	Email     string    `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// InsertUser inserts a new passwordless user.
+// Returns [database.DuplicateEntryError] on duplicate entry,
+// [database.RowsAffectedError] or [database.OperationFailedError].
+func InsertUser(ctx context.Context, db database.Querier, user User) error {
+	ctx, span := tracer.Start(ctx, "InsertUser")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("id", user.ID.String()),
+		attribute.String("email", user.Email),
+	)
+
+	if user.Email == "" {
+		return database.NewInputError(ctx, errors.New("magiclink: email is empty"), "email", user.Email)
+	}
+
+	query := `INSERT INTO magic_link_users (id, email, created_at) VALUES ($1, $2, $3)`
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, user.ID, user.Email, user.CreatedAt)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "magic_link_user")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// ReadUser reads a passwordless [User] by ID.
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func ReadUser(ctx context.Context, db database.Querier, id uuid.UUID) (*User, error) {
+	ctx, span := tracer.Start(ctx, "ReadUser")
+	defer span.End()
+	span.SetAttributes(attribute.String("id", id.String()))
+
+	query := `SELECT id, email, created_at FROM magic_link_users WHERE id = $1`
+	span.SetAttributes(attribute.String("query", query))
+
+	var user User
+	if err := db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Email, &user.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "magic_link_user", id)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	return &user, nil
+}
+
+// ReadUserByEmail reads a passwordless [User] by email.
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func ReadUserByEmail(ctx context.Context, db database.Querier, email string) (*User, error) {
+	ctx, span := tracer.Start(ctx, "ReadUserByEmail")
+	defer span.End()
+	span.SetAttributes(attribute.String("email", email))
+
+	if email == "" {
+		return nil, database.NewInputError(ctx, errors.New("magiclink: email is empty"), "email", email)
+	}
+
+	query := `SELECT id, email, created_at FROM magic_link_users WHERE email = $1`
+	span.SetAttributes(attribute.String("query", query))
+
+	var user User
+	if err := db.QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Email, &user.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "magic_link_user", email)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	return &user, nil
+}
+
+// InsertToken stores a sign-in token for a user. Only the SHA-256 hash
+// of the token is stored, the token itself is only ever sent by email.
+func InsertToken(ctx context.Context, db database.Querier, token string, userID uuid.UUID, expiresAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "InsertToken")
+	defer span.End()
+	span.SetAttributes(attribute.String("user_id", userID.String()))
+
+	if token == "" {
+		return database.NewInputError(ctx, errors.New("magiclink: token is empty"), "token", "")
+	}
+
+	query := `INSERT INTO magic_link_tokens (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`
+	span.SetAttributes(attribute.String("query", query))
+
+	h := sha256.Sum256([]byte(token))
+	res, err := db.ExecContext(ctx, query, h[:], userID, expiresAt)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "magic_link_token")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// TakeToken deletes a token and returns the ID of the user it was issued to,
+// so that every token can only be redeemed once.
+// Expired tokens are reported as [database.NotFoundError].
+func TakeToken(ctx context.Context, db database.Querier, token string) (uuid.UUID, error) {
+	ctx, span := tracer.Start(ctx, "TakeToken")
+	defer span.End()
+
+	if token == "" {
+		return uuid.Nil, database.NewInputError(ctx, errors.New("magiclink: token is empty"), "token", "")
+	}
+
+	query := `
+    DELETE FROM magic_link_tokens
+    WHERE token_hash = $1 AND expires_at > $2
+    RETURNING user_id
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	h := sha256.Sum256([]byte(token))
+	var id uuid.UUID
+	if err := db.QueryRowContext(ctx, query, h[:], time.Now()).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, database.NewNotFoundError(ctx, err, "magic_link_token", "REDACTED")
+		}
+		return uuid.Nil, database.NewOperationFailedError(ctx, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,102 @@
+//go:build testdb
+// +build testdb
+
+package magiclink_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/magiclink"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func insertUser(t *testing.T, ctx context.Context, db database.Querier) magiclink.User {
+	t.Helper()
+
+	user := magiclink.User{
+		ID:        uuid.New(),
+		Email:     random.Email(),
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, magiclink.InsertUser(ctx, db, user))
+	return user
+}
+
+func TestInsertUser(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(magiclink.UsersTablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		user := insertUser(t, ctx, db)
+
+		got, err := magiclink.ReadUser(ctx, db, user.ID)
+		require.NoError(t, err)
+		require.Equal(t, user.Email, got.Email)
+
+		got, err = magiclink.ReadUserByEmail(ctx, db, user.Email)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, got.ID)
+	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		user := insertUser(t, ctx, db)
+
+		err := magiclink.InsertUser(ctx, db, magiclink.User{ID: uuid.New(), Email: user.Email, CreatedAt: time.Now()})
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.DuplicateEntryError{})
+	})
+
+	t.Run("empty email", func(t *testing.T) {
+		err := magiclink.InsertUser(ctx, db, magiclink.User{ID: uuid.New(), CreatedAt: time.Now()})
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.InputError{})
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := magiclink.ReadUserByEmail(ctx, db, random.Email())
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+}
+
+func TestTakeToken(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(magiclink.TokensTablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK and single use", func(t *testing.T) {
+		user := insertUser(t, ctx, db)
+		token := random.String(32)
+		require.NoError(t, magiclink.InsertToken(ctx, db, token, user.ID, time.Now().Add(time.Minute)))
+
+		id, err := magiclink.TakeToken(ctx, db, token)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, id)
+
+		_, err = magiclink.TakeToken(ctx, db, token)
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		user := insertUser(t, ctx, db)
+		token := random.String(32)
+		require.NoError(t, magiclink.InsertToken(ctx, db, token, user.ID, time.Now().Add(-time.Minute)))
+
+		_, err := magiclink.TakeToken(ctx, db, token)
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		_, err := magiclink.TakeToken(ctx, db, random.String(32))
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS federated_sessions;
+DROP TABLE IF EXISTS federated_identities;
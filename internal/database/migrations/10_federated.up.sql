@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS federated_identities (
+    id uuid PRIMARY KEY,
+    provider varchar(64) NOT NULL,
+    subject varchar(255) NOT NULL,
+    email varchar(255) NULL,
+    credentials_id uuid NULL REFERENCES credentials(id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at timestamptz NULL,
+    UNIQUE (provider, subject)
+);
+
+CREATE TABLE IF NOT EXISTS federated_sessions (
+    state text PRIMARY KEY,
+    provider varchar(64) NOT NULL,
+    nonce text NOT NULL,
+    code_verifier text NOT NULL,
+    expires_at timestamptz NOT NULL
+);
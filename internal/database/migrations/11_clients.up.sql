@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS clients (
+    id uuid PRIMARY KEY,
+    name varchar(255) NOT NULL,
+    secret_hash bytea NOT NULL,
+    scopes text[] NOT NULL DEFAULT '{}',
+    token_ttl_seconds integer NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    rotated_at timestamptz NULL
+);
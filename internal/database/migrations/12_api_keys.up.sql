@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id uuid PRIMARY KEY,
+    key_hash bytea NOT NULL UNIQUE,
+    prefix varchar(16) NOT NULL,
+    name varchar(255) NOT NULL,
+    strategy integer NOT NULL,
+    identifier text NOT NULL,
+    scopes text[] NOT NULL DEFAULT '{}',
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamptz NOT NULL,
+    last_used_at timestamptz NULL
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_owner_idx ON api_keys (strategy, identifier);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS password_history (
+    id bigserial PRIMARY KEY,
+    credentials_id uuid NOT NULL REFERENCES credentials(id) ON DELETE CASCADE,
+    password_hash varchar(255) NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS password_history_credentials_id_idx ON password_history (credentials_id, created_at DESC);
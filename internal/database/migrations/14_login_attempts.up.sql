@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+    scope varchar(16) NOT NULL,
+    key text NOT NULL,
+    failures integer NOT NULL,
+    last_failure_at timestamptz NOT NULL,
+    locked_until timestamptz NULL,
+    PRIMARY KEY (scope, key)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE email_changes ADD COLUMN IF NOT EXISTS expires_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE email_changes ALTER COLUMN expires_at DROP DEFAULT;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS totp_secrets (
+    credential_id uuid PRIMARY KEY REFERENCES credentials(id) ON DELETE CASCADE,
+    secret bytea NOT NULL,
+    last_counter bigint NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    confirmed_at timestamptz NULL
+);
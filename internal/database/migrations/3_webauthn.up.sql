@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS webauthn_users (
+    id uuid PRIMARY KEY,
+    name varchar(255) NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id bytea PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES webauthn_users(id) ON DELETE CASCADE,
+    public_key bytea NOT NULL,
+    attestation_type varchar(64) NOT NULL,
+    aaguid bytea NOT NULL,
+    sign_count bigint NOT NULL DEFAULT 0,
+    transports text[] NOT NULL DEFAULT '{}',
+    backup_eligible boolean NOT NULL DEFAULT false,
+    backup_state boolean NOT NULL DEFAULT false,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at timestamptz NULL
+);
+
+CREATE INDEX IF NOT EXISTS webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
+
+CREATE TABLE IF NOT EXISTS webauthn_sessions (
+    id uuid PRIMARY KEY,
+    data bytea NOT NULL,
+    expires_at timestamptz NOT NULL
+);
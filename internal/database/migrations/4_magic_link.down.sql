@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS magic_link_tokens;
+DROP TABLE IF EXISTS magic_link_users;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    token TEXT UNIQUE PRIMARY KEY,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamptz NOT NULL
+);
+
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS password_changed_at timestamptz NULL;
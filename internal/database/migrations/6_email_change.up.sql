@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS email_changes (
+    token TEXT UNIQUE PRIMARY KEY,
+    credentials_id uuid NOT NULL REFERENCES credentials(id) ON DELETE CASCADE,
+    email varchar(255) NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
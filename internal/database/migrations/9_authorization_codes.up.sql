@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS authorization_codes (
+    code_hash text PRIMARY KEY,
+    client_id text NOT NULL,
+    redirect_uri text NOT NULL,
+    code_challenge text NOT NULL,
+    nonce text NOT NULL DEFAULT '',
+    strategy integer NOT NULL,
+    identifier text NOT NULL,
+    scopes text[] NOT NULL DEFAULT '{}',
+    auth_time timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS authorization_codes_expires_at_idx ON authorization_codes (expires_at);
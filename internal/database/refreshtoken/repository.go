@@ -0,0 +1,165 @@
+package refreshtoken
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("refresh_token")
+
+const Tablename = "refresh_tokens"
+
+// Entry defines an entry in the refresh_tokens table.
+type Entry struct {
+	ID        uuid.UUID  `db:"id"`
+	FamilyID  uuid.UUID  `db:"family_id"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+// Insert a new refresh token entry.
+// Returns [database.DuplicateEntryError] on duplicate entry,
+// [database.RowsAffectedError] or [database.OperationFailedError].
+func Insert(ctx context.Context, db database.Querier, id, familyID uuid.UUID, expiresAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "Insert")
+	defer span.End()
+
+	query := `INSERT INTO refresh_tokens (id, family_id, expires_at) VALUES ($1, $2, $3)`
+	span.SetAttributes(
+		attribute.String("id", id.String()),
+		attribute.String("family_id", familyID.String()),
+		attribute.String("query", query),
+	)
+
+	res, err := db.ExecContext(ctx, query, id, familyID, expiresAt)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "refresh_tokens")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// Read a refresh token [Entry] by ID.
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func Read(ctx context.Context, db database.Querier, id uuid.UUID) (*Entry, error) {
+	ctx, span := tracer.Start(ctx, "Read")
+	defer span.End()
+
+	query := `
+    SELECT id, family_id, created_at, expires_at, used_at, revoked_at
+    FROM refresh_tokens
+    WHERE id = $1
+    `
+	span.SetAttributes(
+		attribute.String("id", id.String()),
+		attribute.String("query", query),
+	)
+
+	var entry Entry
+	if err := db.QueryRowContext(ctx, query, id).Scan(
+		&entry.ID,
+		&entry.FamilyID,
+		&entry.CreatedAt,
+		&entry.ExpiresAt,
+		&entry.UsedAt,
+		&entry.RevokedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "refresh_tokens", id.String())
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+
+	return &entry, nil
+}
+
+// Use marks a refresh token as used, so that it can not be used again.
+// Returns [database.RowsAffectedError] if the token does not exist, is expired,
+// already used or revoked, otherwise [database.OperationFailedError].
+func Use(ctx context.Context, db database.Querier, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "Use")
+	defer span.End()
+
+	query := `
+    UPDATE refresh_tokens SET used_at = $1
+    WHERE id = $2 AND used_at IS NULL AND revoked_at IS NULL AND expires_at > $1
+    `
+	span.SetAttributes(
+		attribute.String("id", id.String()),
+		attribute.String("query", query),
+	)
+
+	res, err := db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every refresh token of a family. Revoking an unknown family is not an error.
+// Returns [database.OperationFailedError] on error.
+func RevokeFamily(ctx context.Context, db database.Querier, familyID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "RevokeFamily")
+	defer span.End()
+
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+	span.SetAttributes(
+		attribute.String("family_id", familyID.String()),
+		attribute.String("query", query),
+	)
+
+	if _, err := db.ExecContext(ctx, query, time.Now(), familyID); err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes every refresh token that expired before the given time, used or not,
+// and returns how many were deleted. Tokens that have not expired are kept to detect their reuse.
+// Returns [database.OperationFailedError] on error.
+func DeleteExpired(ctx context.Context, db database.Querier, before time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "DeleteExpired")
+	defer span.End()
+
+	query := `DELETE FROM refresh_tokens WHERE expires_at <= $1`
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, database.NewOperationFailedError(ctx, err)
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,121 @@
+//go:build testdb
+// +build testdb
+
+package refreshtoken_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/refreshtoken"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsert(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(refreshtoken.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		id, family := uuid.New(), uuid.New()
+		require.NoError(t, refreshtoken.Insert(ctx, db, id, family, time.Now().Add(time.Hour)))
+
+		got, err := refreshtoken.Read(ctx, db, id)
+		require.NoError(t, err)
+		require.Equal(t, family, got.FamilyID)
+		require.Nil(t, got.UsedAt)
+		require.Nil(t, got.RevokedAt)
+	})
+
+	t.Run("duplicate entry returns error", func(t *testing.T) {
+		id := uuid.New()
+		require.NoError(t, refreshtoken.Insert(ctx, db, id, uuid.New(), time.Now().Add(time.Hour)))
+
+		err := refreshtoken.Insert(ctx, db, id, uuid.New(), time.Now().Add(time.Hour))
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.DuplicateEntryError{})
+	})
+
+	t.Run("not found returns error", func(t *testing.T) {
+		_, err := refreshtoken.Read(ctx, db, uuid.New())
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+}
+
+func TestUse(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(refreshtoken.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK only once", func(t *testing.T) {
+		id := uuid.New()
+		require.NoError(t, refreshtoken.Insert(ctx, db, id, uuid.New(), time.Now().Add(time.Hour)))
+
+		require.NoError(t, refreshtoken.Use(ctx, db, id))
+
+		got, err := refreshtoken.Read(ctx, db, id)
+		require.NoError(t, err)
+		require.NotNil(t, got.UsedAt)
+
+		err = refreshtoken.Use(ctx, db, id)
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.RowsAffectedError{})
+	})
+
+	t.Run("expired returns error", func(t *testing.T) {
+		id := uuid.New()
+		require.NoError(t, refreshtoken.Insert(ctx, db, id, uuid.New(), time.Now().Add(-time.Hour)))
+
+		err := refreshtoken.Use(ctx, db, id)
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.RowsAffectedError{})
+	})
+
+	t.Run("revoked family returns error", func(t *testing.T) {
+		family := uuid.New()
+		first, second := uuid.New(), uuid.New()
+		require.NoError(t, refreshtoken.Insert(ctx, db, first, family, time.Now().Add(time.Hour)))
+		require.NoError(t, refreshtoken.Insert(ctx, db, second, family, time.Now().Add(time.Hour)))
+
+		require.NoError(t, refreshtoken.RevokeFamily(ctx, db, family))
+
+		for _, id := range []uuid.UUID{first, second} {
+			err := refreshtoken.Use(ctx, db, id)
+			require.Error(t, err)
+			require.ErrorAs(t, err, &database.RowsAffectedError{})
+
+			got, err := refreshtoken.Read(ctx, db, id)
+			require.NoError(t, err)
+			require.NotNil(t, got.RevokedAt)
+		}
+	})
+}
+
+func TestDeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(refreshtoken.Tablename)
+	t.Cleanup(cleanup)
+
+	expired, used, active := uuid.New(), uuid.New(), uuid.New()
+	require.NoError(t, refreshtoken.Insert(ctx, db, expired, uuid.New(), time.Now().Add(-time.Minute)))
+	require.NoError(t, refreshtoken.Insert(ctx, db, used, uuid.New(), time.Now().Add(time.Hour)))
+	require.NoError(t, refreshtoken.Use(ctx, db, used))
+	require.NoError(t, refreshtoken.Insert(ctx, db, active, uuid.New(), time.Now().Add(time.Hour)))
+
+	n, err := refreshtoken.DeleteExpired(ctx, db, time.Now())
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	_, err = refreshtoken.Read(ctx, db, expired)
+	require.ErrorAs(t, err, &database.NotFoundError{})
+
+	// Used tokens are kept until they expire, so that their reuse is detected.
+	_, err = refreshtoken.Read(ctx, db, used)
+	require.NoError(t, err)
+	_, err = refreshtoken.Read(ctx, db, active)
+	require.NoError(t, err)
+}
@@ -0,0 +1,88 @@
+package revokedtoken
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("revoked_token")
+
+const Tablename = "revoked_tokens"
+
+// Entry defines an entry in the revoked_tokens table.
+type Entry struct {
+	JTI       uuid.UUID `db:"jti"`
+	ExpiresAt time.Time `db:"expires_at"`
+	RevokedAt time.Time `db:"revoked_at"`
+}
+
+// Insert a revoked token ID that is kept until expiresAt. Revoking an ID twice is not an error.
+// Returns [database.OperationFailedError] on error.
+func Insert(ctx context.Context, db database.Querier, jti uuid.UUID, expiresAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "Insert")
+	defer span.End()
+
+	query := `INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`
+	span.SetAttributes(
+		attribute.String("jti", jti.String()),
+		attribute.String("query", query),
+	)
+
+	if _, err := db.ExecContext(ctx, query, jti, expiresAt); err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+
+	return nil
+}
+
+// Read a revoked token [Entry] by ID.
+// Returns [database.NotFoundError] if the ID was not revoked, otherwise [database.OperationFailedError].
+func Read(ctx context.Context, db database.Querier, jti uuid.UUID) (*Entry, error) {
+	ctx, span := tracer.Start(ctx, "Read")
+	defer span.End()
+
+	query := `SELECT jti, expires_at, revoked_at FROM revoked_tokens WHERE jti = $1`
+	span.SetAttributes(
+		attribute.String("jti", jti.String()),
+		attribute.String("query", query),
+	)
+
+	var entry Entry
+	if err := db.QueryRowContext(ctx, query, jti).Scan(&entry.JTI, &entry.ExpiresAt, &entry.RevokedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "revoked_tokens", jti.String())
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+
+	return &entry, nil
+}
+
+// DeleteExpired deletes every revoked token ID that expired before the given time
+// and returns how many were deleted.
+// Returns [database.OperationFailedError] on error.
+func DeleteExpired(ctx context.Context, db database.Querier, before time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "DeleteExpired")
+	defer span.End()
+
+	query := `DELETE FROM revoked_tokens WHERE expires_at <= $1`
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, database.NewOperationFailedError(ctx, err)
+	}
+
+	return rowsAffected, nil
+}
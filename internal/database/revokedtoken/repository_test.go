@@ -0,0 +1,62 @@
+//go:build testdb
+// +build testdb
+
+package revokedtoken_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/revokedtoken"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsert(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(revokedtoken.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		jti := uuid.New()
+		require.NoError(t, revokedtoken.Insert(ctx, db, jti, time.Now().Add(time.Hour)))
+
+		got, err := revokedtoken.Read(ctx, db, jti)
+		require.NoError(t, err)
+		require.Equal(t, jti, got.JTI)
+	})
+
+	t.Run("revoking twice is not an error", func(t *testing.T) {
+		jti := uuid.New()
+		require.NoError(t, revokedtoken.Insert(ctx, db, jti, time.Now().Add(time.Hour)))
+		require.NoError(t, revokedtoken.Insert(ctx, db, jti, time.Now().Add(time.Hour)))
+	})
+
+	t.Run("unknown ID returns error", func(t *testing.T) {
+		_, err := revokedtoken.Read(ctx, db, uuid.New())
+		require.Error(t, err)
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+}
+
+func TestDeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(revokedtoken.Tablename)
+	t.Cleanup(cleanup)
+
+	expired, active := uuid.New(), uuid.New()
+	require.NoError(t, revokedtoken.Insert(ctx, db, expired, time.Now().Add(-time.Minute)))
+	require.NoError(t, revokedtoken.Insert(ctx, db, active, time.Now().Add(time.Hour)))
+
+	n, err := revokedtoken.DeleteExpired(ctx, db, time.Now())
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	_, err = revokedtoken.Read(ctx, db, expired)
+	require.ErrorAs(t, err, &database.NotFoundError{})
+
+	_, err = revokedtoken.Read(ctx, db, active)
+	require.NoError(t, err)
+}
@@ -0,0 +1,77 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const PasswordResetTablename = "password_reset_tokens"
+
+// InsertPasswordReset stores a password reset token that can be taken until expiresAt.
+func InsertPasswordReset(ctx context.Context, db database.Querier, token string, expiresAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "InsertPasswordReset")
+	defer span.End()
+	span.SetAttributes(attribute.String("token", token))
+
+	if token == "" {
+		return database.NewInputError(
+			ctx,
+			errors.New("token: token is empty"),
+			"token",
+			token,
+		)
+	}
+
+	query := `INSERT INTO password_reset_tokens (token, expires_at) VALUES ($1, $2)`
+	span.SetAttributes(attribute.String("query", query))
+	res, err := db.ExecContext(ctx, query, token, expiresAt)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "password_reset_tokens")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// TakePasswordReset deletes and returns a password reset token, so that it can only be used once.
+// Expired tokens are reported as [database.NotFoundError].
+func TakePasswordReset(ctx context.Context, db database.Querier, token string) (string, error) {
+	ctx, span := tracer.Start(ctx, "TakePasswordReset")
+	defer span.End()
+	span.SetAttributes(attribute.String("token", token))
+
+	if token == "" {
+		return "", database.NewInputError(
+			ctx,
+			errors.New("token: token is empty"),
+			"token",
+			token,
+		)
+	}
+
+	var s string
+	query := `DELETE FROM password_reset_tokens WHERE token = $1 AND expires_at > $2 RETURNING token`
+	span.SetAttributes(attribute.String("query", query))
+	if err := db.QueryRowContext(ctx, query, token, time.Now()).Scan(&s); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", database.NewNotFoundError(ctx, err, "password_reset_token", token)
+		}
+		return "", database.NewOperationFailedError(ctx, err)
+	}
+
+	return s, nil
+}
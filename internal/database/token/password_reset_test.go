@@ -0,0 +1,71 @@
+//go:build testdb
+// +build testdb
+
+package token_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/token"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+)
+
+func TestTakePasswordReset(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(token.PasswordResetTablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK and single use", func(t *testing.T) {
+		tt := fmt.Sprintf("%s/%s", uuid.NewString(), uuid.NewString())
+
+		if err := token.InsertPasswordReset(ctx, db, tt, time.Now().Add(time.Minute)); err != nil {
+			t.Error("expected no error")
+		}
+
+		got, err := token.TakePasswordReset(ctx, db, tt)
+		if err != nil {
+			t.Error("expected no error")
+		}
+		if got != tt {
+			t.Error("expected got to be equal to token")
+		}
+
+		_, err = token.TakePasswordReset(ctx, db, tt)
+		if !errors.As(err, &database.NotFoundError{}) {
+			t.Error("expected not found error")
+		}
+	})
+
+	t.Run("expired returns error", func(t *testing.T) {
+		tt := fmt.Sprintf("%s/%s", uuid.NewString(), uuid.NewString())
+
+		if err := token.InsertPasswordReset(ctx, db, tt, time.Now().Add(-time.Minute)); err != nil {
+			t.Error("expected no error")
+		}
+
+		_, err := token.TakePasswordReset(ctx, db, tt)
+		if !errors.As(err, &database.NotFoundError{}) {
+			t.Error("expected not found error")
+		}
+	})
+
+	t.Run("empty string returns error", func(t *testing.T) {
+		_, err := token.TakePasswordReset(ctx, db, "")
+		if !errors.As(err, &database.InputError{}) {
+			t.Error("expected input error")
+		}
+	})
+
+	t.Run("not found returns error", func(t *testing.T) {
+		_, err := token.TakePasswordReset(ctx, db, random.String(100))
+		if !errors.As(err, &database.NotFoundError{}) {
+			t.Error("expected not found error")
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/Salam4nder/identity/internal/database"
 	"go.opentelemetry.io/otel"
@@ -14,6 +15,12 @@ var tracer = otel.Tracer("token")
 
 const Tablename = "tokens"
 
+// Entry defines an entry in the tokens table.
+type Entry struct {
+	Token     string    `db:"token"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
 func Insert(ctx context.Context, db database.Querier, token string) error {
 	ctx, span := tracer.Start(ctx, "Insert")
 	defer span.End()
@@ -75,6 +82,35 @@ func Get(ctx context.Context, db *sql.DB, token string) (string, error) {
 	return s, nil
 }
 
+// Read a token [Entry].
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func Read(ctx context.Context, db database.Querier, token string) (*Entry, error) {
+	ctx, span := tracer.Start(ctx, "Read")
+	defer span.End()
+	span.SetAttributes(attribute.String("token", token))
+
+	if token == "" {
+		return nil, database.NewInputError(
+			ctx,
+			errors.New("token: token is empty"),
+			"token",
+			token,
+		)
+	}
+
+	var entry Entry
+	query := `SELECT token, created_at FROM tokens WHERE token = $1`
+	span.SetAttributes(attribute.String("query", query))
+	if err := db.QueryRowContext(ctx, query, token).Scan(&entry.Token, &entry.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "token", token)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+
+	return &entry, nil
+}
+
 func Delete(ctx context.Context, db database.Querier, token string) error {
 	ctx, span := tracer.Start(ctx, "Delete")
 	defer span.End()
@@ -105,3 +141,29 @@ func Delete(ctx context.Context, db database.Querier, token string) error {
 
 	return nil
 }
+
+// DeleteByPrefix deletes all tokens starting with the given prefix followed by a slash,
+// such as all verification tokens of a user ID. Deleting nothing is not an error.
+// Returns [database.OperationFailedError] on error.
+func DeleteByPrefix(ctx context.Context, db database.Querier, prefix string) error {
+	ctx, span := tracer.Start(ctx, "DeleteByPrefix")
+	defer span.End()
+	span.SetAttributes(attribute.String("prefix", prefix))
+
+	if prefix == "" {
+		return database.NewInputError(
+			ctx,
+			errors.New("token: prefix is empty"),
+			"prefix",
+			prefix,
+		)
+	}
+
+	query := `DELETE FROM tokens WHERE starts_with(token, $1 || '/')`
+	span.SetAttributes(attribute.String("query", query))
+	if _, err := db.ExecContext(ctx, query, prefix); err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+
+	return nil
+}
@@ -139,3 +139,72 @@ func TestDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestRead(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(token.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		tt := fmt.Sprintf("%s/%s", uuid.NewString(), uuid.NewString())
+
+		if err := token.Insert(ctx, db, tt); err != nil {
+			t.Error("expected no error")
+		}
+
+		got, err := token.Read(ctx, db, tt)
+		if err != nil {
+			t.Error("expected no error")
+		}
+		if got.Token != tt {
+			t.Error("expected got to be equal to token")
+		}
+		if got.CreatedAt.IsZero() {
+			t.Error("expected created at to be set")
+		}
+	})
+
+	t.Run("not found returns error", func(t *testing.T) {
+		_, err := token.Read(ctx, db, random.String(100))
+		if !errors.As(err, &database.NotFoundError{}) {
+			t.Error("expected not found error")
+		}
+	})
+}
+
+func TestDeleteByPrefix(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(token.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		id := uuid.NewString()
+		first := fmt.Sprintf("%s/%s", id, uuid.NewString())
+		second := fmt.Sprintf("%s/%s", id, uuid.NewString())
+		other := fmt.Sprintf("%s/%s", uuid.NewString(), uuid.NewString())
+		for _, tt := range []string{first, second, other} {
+			if err := token.Insert(ctx, db, tt); err != nil {
+				t.Error("expected no error")
+			}
+		}
+
+		if err := token.DeleteByPrefix(ctx, db, id); err != nil {
+			t.Error("expected no error")
+		}
+
+		for _, tt := range []string{first, second} {
+			if _, err := token.Get(ctx, db, tt); !errors.As(err, &database.NotFoundError{}) {
+				t.Error("expected token to be deleted")
+			}
+		}
+		if _, err := token.Get(ctx, db, other); err != nil {
+			t.Error("expected other token to remain")
+		}
+	})
+
+	t.Run("nothing to delete", func(t *testing.T) {
+		if err := token.DeleteByPrefix(ctx, db, uuid.NewString()); err != nil {
+			t.Error("expected no error")
+		}
+	})
+}
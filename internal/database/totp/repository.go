@@ -0,0 +1,161 @@
+package totp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("totp")
+
+const Tablename = "totp_secrets"
+
+// Entry defines an entry in the totp_secrets table.
+// The secret is stored encrypted and is never logged or traced.
+type Entry struct {
+	CredentialID uuid.UUID  `db:"credential_id"`
+	Secret       []byte     `db:"secret"`
+	LastCounter  uint64     `db:"last_counter"`
+	CreatedAt    time.Time  `db:"created_at"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+}
+
+// Upsert stores an encrypted secret for a credential.
+// An unconfirmed secret is replaced, a confirmed one is left untouched and
+// [database.RowsAffectedError] is returned.
+func Upsert(ctx context.Context, db database.Querier, credentialID uuid.UUID, secret []byte) error {
+	ctx, span := tracer.Start(ctx, "Upsert")
+	defer span.End()
+	span.SetAttributes(attribute.String("credential_id", credentialID.String()))
+
+	if credentialID == uuid.Nil {
+		return database.NewInputError(ctx, errors.New("totp: credential id is empty"), "credential_id", credentialID)
+	}
+	if len(secret) == 0 {
+		return database.NewInputError(ctx, errors.New("totp: secret is empty"), "secret", "")
+	}
+
+	query := `
+    INSERT INTO totp_secrets (credential_id, secret, created_at)
+    VALUES ($1, $2, $3)
+    ON CONFLICT (credential_id) DO UPDATE
+    SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_counter = 0
+    WHERE totp_secrets.confirmed_at IS NULL
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, credentialID, secret, time.Now())
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// Read a TOTP [Entry] by credential ID.
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func Read(ctx context.Context, db database.Querier, credentialID uuid.UUID) (*Entry, error) {
+	ctx, span := tracer.Start(ctx, "Read")
+	defer span.End()
+	span.SetAttributes(attribute.String("credential_id", credentialID.String()))
+
+	if credentialID == uuid.Nil {
+		return nil, database.NewInputError(ctx, errors.New("totp: credential id is empty"), "credential_id", credentialID)
+	}
+
+	query := `
+    SELECT credential_id, secret, last_counter, created_at, confirmed_at
+    FROM totp_secrets
+    WHERE credential_id = $1
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	var entry Entry
+	if err := db.QueryRowContext(ctx, query, credentialID).Scan(
+		&entry.CredentialID,
+		&entry.Secret,
+		&entry.LastCounter,
+		&entry.CreatedAt,
+		&entry.ConfirmedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "totp_secret", credentialID)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+
+	return &entry, nil
+}
+
+// Confirm marks the secret of a credential as confirmed, enabling it for logins.
+func Confirm(ctx context.Context, db database.Querier, credentialID uuid.UUID, counter uint64) error {
+	ctx, span := tracer.Start(ctx, "Confirm")
+	defer span.End()
+
+	query := `
+    UPDATE totp_secrets SET confirmed_at = $1, last_counter = $2
+    WHERE credential_id = $3 AND confirmed_at IS NULL
+    `
+	span.SetAttributes(
+		attribute.String("credential_id", credentialID.String()),
+		attribute.String("query", query),
+	)
+
+	res, err := db.ExecContext(ctx, query, time.Now(), counter, credentialID)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// UpdateCounter stores the last used time step of a confirmed secret.
+// The update only succeeds if counter is greater than the stored one,
+// which guards against replaying a code within its validity window.
+func UpdateCounter(ctx context.Context, db database.Querier, credentialID uuid.UUID, counter uint64) error {
+	ctx, span := tracer.Start(ctx, "UpdateCounter")
+	defer span.End()
+
+	query := `
+    UPDATE totp_secrets SET last_counter = $1
+    WHERE credential_id = $2 AND confirmed_at IS NOT NULL AND last_counter < $1
+    `
+	span.SetAttributes(
+		attribute.String("credential_id", credentialID.String()),
+		attribute.String("query", query),
+	)
+
+	res, err := db.ExecContext(ctx, query, counter, credentialID)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
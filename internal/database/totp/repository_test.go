@@ -0,0 +1,119 @@
+//go:build testdb
+// +build testdb
+
+package totp_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/credentials"
+	"github.com/Salam4nder/identity/internal/database/totp"
+	"github.com/Salam4nder/identity/pkg/password"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func insertCredentials(t *testing.T, ctx context.Context, db database.Querier) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	require.NoError(t, credentials.Insert(ctx, db, credentials.InsertParams{
+		ID:        id,
+		Email:     random.Email(),
+		Password:  password.SafeString(random.String(10)),
+		CreatedAt: time.Now(),
+	}))
+	return id
+}
+
+func TestUpsert(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("OK", func(t *testing.T) {
+		id := insertCredentials(t, ctx, db)
+
+		require.NoError(t, totp.Upsert(ctx, db, id, []byte("secret")))
+
+		got, err := totp.Read(ctx, db, id)
+		require.NoError(t, err)
+		require.Equal(t, []byte("secret"), got.Secret)
+		require.Nil(t, got.ConfirmedAt)
+	})
+
+	t.Run("unconfirmed secret is replaced", func(t *testing.T) {
+		id := insertCredentials(t, ctx, db)
+
+		require.NoError(t, totp.Upsert(ctx, db, id, []byte("first")))
+		require.NoError(t, totp.Upsert(ctx, db, id, []byte("second")))
+
+		got, err := totp.Read(ctx, db, id)
+		require.NoError(t, err)
+		require.Equal(t, []byte("second"), got.Secret)
+	})
+
+	t.Run("confirmed secret is not replaced", func(t *testing.T) {
+		id := insertCredentials(t, ctx, db)
+
+		require.NoError(t, totp.Upsert(ctx, db, id, []byte("first")))
+		require.NoError(t, totp.Confirm(ctx, db, id, 1))
+
+		err := totp.Upsert(ctx, db, id, []byte("second"))
+		require.ErrorAs(t, err, &database.RowsAffectedError{})
+	})
+
+	t.Run("empty secret returns error", func(t *testing.T) {
+		err := totp.Upsert(ctx, db, uuid.New(), nil)
+		require.ErrorAs(t, err, &database.InputError{})
+	})
+}
+
+func TestRead(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.Tablename)
+	t.Cleanup(cleanup)
+
+	t.Run("Not found", func(t *testing.T) {
+		_, err := totp.Read(ctx, db, uuid.New())
+		require.ErrorAs(t, err, &database.NotFoundError{})
+	})
+
+	t.Run("InputError on nil UUID", func(t *testing.T) {
+		_, err := totp.Read(ctx, db, uuid.Nil)
+		require.ErrorAs(t, err, &database.InputError{})
+	})
+}
+
+func TestUpdateCounter(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.Tablename)
+	t.Cleanup(cleanup)
+
+	id := insertCredentials(t, ctx, db)
+	require.NoError(t, totp.Upsert(ctx, db, id, []byte("secret")))
+
+	t.Run("unconfirmed returns error", func(t *testing.T) {
+		err := totp.UpdateCounter(ctx, db, id, 5)
+		require.ErrorAs(t, err, &database.RowsAffectedError{})
+	})
+
+	require.NoError(t, totp.Confirm(ctx, db, id, 5))
+
+	t.Run("OK", func(t *testing.T) {
+		require.NoError(t, totp.UpdateCounter(ctx, db, id, 6))
+
+		got, err := totp.Read(ctx, db, id)
+		require.NoError(t, err)
+		require.Equal(t, uint64(6), got.LastCounter)
+	})
+
+	t.Run("replayed counter returns error", func(t *testing.T) {
+		err := totp.UpdateCounter(ctx, db, id, 6)
+		require.ErrorAs(t, err, &database.RowsAffectedError{})
+	})
+}
@@ -0,0 +1,299 @@
+package webauthn
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var tracer = otel.Tracer("webauthn")
+
+const (
+	UsersTablename       = "webauthn_users"
+	CredentialsTablename = "webauthn_credentials"
+	SessionsTablename    = "webauthn_sessions"
+)
+
+// User defines an entry in the webauthn_users table.
+type User struct {
+	ID        uuid.UUID `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Credential defines an entry in the webauthn_credentials table.
+type Credential struct {
+	ID              []byte     `db:"id"`
+	UserID          uuid.UUID  `db:"user_id"`
+	PublicKey       []byte     `db:"public_key"`
+	AttestationType string     `db:"attestation_type"`
+	AAGUID          []byte     `db:"aaguid"`
+	SignCount       uint32     `db:"sign_count"`
+	Transports      []string   `db:"transports"`
+	BackupEligible  bool       `db:"backup_eligible"`
+	BackupState     bool       `db:"backup_state"`
+	CreatedAt       time.Time  `db:"created_at"`
+	LastUsedAt      *time.Time `db:"last_used_at"`
+}
+
+// InsertUser inserts a new passkey user.
+// Returns [database.DuplicateEntryError] if the name is taken,
+// [database.RowsAffectedError] or [database.OperationFailedError].
+func InsertUser(ctx context.Context, db database.Querier, user User) error {
+	ctx, span := tracer.Start(ctx, "InsertUser")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("id", user.ID.String()),
+		attribute.String("name", user.Name),
+	)
+
+	if user.Name == "" {
+		return database.NewInputError(ctx, errors.New("webauthn: name is empty"), "name", user.Name)
+	}
+
+	query := `INSERT INTO webauthn_users (id, name, created_at) VALUES ($1, $2, $3)`
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, user.ID, user.Name, user.CreatedAt)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "webauthn_user")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// ReadUser reads a passkey [User] by ID.
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func ReadUser(ctx context.Context, db database.Querier, id uuid.UUID) (*User, error) {
+	ctx, span := tracer.Start(ctx, "ReadUser")
+	defer span.End()
+	span.SetAttributes(attribute.String("id", id.String()))
+
+	query := `SELECT id, name, created_at FROM webauthn_users WHERE id = $1`
+	span.SetAttributes(attribute.String("query", query))
+
+	var user User
+	if err := db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Name, &user.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "webauthn_user", id)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	return &user, nil
+}
+
+// ReadUserByName reads a passkey [User] by name.
+// Returns [database.NotFoundError] if entry is not found, otherwise [database.OperationFailedError].
+func ReadUserByName(ctx context.Context, db database.Querier, name string) (*User, error) {
+	ctx, span := tracer.Start(ctx, "ReadUserByName")
+	defer span.End()
+	span.SetAttributes(attribute.String("name", name))
+
+	if name == "" {
+		return nil, database.NewInputError(ctx, errors.New("webauthn: name is empty"), "name", name)
+	}
+
+	query := `SELECT id, name, created_at FROM webauthn_users WHERE name = $1`
+	span.SetAttributes(attribute.String("query", query))
+
+	var user User
+	if err := db.QueryRowContext(ctx, query, name).Scan(&user.ID, &user.Name, &user.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "webauthn_user", name)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	return &user, nil
+}
+
+// InsertCredential stores a newly registered public key credential.
+// Returns [database.DuplicateEntryError] on duplicate entry,
+// [database.RowsAffectedError] or [database.OperationFailedError].
+func InsertCredential(ctx context.Context, db database.Querier, c Credential) error {
+	ctx, span := tracer.Start(ctx, "InsertCredential")
+	defer span.End()
+	span.SetAttributes(attribute.String("user_id", c.UserID.String()))
+
+	query := `
+    INSERT INTO webauthn_credentials (
+        id, user_id, public_key, attestation_type, aaguid,
+        sign_count, transports, backup_eligible, backup_state, created_at
+    )
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(
+		ctx,
+		query,
+		c.ID,
+		c.UserID,
+		c.PublicKey,
+		c.AttestationType,
+		c.AAGUID,
+		int64(c.SignCount),
+		pq.Array(c.Transports),
+		c.BackupEligible,
+		c.BackupState,
+		c.CreatedAt,
+	)
+	if err != nil {
+		if database.IsPSQLDuplicateEntryError(err) {
+			return database.NewDuplicateEntryError(ctx, err, "webauthn_credential")
+		}
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// ListCredentials returns all credentials registered by a user.
+func ListCredentials(ctx context.Context, db *sql.DB, userID uuid.UUID) ([]Credential, error) {
+	ctx, span := tracer.Start(ctx, "ListCredentials")
+	defer span.End()
+	span.SetAttributes(attribute.String("user_id", userID.String()))
+
+	query := `
+    SELECT id, user_id, public_key, attestation_type, aaguid, sign_count,
+           transports, backup_eligible, backup_state, created_at, last_used_at
+    FROM webauthn_credentials
+    WHERE user_id = $1
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	defer rows.Close()
+
+	var res []Credential
+	for rows.Next() {
+		var (
+			c         Credential
+			signCount int64
+		)
+		if err = rows.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.PublicKey,
+			&c.AttestationType,
+			&c.AAGUID,
+			&signCount,
+			pq.Array(&c.Transports),
+			&c.BackupEligible,
+			&c.BackupState,
+			&c.CreatedAt,
+			&c.LastUsedAt,
+		); err != nil {
+			return nil, database.NewOperationFailedError(ctx, err)
+		}
+		c.SignCount = uint32(signCount)
+		res = append(res, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+
+	return res, nil
+}
+
+// UpdateCredentialUsage stores the sign counter and backup state reported by the
+// authenticator on a successful login.
+func UpdateCredentialUsage(ctx context.Context, db database.Querier, id []byte, signCount uint32, backupState bool) error {
+	ctx, span := tracer.Start(ctx, "UpdateCredentialUsage")
+	defer span.End()
+
+	query := `
+    UPDATE webauthn_credentials
+    SET sign_count = $1, backup_state = $2, last_used_at = $3
+    WHERE id = $4
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, int64(signCount), backupState, time.Now(), id)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// InsertSession stores the state of a started ceremony until it is finished.
+func InsertSession(ctx context.Context, db database.Querier, id uuid.UUID, data []byte, expiresAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "InsertSession")
+	defer span.End()
+	span.SetAttributes(attribute.String("id", id.String()))
+
+	query := `INSERT INTO webauthn_sessions (id, data, expires_at) VALUES ($1, $2, $3)`
+	span.SetAttributes(attribute.String("query", query))
+
+	res, err := db.ExecContext(ctx, query, id, data, expiresAt)
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return database.NewOperationFailedError(ctx, err)
+	}
+	if rowsAffected != 1 {
+		return database.NewRowsAffectedError(ctx, database.ErrUnexpectedRowsAffectedError, 1, rowsAffected)
+	}
+
+	return nil
+}
+
+// TakeSession deletes a session and returns its data, so that every ceremony
+// can only be finished once. Expired sessions are reported as [database.NotFoundError].
+func TakeSession(ctx context.Context, db database.Querier, id uuid.UUID) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "TakeSession")
+	defer span.End()
+	span.SetAttributes(attribute.String("id", id.String()))
+
+	query := `
+    DELETE FROM webauthn_sessions
+    WHERE id = $1 AND expires_at > $2
+    RETURNING data
+    `
+	span.SetAttributes(attribute.String("query", query))
+
+	var data []byte
+	if err := db.QueryRowContext(ctx, query, id, time.Now()).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, database.NewNotFoundError(ctx, err, "webauthn_session", id)
+		}
+		return nil, database.NewOperationFailedError(ctx, err)
+	}
+	return data, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/gob"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"go.opentelemetry.io/otel"
@@ -22,6 +23,15 @@ const (
 	TestSubject = "You have requested to create an identity."
 	TestBody    = "Do the following step to verify your identity."
 	TestFrom    = "fugaziindustries@proton.me"
+
+	MagicLinkSubject     = "Your sign-in link."
+	PasswordResetSubject = "You have requested to reset your password."
+	EmailChangeSubject   = "You have requested to change your email."
+	LockoutSubject       = "Your account has been locked."
+
+	AccountExistsSubject = "Someone tried to create an identity with your email."
+	AccountExistsBody    = "You already have an identity with this email. If this was you, sign in instead " +
+		"or request a password reset if you forgot your password. Otherwise you can ignore this email."
 )
 
 // Verification builds the email body for email verifications.
@@ -29,6 +39,35 @@ func Verification(origin, token string) string {
 	return fmt.Sprintf("Please click the following link to verify your email: %s/%s", origin, token)
 }
 
+// MagicLink builds the email body for passwordless sign-in links.
+func MagicLink(origin, token string, ttl time.Duration) string {
+	return fmt.Sprintf(
+		"Please click the following link to sign in, it expires in %s and can only be used once: %s/%s",
+		ttl,
+		origin,
+		token,
+	)
+}
+
+// PasswordReset builds the email body for password resets.
+func PasswordReset(origin, token string, ttl time.Duration) string {
+	return fmt.Sprintf(
+		"Please click the following link to reset your password, it expires in %s and can only be used once: %s/%s",
+		ttl,
+		origin,
+		token,
+	)
+}
+
+// Lockout builds the email body for accounts locked after too many failed sign-ins.
+func Lockout(until time.Time) string {
+	return fmt.Sprintf(
+		"There were too many failed attempts to sign in to your account, it is locked until %s. "+
+			"If this was not you, consider resetting your password.",
+		until.UTC().Format(time.RFC1123),
+	)
+}
+
 type Email struct {
 	To      string
 	Subject string
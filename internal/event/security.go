@@ -0,0 +1,55 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("event")
+
+const (
+	SecurityEvent = "security_event"
+
+	// SecurityRefreshTokenReused is emitted when an already used refresh token
+	// is presented again, which means it was likely stolen.
+	SecurityRefreshTokenReused = "refresh_token_reused"
+)
+
+// Security describes something that happened to an identity that
+// should be audited or alerted on.
+type Security struct {
+	Kind       string
+	Subject    string
+	Detail     string
+	OccurredAt time.Time
+}
+
+func (x Security) TraceAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("kind", x.Kind),
+		attribute.String("subject", x.Subject),
+		attribute.String("detail", x.Detail),
+	}
+}
+
+// EmitSecurity logs a security event and publishes it for other services to consume.
+func EmitSecurity(ctx context.Context, natsConn *nats.Conn, e Security) error {
+	ctx, span := tracer.Start(ctx, "EmitSecurity", trace.WithAttributes(e.TraceAttributes()...))
+	defer span.End()
+
+	slog.WarnContext(ctx, "event: security event", "kind", e.Kind, "subject", e.Subject, "detail", e.Detail)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(e); err != nil {
+		return err
+	}
+	return natsConn.Publish(SecurityEvent, buf.Bytes())
+}
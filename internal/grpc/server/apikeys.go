@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth/apikey"
+	apikeydb "github.com/Salam4nder/identity/internal/database/apikey"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateAPIKey creates a long-lived key for the owner of the given access token, with at most its scopes.
+// The key is only returned here, the service keeps a hash of it.
+func (x *Identity) CreateAPIKey(ctx context.Context, req *gen.CreateAPIKeyRequest) (*gen.CreateAPIKeyResponse, error) {
+	ctx, span := tracer.Start(ctx, "CreateAPIKey")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	owner, c, err := x.apiKeyOwner(ctx, req.GetToken())
+	if err != nil {
+		return nil, err
+	}
+
+	var ttl time.Duration
+	if req.GetLifetime() != nil {
+		ttl = req.GetLifetime().AsDuration()
+	}
+	key, k, err := x.keys.Create(ctx, owner, req.GetName(), req.GetScopes(), c.Scopes, ttl)
+	if err != nil {
+		if errors.Is(err, apikey.ErrInvalidInput) {
+			return nil, invalidArgumentError(ctx, err, err.Error())
+		}
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &gen.CreateAPIKeyResponse{Key: key, ApiKey: apiKeyToProto(k)}, nil
+}
+
+// ListAPIKeys lists the keys of the owner of the given access token, without the keys themselves.
+func (x *Identity) ListAPIKeys(ctx context.Context, req *gen.TokenRequest) (*gen.ListAPIKeysResponse, error) {
+	ctx, span := tracer.Start(ctx, "ListAPIKeys")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	owner, _, err := x.apiKeyOwner(ctx, req.GetToken())
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := x.keys.List(ctx, owner)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+
+	resp := &gen.ListAPIKeysResponse{ApiKeys: make([]*gen.APIKey, 0, len(keys))}
+	for i := range keys {
+		resp.ApiKeys = append(resp.ApiKeys, apiKeyToProto(&keys[i]))
+	}
+	return resp, nil
+}
+
+// RevokeAPIKey deletes a key of the owner of the given access token.
+func (x *Identity) RevokeAPIKey(ctx context.Context, req *gen.RevokeAPIKeyRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "RevokeAPIKey")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	owner, _, err := x.apiKeyOwner(ctx, req.GetToken())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = x.keys.Revoke(ctx, owner, req.GetId()); err != nil {
+		if errors.Is(err, apikey.ErrNotFound) {
+			return nil, notFoundError(ctx, err, "api key not found")
+		}
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// apiKeyOwner parses the access token of a user managing their keys.
+// Only credentials and personal number users have keys, and only tokens issued to them without
+// an audience or actor manage them, not tokens a downstream service received on their behalf.
+func (x *Identity) apiKeyOwner(ctx context.Context, t string) (apikey.Owner, token.Claims, error) {
+	parsed, err := x.parseToken(ctx, t)
+	if err != nil {
+		return apikey.Owner{}, token.Claims{}, tokenError(ctx, err)
+	}
+	c, err := token.ClaimsFromToken(parsed)
+	if err != nil {
+		return apikey.Owner{}, token.Claims{}, unauthenticatedError(ctx, err, "incorrect token")
+	}
+	if c.TokenType != token.PasetoTokenTypeAccess {
+		return apikey.Owner{}, token.Claims{}, unauthenticatedError(
+			ctx,
+			fmt.Errorf("rpc: token type is %s, expecting %s", c.TokenType, token.PasetoTokenTypeAccess),
+			"incorrect token",
+		)
+	}
+	if c.Strategy != gen.Strategy_TypeCredentials && c.Strategy != gen.Strategy_TypePersonalNumber {
+		return apikey.Owner{}, token.Claims{}, permissionDeniedError(
+			ctx,
+			fmt.Errorf("rpc: token strategy is %s", c.Strategy),
+			"api keys are only available to credentials and personal number users",
+		)
+	}
+	// Tokens issued for another service, such as by ExchangeToken, must not outlive it as keys.
+	if err = requireFirstParty(parsed, c); err != nil {
+		return apikey.Owner{}, token.Claims{}, tokenError(ctx, err)
+	}
+	return apikey.Owner{Strategy: c.Strategy, Identifier: c.Subject}, c, nil
+}
+
+// validateAPIKey is [Identity.Validate] for API keys, which carry scopes but no audience.
+func (x *Identity) validateAPIKey(ctx context.Context, req *gen.ValidateRequest) (*emptypb.Empty, error) {
+	k, err := x.keys.Verify(ctx, req.GetToken())
+	if err != nil {
+		if errors.Is(err, apikey.ErrInvalidKey) {
+			return nil, unauthenticatedError(ctx, err, "incorrect token")
+		}
+		return nil, internalServerError(ctx, err)
+	}
+
+	if req.GetAudience() != "" {
+		return nil, unauthenticatedError(
+			ctx,
+			fmt.Errorf("rpc: api keys have no audience, expecting %q", req.GetAudience()),
+			"incorrect audience",
+		)
+	}
+	c := token.Claims{Scopes: k.Scopes}
+	if !c.HasScopes(req.GetScopes()...) {
+		return nil, permissionDeniedError(
+			ctx,
+			fmt.Errorf("rpc: api key scopes are %v, expecting %v", k.Scopes, req.GetScopes()),
+			"insufficient scope",
+		)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// introspectAPIKey is [Identity.Introspect] for API keys.
+func (x *Identity) introspectAPIKey(ctx context.Context, key string) (*gen.IntrospectResponse, error) {
+	k, err := x.keys.Verify(ctx, key)
+	if err != nil {
+		if errors.Is(err, apikey.ErrInvalidKey) {
+			slog.InfoContext(ctx, "rpc: introspected inactive api key", "err", err)
+			return &gen.IntrospectResponse{Active: false}, nil
+		}
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &gen.IntrospectResponse{
+		Active:    true,
+		Subject:   k.Identifier,
+		Strategy:  gen.Strategy(k.Strategy),
+		TokenType: apikey.TokenType,
+		IssuedAt:  timestamppb.New(k.CreatedAt),
+		ExpiresAt: timestamppb.New(k.ExpiresAt),
+		Scopes:    k.Scopes,
+		TokenId:   k.ID.String(),
+	}, nil
+}
+
+func apiKeyToProto(k *apikeydb.Key) *gen.APIKey {
+	p := &gen.APIKey{
+		Id:        k.ID.String(),
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: timestamppb.New(k.CreatedAt),
+		ExpiresAt: timestamppb.New(k.ExpiresAt),
+	}
+	if k.LastUsedAt != nil {
+		p.LastUsedAt = timestamppb.New(*k.LastUsedAt)
+	}
+	return p
+}
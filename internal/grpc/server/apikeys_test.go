@@ -0,0 +1,49 @@
+//go:build testdb
+// +build testdb
+
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/apikey"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAPIKeyOwner(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(apikey.Tablename)
+	t.Cleanup(cleanup)
+
+	srv, tokens, _ := newIdentity(t, db)
+
+	accessToken, err := tokens.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, token.Grant{})
+	require.NoError(t, err)
+
+	t.Run("first-party token", func(t *testing.T) {
+		_, err := srv.ListAPIKeys(ctx, &gen.TokenRequest{Token: string(accessToken)})
+		require.NoError(t, err)
+	})
+
+	t.Run("exchanged token", func(t *testing.T) {
+		exchanged, err := srv.ExchangeToken(ctx, &gen.ExchangeTokenRequest{
+			SubjectToken: string(accessToken),
+			ClientId:     exchangeClient,
+			ClientSecret: exchangeSecret,
+			Audience:     exchangeAudience,
+		})
+		require.NoError(t, err)
+
+		_, err = srv.CreateAPIKey(ctx, &gen.CreateAPIKeyRequest{Token: exchanged.GetAccessToken(), Name: "ci"})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = srv.ListAPIKeys(ctx, &gen.TokenRequest{Token: exchanged.GetAccessToken()})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/strategy/clientcredentials"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CreateClient registers a machine identity of the client credentials strategy
+// and returns its secret, which is not returned again. Requires the admin scope of clients.
+func (x *Identity) CreateClient(ctx context.Context, req *gen.CreateClientRequest) (*gen.ClientSecretResponse, error) {
+	ctx, span := tracer.Start(ctx, "CreateClient")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+	if err := x.requireAdmin(ctx, req.GetToken()); err != nil {
+		return nil, err
+	}
+
+	s, err := x.clientCredentialsStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	var ttl time.Duration
+	if req.GetTokenLifetime() != nil {
+		ttl = req.GetTokenLifetime().AsDuration()
+	}
+	c, secret, err := s.Create(ctx, req.GetName(), req.GetScopes(), ttl)
+	if err != nil {
+		if errors.Is(err, clientcredentials.ErrInvalidInput) {
+			return nil, invalidArgumentError(ctx, err, err.Error())
+		}
+		return nil, internalServerError(ctx, err)
+	}
+	span.SetAttributes(attribute.String("client_id", c.ID.String()))
+
+	return &gen.ClientSecretResponse{ClientId: c.ID.String(), ClientSecret: secret}, nil
+}
+
+// RotateClientSecret replaces the secret of a client, the previous one stops working immediately.
+// Requires the admin scope of clients.
+func (x *Identity) RotateClientSecret(
+	ctx context.Context,
+	req *gen.RotateClientSecretRequest,
+) (*gen.ClientSecretResponse, error) {
+	ctx, span := tracer.Start(ctx, "RotateClientSecret")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+	span.SetAttributes(attribute.String("client_id", req.GetClientId()))
+	if err := x.requireAdmin(ctx, req.GetToken()); err != nil {
+		return nil, err
+	}
+
+	s, err := x.clientCredentialsStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	secret, err := s.Rotate(ctx, req.GetClientId())
+	if err != nil {
+		if errors.Is(err, clientcredentials.ErrClientNotFound) {
+			return nil, notFoundError(ctx, err, "client not found")
+		}
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &gen.ClientSecretResponse{ClientId: req.GetClientId(), ClientSecret: secret}, nil
+}
+
+// requireAdmin checks that an access token issued to a user themselves carries the admin scope of clients.
+func (x *Identity) requireAdmin(ctx context.Context, t string) error {
+	parsed, err := x.parseToken(ctx, t)
+	if err != nil {
+		return tokenError(ctx, err)
+	}
+	c, err := token.ClaimsFromToken(parsed)
+	if err != nil {
+		return unauthenticatedError(ctx, err, "incorrect token")
+	}
+	if c.TokenType != token.PasetoTokenTypeAccess {
+		return unauthenticatedError(
+			ctx,
+			fmt.Errorf("rpc: token type is %s, expecting %s", c.TokenType, token.PasetoTokenTypeAccess),
+			"incorrect token",
+		)
+	}
+	if err = requireFirstParty(parsed, c); err != nil {
+		return tokenError(ctx, err)
+	}
+	if x.cfg.Clients.AdminScope == "" || !c.HasScopes(x.cfg.Clients.AdminScope) {
+		return permissionDeniedError(
+			ctx,
+			fmt.Errorf("rpc: token scopes are %v, expecting %s", c.Scopes, x.cfg.Clients.AdminScope),
+			"insufficient scope",
+		)
+	}
+	return nil
+}
+
+func (x *Identity) clientCredentialsStrategy() (*clientcredentials.Strategy, error) {
+	s, ok := x.strategies[gen.Strategy_TypeClientCredentials]
+	if !ok {
+		return nil, fmt.Errorf("rpc: client_credentials %w", errNotMounted)
+	}
+	c, ok := auth.As[*clientcredentials.Strategy](s)
+	if !ok {
+		return nil, errors.New("rpc: strategy is not client_credentials")
+	}
+	return c, nil
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/auth/strategy/credentials"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// ResendVerification emails a new verification token to an unverified credentials user,
+// replacing the outstanding one. It always succeeds, so that callers can not learn which emails are registered.
+func (x *Identity) ResendVerification(ctx context.Context, req *gen.ResendVerificationRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "ResendVerification")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	s, err := x.credentialsStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	if err = s.ResendVerification(ctx, req.GetEmail()); err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ChangeEmail starts an email change for the owner of the given access token after checking their password.
+// The current email stays active until the new one is confirmed with ConfirmEmailChange.
+func (x *Identity) ChangeEmail(ctx context.Context, req *gen.ChangeEmailRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "ChangeEmail")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	email, err := x.credentialsIdentifier(ctx, req.GetToken())
+	if err != nil {
+		return nil, tokenError(ctx, err)
+	}
+
+	s, err := x.credentialsStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	if err = s.ChangeEmail(ctx, email, req.GetPassword(), req.GetNewEmail()); err != nil {
+		var locked *lockout.LockedError
+		switch {
+		case errors.Is(err, credentials.ErrUserNotFound):
+			return nil, unauthenticatedError(ctx, err, "incorrect token")
+		case errors.As(err, &locked):
+			return nil, lockedError(ctx, err, locked)
+		case errors.Is(err, credentials.ErrIncorrectPassword):
+			return nil, fieldError(ctx, err, "incorrect password", "password", "does not match the current password")
+		case errors.Is(err, credentials.ErrInvalidEmail):
+			return nil, fieldError(ctx, err, "invalid email", "new_email", "must be a valid email address")
+		case errors.Is(err, credentials.ErrEmailTaken):
+			return nil, alreadyExistsError(ctx, err, "email is already in use")
+		default:
+			return nil, internalServerError(ctx, err)
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ConfirmEmailChange completes an email change with the token sent to the new address.
+func (x *Identity) ConfirmEmailChange(ctx context.Context, req *gen.TokenRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "ConfirmEmailChange")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	s, err := x.credentialsStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	if err = s.ConfirmEmailChange(ctx, req.GetToken()); err != nil {
+		switch {
+		case errors.Is(err, credentials.ErrTokenDoesNotExist):
+			return nil, unauthenticatedError(ctx, err, "incorrect token")
+		case errors.Is(err, credentials.ErrEmailTaken):
+			return nil, alreadyExistsError(ctx, err, "email is already in use")
+		default:
+			return nil, internalServerError(ctx, err)
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
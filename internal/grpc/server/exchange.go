@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Salam4nder/identity/internal/auth/exchange"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ExchangeToken swaps the access token of a user for one issued to a downstream audience
+// with at most the same scopes, on behalf of an authenticated client. See RFC 8693.
+func (x *Identity) ExchangeToken(ctx context.Context, req *gen.ExchangeTokenRequest) (*gen.ExchangeTokenResponse, error) {
+	ctx, span := tracer.Start(ctx, "ExchangeToken")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	t, err := x.parseToken(ctx, req.GetSubjectToken())
+	if err != nil {
+		return nil, tokenError(ctx, err)
+	}
+	c, err := token.ClaimsFromToken(t)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+	if c.TokenType != token.PasetoTokenTypeAccess {
+		return nil, invalidArgumentError(
+			ctx,
+			fmt.Errorf("rpc: token type is %s, expecting %s", c.TokenType, token.PasetoTokenTypeAccess),
+			"incorrect token",
+		)
+	}
+	actor, _ := t.Claims()[exchange.ActorKey].(map[string]any)
+
+	d, err := x.exchanges.Decide(
+		req.GetClientId(),
+		req.GetClientSecret(),
+		req.GetAudience(),
+		req.GetScopes(),
+		c.Scopes,
+		actor,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, exchange.ErrUnknownClient):
+			return nil, unauthenticatedError(ctx, err, "incorrect client")
+		case errors.Is(err, exchange.ErrAudienceNotAllowed), errors.Is(err, exchange.ErrScopeNotAllowed):
+			return nil, permissionDeniedError(ctx, err, err.Error())
+		default:
+			return nil, internalServerError(ctx, err)
+		}
+	}
+
+	identifier, err := token.IdentifierFromToken(t)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+	g := token.Grant{Scopes: d.Scopes, Audience: d.Audience}
+	if d.Actor != nil {
+		g.Extra = map[string]any{exchange.ActorKey: d.Actor}
+	}
+	accessToken, err := x.tokenMaker.MakeAccessToken(identifier, c.Strategy, g)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &gen.ExchangeTokenResponse{
+		AccessToken:     string(accessToken),
+		IssuedTokenType: exchange.TokenTypeAccessToken,
+		ExpiresAt:       timestamppb.New(x.tokenMaker.AccessTokenExpiration()),
+		Scopes:          d.Scopes,
+	}, nil
+}
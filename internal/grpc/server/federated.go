@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/strategy/federated"
+	"github.com/Salam4nder/identity/proto/gen"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// BeginFederatedLogin starts a sign-in at an upstream provider. The provider redirects the user back
+// with the state and a code, which are passed to [Identity.Authenticate] with the federated strategy.
+func (x *Identity) BeginFederatedLogin(
+	ctx context.Context,
+	req *gen.FederatedBeginRequest,
+) (*gen.FederatedBeginResponse, error) {
+	ctx, span := tracer.Start(ctx, "BeginFederatedLogin")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+	span.SetAttributes(attribute.String("provider", req.GetProvider()))
+
+	s, err := x.federatedStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	authURL, state, err := s.Begin(ctx, req.GetProvider())
+	if err != nil {
+		if errors.Is(err, federated.ErrUnknownProvider) {
+			return nil, invalidArgumentError(ctx, err, "unknown provider")
+		}
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &gen.FederatedBeginResponse{AuthorizationUrl: authURL, State: state}, nil
+}
+
+func (x *Identity) federatedStrategy() (*federated.Strategy, error) {
+	s, ok := x.strategies[gen.Strategy_TypeFederated]
+	if !ok {
+		return nil, fmt.Errorf("rpc: federated %w", errNotMounted)
+	}
+	f, ok := auth.As[*federated.Strategy](s)
+	if !ok {
+		return nil, errors.New("rpc: strategy is not federated")
+	}
+	return f, nil
+}
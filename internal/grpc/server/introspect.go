@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Salam4nder/identity/internal/auth/apikey"
+	"github.com/Salam4nder/identity/internal/auth/revocation"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Introspect decodes the claims of a token or API key. Like RFC 7662, a token that is invalid, expired
+// or revoked is not an error but reported as inactive, without any claims.
+func (x *Identity) Introspect(ctx context.Context, req *gen.TokenRequest) (*gen.IntrospectResponse, error) {
+	ctx, span := tracer.Start(ctx, "Introspect")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+	if apikey.IsKey(req.GetToken()) {
+		return x.introspectAPIKey(ctx, req.GetToken())
+	}
+
+	t, err := x.parseToken(ctx, req.GetToken())
+	if err != nil {
+		if errors.Is(err, errIncorrectToken) || errors.Is(err, revocation.ErrRevoked) {
+			slog.InfoContext(ctx, "rpc: introspected inactive token", "err", err)
+			return &gen.IntrospectResponse{Active: false}, nil
+		}
+		return nil, internalServerError(ctx, err)
+	}
+
+	c, err := token.ClaimsFromToken(t)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &gen.IntrospectResponse{
+		Active:    true,
+		Subject:   c.Subject,
+		Strategy:  c.Strategy,
+		TokenType: c.TokenType,
+		IssuedAt:  timestamppb.New(c.IssuedAt),
+		ExpiresAt: timestamppb.New(c.ExpiresAt),
+		Scopes:    c.Scopes,
+		Audience:  c.Audience,
+		TokenId:   c.ID.String(),
+	}, nil
+}
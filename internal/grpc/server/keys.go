@@ -0,0 +1,23 @@
+package server
+
+import (
+	"context"
+
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// PublicKeys returns the PASERK encoded keys that verify v4.public tokens.
+// It is empty when tokens are v4.local.
+func (x *Identity) PublicKeys(ctx context.Context, _ *emptypb.Empty) (*gen.PublicKeysResponse, error) {
+	_, span := tracer.Start(ctx, "PublicKeys")
+	defer span.End()
+
+	keys := x.tokenMaker.PublicKeys()
+	resp := &gen.PublicKeysResponse{Keys: make([]*gen.PublicKey, 0, len(keys))}
+	for _, k := range keys {
+		resp.Keys = append(resp.Keys, &gen.PublicKey{Id: k.ID, Paserk: k.PASERK})
+	}
+
+	return resp, nil
+}
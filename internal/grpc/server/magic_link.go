@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/strategy/magiclink"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// RequestMagicLink emails a one-time sign-in link to a passwordless user.
+// It always succeeds, so that callers can not learn which emails are registered.
+func (x *Identity) RequestMagicLink(ctx context.Context, req *gen.MagicLink) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "RequestMagicLink")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	s, err := x.magicLinkStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	if err = s.RequestLink(ctx, req.GetEmail()); err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// RedeemMagicLink exchanges the token of a sign-in link for access and refresh tokens.
+// It is equivalent to [Identity.Authenticate] with the magic_link strategy.
+func (x *Identity) RedeemMagicLink(ctx context.Context, req *gen.TokenRequest) (*gen.AuthenticateResponse, error) {
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+	return x.Authenticate(ctx, &gen.AuthenticateRequest{
+		Strategy: gen.Strategy_TypeMagicLink,
+		Data:     &gen.AuthenticateRequest_MagicLink{MagicLink: req},
+	})
+}
+
+func (x *Identity) magicLinkStrategy() (*magiclink.Strategy, error) {
+	s, ok := x.strategies[gen.Strategy_TypeMagicLink]
+	if !ok {
+		return nil, fmt.Errorf("rpc: magic link %w", errNotMounted)
+	}
+	m, ok := auth.As[*magiclink.Strategy](s)
+	if !ok {
+		return nil, errors.New("rpc: strategy is not magic link")
+	}
+	return m, nil
+}
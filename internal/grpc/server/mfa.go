@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/Salam4nder/identity/internal/auth/grant"
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/auth/mfa"
+	"github.com/Salam4nder/identity/internal/database/loginattempt"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	// maxTOTPAttempts is how many invalid codes revoke an MFA token, signing in again is required then.
+	maxTOTPAttempts = 5
+	// totpAttemptScope counts invalid codes per MFA token ID among the login attempts.
+	totpAttemptScope = "mfa_token"
+)
+
+// EnrollTOTP starts a TOTP enrollment for the owner of the given access token.
+func (x *Identity) EnrollTOTP(ctx context.Context, req *gen.TokenRequest) (*gen.EnrollTOTPResponse, error) {
+	ctx, span := tracer.Start(ctx, "EnrollTOTP")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	email, err := x.credentialsIdentifier(ctx, req.GetToken())
+	if err != nil {
+		return nil, tokenError(ctx, err)
+	}
+
+	secret, uri, err := x.totp.Enroll(ctx, email)
+	if err != nil {
+		if errors.Is(err, mfa.ErrAlreadyEnrolled) {
+			return nil, failedPreconditionError(ctx, err, "totp is already enrolled")
+		}
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &gen.EnrollTOTPResponse{Secret: secret, Uri: uri}, nil
+}
+
+// ConfirmTOTP completes a TOTP enrollment with a code from the user's authenticator.
+func (x *Identity) ConfirmTOTP(ctx context.Context, req *gen.TOTPRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "ConfirmTOTP")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	email, err := x.credentialsIdentifier(ctx, req.GetToken())
+	if err != nil {
+		return nil, tokenError(ctx, err)
+	}
+
+	if err = x.totp.Confirm(ctx, email, req.GetCode()); err != nil {
+		switch {
+		case errors.Is(err, mfa.ErrInvalidCode):
+			return nil, invalidArgumentError(ctx, err, "incorrect code")
+		case errors.Is(err, mfa.ErrNotEnrolled), errors.Is(err, mfa.ErrAlreadyEnrolled):
+			return nil, failedPreconditionError(ctx, err, "no pending totp enrollment")
+		default:
+			return nil, internalServerError(ctx, err)
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// VerifyTOTP exchanges an MFA token from [Identity.Authenticate] and a valid code for access and refresh tokens.
+// The MFA token can only be exchanged once and is revoked after [maxTOTPAttempts] invalid codes.
+func (x *Identity) VerifyTOTP(ctx context.Context, req *gen.TOTPRequest) (*gen.AuthenticateResponse, error) {
+	ctx, span := tracer.Start(ctx, "VerifyTOTP")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	t, c, err := x.credentialsClaims(ctx, req.GetToken(), token.PasetoTokenTypeMFAPending)
+	if err != nil {
+		return nil, tokenError(ctx, err)
+	}
+	email := c.Subject
+
+	if err = x.totp.Verify(ctx, email, req.GetCode()); err != nil {
+		var locked *lockout.LockedError
+		switch {
+		case errors.As(err, &locked):
+			return nil, lockedError(ctx, err, locked)
+		case errors.Is(err, mfa.ErrInvalidCode), errors.Is(err, mfa.ErrNotEnrolled):
+			if err := x.failTOTP(ctx, t, c); err != nil {
+				return nil, internalServerError(ctx, err)
+			}
+			return nil, unauthenticatedError(ctx, err, "incorrect code")
+		default:
+			return nil, internalServerError(ctx, err)
+		}
+	}
+
+	// Spend the MFA token before issuing tokens, so that it can not be replayed.
+	if err = x.revokeToken(ctx, t); err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+	if err = loginattempt.Delete(ctx, x.db, totpAttemptScope, c.ID.String()); err != nil {
+		slog.ErrorContext(ctx, "rpc: forgetting invalid codes", "err", err)
+	}
+
+	g, err := x.grantFor(ctx, grant.Request{
+		Identifier: email,
+		Strategy:   gen.Strategy_TypeCredentials,
+		Scopes:     c.Scopes,
+		Audience:   c.Audience,
+	})
+	if err != nil {
+		return nil, grantError(ctx, err)
+	}
+
+	resp, err := x.issueTokens(ctx, email, gen.Strategy_TypeCredentials, g)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+	return resp, nil
+}
+
+// failTOTP counts an invalid code for the MFA token and revokes it once it reaches [maxTOTPAttempts].
+func (x *Identity) failTOTP(ctx context.Context, t *paseto.Token, c token.Claims) error {
+	now := time.Now()
+	failures, err := loginattempt.Fail(ctx, x.db, totpAttemptScope, c.ID.String(), now, now.Add(-token.MFATokenDuration))
+	if err != nil {
+		return fmt.Errorf("rpc: recording invalid code, %w", err)
+	}
+	if failures < maxTOTPAttempts {
+		return nil
+	}
+	if err = x.revokeToken(ctx, t); err != nil {
+		return fmt.Errorf("rpc: revoking mfa token, %w", err)
+	}
+	return loginattempt.Delete(ctx, x.db, totpAttemptScope, c.ID.String())
+}
+
+// credentialsIdentifier parses an access token issued to a credentials user themselves, see [requireFirstParty],
+// and returns their email. Errors are mapped to a response with [tokenError].
+func (x *Identity) credentialsIdentifier(ctx context.Context, t string) (string, error) {
+	parsed, c, err := x.credentialsClaims(ctx, t, token.PasetoTokenTypeAccess)
+	if err != nil {
+		return "", err
+	}
+	if err = requireFirstParty(parsed, c); err != nil {
+		return "", err
+	}
+	return c.Subject, nil
+}
+
+// credentialsClaims parses a token of the expected type issued to a credentials user and returns it with its claims,
+// the subject being their email. Errors are mapped to a response with [tokenError].
+func (x *Identity) credentialsClaims(ctx context.Context, t, expectedType string) (*paseto.Token, token.Claims, error) {
+	parsed, err := x.parseToken(ctx, t)
+	if err != nil {
+		return nil, token.Claims{}, err
+	}
+
+	c, err := token.ClaimsFromToken(parsed)
+	if err != nil {
+		return nil, token.Claims{}, fmt.Errorf("%w, %w", errIncorrectToken, err)
+	}
+	if c.TokenType != expectedType {
+		return nil, token.Claims{}, fmt.Errorf("%w, token type is %s, expecting %s", errIncorrectToken, c.TokenType, expectedType)
+	}
+	if c.Strategy != gen.Strategy_TypeCredentials {
+		return nil, token.Claims{}, fmt.Errorf(
+			"%w, token strategy is %s, expecting %s",
+			errIncorrectToken,
+			c.Strategy,
+			gen.Strategy_TypeCredentials,
+		)
+	}
+	return parsed, c, nil
+}
@@ -0,0 +1,80 @@
+//go:build testdb
+// +build testdb
+
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/credentials"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/pkg/totp"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestVerifyTOTP(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.Tablename)
+	t.Cleanup(cleanup)
+
+	srv, tokens, mfa := newIdentity(t, db)
+
+	// enroll returns a user with TOTP enabled, whose codes of the current time window are not used yet.
+	enroll := func(t *testing.T) (email, secret string) {
+		t.Helper()
+		email = insertUser(t, ctx, db)
+		secret, _, err := mfa.Enroll(ctx, email)
+		require.NoError(t, err)
+		code, err := totp.Code(secret, time.Now().Add(-30*time.Second))
+		require.NoError(t, err)
+		require.NoError(t, mfa.Confirm(ctx, email, code))
+		return email, secret
+	}
+	mfaToken := func(t *testing.T, email string) string {
+		t.Helper()
+		s, err := tokens.MakeMFAToken(email, gen.Strategy_TypeCredentials, token.Grant{})
+		require.NoError(t, err)
+		return string(s)
+	}
+
+	t.Run("MFA token can only be exchanged once", func(t *testing.T) {
+		email, secret := enroll(t)
+		pending := mfaToken(t, email)
+
+		code, err := totp.Code(secret, time.Now())
+		require.NoError(t, err)
+		resp, err := srv.VerifyTOTP(ctx, &gen.TOTPRequest{Token: pending, Code: code})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.GetAccessToken())
+
+		// The code of the next time window is valid, the token is not.
+		code, err = totp.Code(secret, time.Now().Add(30*time.Second))
+		require.NoError(t, err)
+		_, err = srv.VerifyTOTP(ctx, &gen.TOTPRequest{Token: pending, Code: code})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Equal(t, "incorrect token", status.Convert(err).Message())
+	})
+
+	t.Run("MFA token is revoked after too many invalid codes", func(t *testing.T) {
+		email, secret := enroll(t)
+		pending := mfaToken(t, email)
+
+		for range 5 {
+			_, err := srv.VerifyTOTP(ctx, &gen.TOTPRequest{Token: pending, Code: "000000"})
+			require.Equal(t, codes.Unauthenticated, status.Code(err))
+			require.Equal(t, "incorrect code", status.Convert(err).Message())
+		}
+
+		code, err := totp.Code(secret, time.Now())
+		require.NoError(t, err)
+		_, err = srv.VerifyTOTP(ctx, &gen.TOTPRequest{Token: pending, Code: code})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Equal(t, "incorrect token", status.Convert(err).Message())
+	})
+}
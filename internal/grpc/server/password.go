@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/auth/strategy/credentials"
+	"github.com/Salam4nder/identity/pkg/password"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// RequestPasswordReset emails a password reset link to a credentials user.
+// It always succeeds, so that callers can not learn which emails are registered.
+func (x *Identity) RequestPasswordReset(ctx context.Context, req *gen.PasswordResetRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "RequestPasswordReset")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	s, err := x.credentialsStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	if err = s.RequestPasswordReset(ctx, req.GetEmail()); err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ResetPassword sets a new password with the token of a password reset link.
+// Refresh tokens issued before the reset can no longer be used.
+func (x *Identity) ResetPassword(ctx context.Context, req *gen.ResetPasswordRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "ResetPassword")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	s, err := x.credentialsStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	if err = s.ResetPassword(ctx, req.GetToken(), req.GetPassword()); err != nil {
+		switch {
+		case errors.Is(err, credentials.ErrTokenDoesNotExist):
+			return nil, unauthenticatedError(ctx, err, "incorrect token")
+		case errors.Is(err, credentials.ErrInvalidPassword):
+			return nil, passwordError(ctx, err, "password")
+		default:
+			return nil, internalServerError(ctx, err)
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ChangePassword sets a new password for the owner of the given access token after checking their current one.
+// Refresh tokens issued before the change can no longer be used.
+func (x *Identity) ChangePassword(ctx context.Context, req *gen.ChangePasswordRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "ChangePassword")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	email, err := x.credentialsIdentifier(ctx, req.GetToken())
+	if err != nil {
+		return nil, tokenError(ctx, err)
+	}
+
+	s, err := x.credentialsStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	if err = s.ChangePassword(ctx, email, req.GetPassword(), req.GetNewPassword()); err != nil {
+		var locked *lockout.LockedError
+		switch {
+		case errors.Is(err, credentials.ErrUserNotFound):
+			return nil, unauthenticatedError(ctx, err, "incorrect token")
+		case errors.As(err, &locked):
+			return nil, lockedError(ctx, err, locked)
+		case errors.Is(err, credentials.ErrIncorrectPassword):
+			return nil, fieldError(ctx, err, "incorrect password", "password", "does not match the current password")
+		case errors.Is(err, credentials.ErrInvalidPassword):
+			return nil, passwordError(ctx, err, "new_password")
+		default:
+			return nil, internalServerError(ctx, err)
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (x *Identity) credentialsStrategy() (*credentials.Strategy, error) {
+	s, ok := x.strategies[gen.Strategy_TypeCredentials]
+	if !ok {
+		return nil, fmt.Errorf("rpc: credentials %w", errNotMounted)
+	}
+	c, ok := auth.As[*credentials.Strategy](s)
+	if !ok {
+		return nil, errors.New("rpc: strategy is not credentials")
+	}
+	return c, nil
+}
+
+// passwordError maps a new password the policy rejects to InvalidArgument
+// with a violation of the field for every rule it breaks, as registering does.
+func passwordError(ctx context.Context, err error, field string) error {
+	e := &auth.Error{Code: codes.InvalidArgument, Message: "password does not satisfy the policy", Err: err}
+	var policyErr password.PolicyError
+	if errors.As(err, &policyErr) {
+		for _, v := range policyErr.Violations {
+			e.Fields = append(e.Fields, &errdetails.BadRequest_FieldViolation{
+				Field:       field,
+				Description: fmt.Sprintf("%s: %s", v.Rule, v.Description),
+			})
+		}
+	}
+	return strategyError(ctx, e)
+}
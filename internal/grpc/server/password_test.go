@@ -0,0 +1,94 @@
+//go:build testdb
+// +build testdb
+
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/credentials"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/pkg/password"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestChangeFieldViolations(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.Tablename)
+	t.Cleanup(cleanup)
+
+	srv, tokens, _ := newIdentity(t, db)
+	require.NoError(t, srv.MountStrategies("credentials"))
+
+	id, email, current := uuid.New(), random.Email(), random.String(12)+"Aa1!"
+	require.NoError(t, credentials.Insert(ctx, db, credentials.InsertParams{
+		ID:        id,
+		Email:     email,
+		Password:  password.SafeString(current),
+		CreatedAt: time.Now(),
+	}))
+	require.NoError(t, credentials.Verify(ctx, db, id))
+	accessToken, err := tokens.MakeAccessToken(email, gen.Strategy_TypeCredentials, token.Grant{})
+	require.NoError(t, err)
+
+	// violations returns the message of the status and the fields of its violations.
+	violations := func(t *testing.T, err error) (string, []string) {
+		t.Helper()
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		st := status.Convert(err)
+		var fields []string
+		for _, d := range st.Details() {
+			if br, ok := d.(*errdetails.BadRequest); ok {
+				for _, v := range br.GetFieldViolations() {
+					fields = append(fields, v.GetField())
+				}
+			}
+		}
+		return st.Message(), fields
+	}
+
+	t.Run("ChangePassword incorrect password", func(t *testing.T) {
+		_, err := srv.ChangePassword(ctx, &gen.ChangePasswordRequest{
+			Token:       string(accessToken),
+			Password:    random.String(12),
+			NewPassword: random.String(12) + "Aa1!",
+		})
+		msg, fields := violations(t, err)
+		require.Equal(t, "incorrect password", msg)
+		require.Equal(t, []string{"password"}, fields)
+	})
+
+	t.Run("ChangePassword new password breaks the policy", func(t *testing.T) {
+		_, err := srv.ChangePassword(ctx, &gen.ChangePasswordRequest{
+			Token:       string(accessToken),
+			Password:    current,
+			NewPassword: "a",
+		})
+		msg, fields := violations(t, err)
+		require.Equal(t, "password does not satisfy the policy", msg)
+		require.NotEmpty(t, fields)
+		for _, f := range fields {
+			require.Equal(t, "new_password", f)
+		}
+	})
+
+	t.Run("ChangeEmail invalid email", func(t *testing.T) {
+		_, err := srv.ChangeEmail(ctx, &gen.ChangeEmailRequest{
+			Token:    string(accessToken),
+			Password: current,
+			NewEmail: "not an email",
+		})
+		msg, fields := violations(t, err)
+		require.Equal(t, "invalid email", msg)
+		require.Equal(t, []string{"new_email"}, fields)
+	})
+}
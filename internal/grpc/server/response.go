@@ -2,11 +2,17 @@ package server
 
 import (
 	"context"
+	"errors"
 
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/lockout"
 	otelCode "go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 func requestIsNilError() error {
@@ -31,14 +37,14 @@ func invalidArgumentError(ctx context.Context, err error, msg string) error {
 	return status.Error(codes.InvalidArgument, msg)
 }
 
-// func alreadyExistsError(ctx context.Context, err error, msg string) error {
-// 	if err != nil {
-// 		span := trace.SpanFromContext(ctx)
-// 		span.SetStatus(otelCode.Error, err.Error())
-// 		span.RecordError(err)
-// 	}
-// 	return status.Error(codes.AlreadyExists, msg)
-// }
+func alreadyExistsError(ctx context.Context, err error, msg string) error {
+	if err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.SetStatus(otelCode.Error, err.Error())
+		span.RecordError(err)
+	}
+	return status.Error(codes.AlreadyExists, msg)
+}
 
 func unauthenticatedError(ctx context.Context, err error, msg string) error {
 	if err != nil {
@@ -57,3 +63,88 @@ func notFoundError(ctx context.Context, err error, msg string) error {
 	}
 	return status.Error(codes.NotFound, msg)
 }
+
+func failedPreconditionError(ctx context.Context, err error, msg string) error {
+	if err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.SetStatus(otelCode.Error, err.Error())
+		span.RecordError(err)
+	}
+	return status.Error(codes.FailedPrecondition, msg)
+}
+
+func permissionDeniedError(ctx context.Context, err error, msg string) error {
+	if err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.SetStatus(otelCode.Error, err.Error())
+		span.RecordError(err)
+	}
+	return status.Error(codes.PermissionDenied, msg)
+}
+
+func unimplementedError(ctx context.Context, err error, msg string) error {
+	if err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.SetStatus(otelCode.Error, err.Error())
+		span.RecordError(err)
+	}
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// unmountedError maps an error of the strategy helpers to a response,
+// a strategy that is not configured is unimplemented rather than a fault of the server.
+func unmountedError(ctx context.Context, err error) error {
+	if errors.Is(err, errNotMounted) {
+		return unimplementedError(ctx, err, "strategy is not enabled")
+	}
+	return internalServerError(ctx, err)
+}
+
+// lockedError returns ResourceExhausted with the time the blocked attempts can be retried after.
+func lockedError(ctx context.Context, err error, locked *lockout.LockedError) error {
+	return strategyError(ctx, &auth.Error{
+		Code:       codes.ResourceExhausted,
+		Message:    "too many failed attempts, retry later",
+		Err:        err,
+		RetryAfter: locked.RetryAfter,
+	})
+}
+
+// fieldError returns InvalidArgument with a fixed message and a violation of the field of the request.
+func fieldError(ctx context.Context, err error, msg, field, description string) error {
+	return strategyError(ctx, &auth.Error{
+		Code:    codes.InvalidArgument,
+		Message: msg,
+		Err:     err,
+		Fields:  []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}},
+	})
+}
+
+// strategyError maps an error of a strategy to a response, [auth.Error] is returned with its code and message.
+func strategyError(ctx context.Context, err error) error {
+	var e *auth.Error
+	if !errors.As(err, &e) {
+		return internalServerError(ctx, err)
+	}
+	span := trace.SpanFromContext(ctx)
+	span.SetStatus(otelCode.Error, err.Error())
+	span.RecordError(err)
+
+	var details []protoadapt.MessageV1
+	if len(e.Fields) > 0 {
+		details = append(details, &errdetails.BadRequest{FieldViolations: e.Fields})
+	}
+	if e.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)})
+	}
+
+	st := status.New(e.Code, e.Message)
+	if len(details) > 0 {
+		withDetails, err := st.WithDetails(details...)
+		if err != nil {
+			return internalServerError(ctx, err)
+		}
+		st = withDetails
+	}
+	return st.Err()
+}
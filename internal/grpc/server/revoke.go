@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Logout revokes the given access token, which must be issued to the user themselves, and, if given,
+// the refresh token of the same login together with every refresh token rotated from it.
+func (x *Identity) Logout(ctx context.Context, req *gen.LogoutRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "Logout")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	access, err := x.parseToken(ctx, req.GetAccessToken())
+	if err != nil {
+		return nil, tokenError(ctx, err)
+	}
+	c, err := token.ClaimsFromToken(access)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+	if c.TokenType != token.PasetoTokenTypeAccess {
+		return nil, invalidArgumentError(
+			ctx,
+			fmt.Errorf("rpc: token type is %s, expecting %s", c.TokenType, token.PasetoTokenTypeAccess),
+			"incorrect token",
+		)
+	}
+	if err = requireFirstParty(access, c); err != nil {
+		return nil, tokenError(ctx, err)
+	}
+
+	if req.GetRefreshToken() != "" {
+		refresh, err := x.tokenMaker.Parse(req.GetRefreshToken())
+		if err != nil {
+			return nil, unauthenticatedError(ctx, err, "incorrect refresh token")
+		}
+		var tokenType string
+		if err = refresh.Get(token.PasetoTokenTypeKey, &tokenType); err != nil {
+			return nil, internalServerError(ctx, err)
+		}
+		if tokenType != token.PasetoTokenTypeRefresh {
+			return nil, invalidArgumentError(
+				ctx,
+				fmt.Errorf("rpc: token type is %s, expecting %s", tokenType, token.PasetoTokenTypeRefresh),
+				"incorrect refresh token",
+			)
+		}
+		if err = x.revokeToken(ctx, refresh); err != nil {
+			return nil, internalServerError(ctx, err)
+		}
+	}
+
+	if err = x.revokeToken(ctx, access); err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// RevokeToken revokes any token issued by the service until it expires,
+// a refresh token together with every refresh token rotated from the same login.
+// Like RFC 7009, a token that is invalid or expired is not an error as there is nothing to revoke.
+func (x *Identity) RevokeToken(ctx context.Context, req *gen.TokenRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "RevokeToken")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	t, err := x.tokenMaker.Parse(req.GetToken())
+	if err != nil {
+		slog.InfoContext(ctx, "rpc: revoking token that does not parse", "err", err)
+		return &emptypb.Empty{}, nil
+	}
+	if err = x.revokeToken(ctx, t); err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/apikey"
+	"github.com/Salam4nder/identity/internal/auth/grant"
+	"github.com/Salam4nder/identity/internal/auth/refresh"
 	"github.com/Salam4nder/identity/internal/auth/strategy/credentials"
-	"github.com/Salam4nder/identity/internal/auth/strategy/personalnumber"
 	"github.com/Salam4nder/identity/internal/observability/metrics"
 	"github.com/Salam4nder/identity/internal/token"
 	"github.com/Salam4nder/identity/proto/gen"
@@ -30,50 +34,26 @@ func (x *Identity) Register(ctx context.Context, req *gen.RegisterRequest) (*gen
 	strategy := req.GetStrategy()
 	span.SetAttributes(attribute.String("strategy", strategy.String()))
 
-	registerResponse := new(gen.RegisterResponse)
-	var (
-		err        error
-		requestCtx context.Context
-	)
-	switch strategy {
-	case gen.Strategy_TypeCredentials:
-		ctx = credentials.NewContext(ctx, &credentials.Input{
-			Email:    req.GetCredentials().GetEmail(),
-			Password: req.GetCredentials().GetPassword(),
-		})
-
-		requestCtx, err = x.strategies[strategy].Register(ctx)
-		if err != nil {
-			return nil, internalServerError(ctx, err)
-		}
-
-		creds, err := credentials.FromContext(requestCtx)
-		if err != nil {
-			return nil, internalServerError(ctx, err)
-		}
-
-		registerResponse.Data = &gen.RegisterResponse_Credentials{Credentials: &gen.CredentialsOutput{Email: creds.Email}}
-	case gen.Strategy_TypePersonalNumber:
-		requestCtx, err = x.strategies[strategy].Register(ctx)
-		if err != nil {
-			return nil, internalServerError(ctx, err)
-		}
+	m, err := x.mounted(strategy)
+	if err != nil {
+		return nil, invalidArgumentError(ctx, err, fmt.Sprintf("unsupported strategy %s", strategy))
+	}
 
-		n, err := personalnumber.FromContext(requestCtx)
-		if err != nil {
-			return nil, internalServerError(ctx, err)
+	registered, err := m.Register(ctx, req)
+	if err != nil {
+		if errors.Is(err, auth.ErrRegistrationNotSupported) {
+			return nil, invalidArgumentError(ctx, err, fmt.Sprintf("strategy %s does not support registration", strategy))
 		}
-
-		registerResponse.Data = &gen.RegisterResponse_Number{Number: &gen.PersonalNumber{Number: n}}
-
-	default:
-		return nil, internalServerError(ctx, fmt.Errorf("unsupported strategy %s", req.GetStrategy().String()))
+		return nil, strategyError(ctx, err)
 	}
 
-	metrics.UsersActive.Inc()
-	metrics.UsersRegistered.Inc()
+	// Registering a taken identifier may succeed without creating a user.
+	if registered.Created {
+		metrics.UsersActive.Inc()
+		metrics.UsersRegistered.Inc()
+	}
 
-	return registerResponse, nil
+	return registered.Response, nil
 }
 
 // VerifyEmail verifies a user that registered using the credentials strategy.
@@ -85,20 +65,21 @@ func (x *Identity) VerifyEmail(ctx context.Context, req *gen.TokenRequest) (*emp
 		return nil, requestIsNilError()
 	}
 
-	c, ok := x.strategies[gen.Strategy_TypeCredentials]
-	if !ok {
-		return nil, internalServerError(ctx, errors.New("rpc: getting strategy"))
-	}
-	switch credStrat := c.(type) {
-	case *credentials.Strategy:
-		if err := credStrat.VerifyEmail(ctx, req.GetToken()); err != nil {
-			if errors.Is(err, credentials.ErrTokenDoesNotExist) {
-				return nil, unauthenticatedError(ctx, err, "incorrect token")
-			}
+	c, err := x.credentialsStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+	if err = c.VerifyEmail(ctx, req.GetToken()); err != nil {
+		switch {
+		case errors.Is(err, credentials.ErrTokenDoesNotExist):
+			return nil, unauthenticatedError(ctx, err, "incorrect token")
+		case errors.Is(err, credentials.ErrTokenExpired):
+			return nil, failedPreconditionError(ctx, err, "token has expired, request a new one")
+		case errors.Is(err, credentials.ErrUserVerified):
+			return nil, failedPreconditionError(ctx, err, "user is already verified")
+		default:
 			return nil, internalServerError(ctx, err)
 		}
-	default:
-		return nil, internalServerError(ctx, errors.New("rpc: strategy is not credentials"))
 	}
 
 	return &emptypb.Empty{}, nil
@@ -116,73 +97,74 @@ func (x *Identity) Authenticate(ctx context.Context, req *gen.AuthenticateReques
 	strategy := req.GetStrategy()
 	span.SetAttributes(attribute.String("strategy", strategy.String()))
 
-	var (
-		err                       error
-		accessToken, refreshToken token.SafeString
-	)
-	switch strategy {
-	case gen.Strategy_TypeCredentials:
-		ctx = credentials.NewContext(ctx, &credentials.Input{
-			Email:    req.GetCredentials().GetEmail(),
-			Password: req.GetCredentials().GetPassword(),
-		})
-
-		if err = x.strategies[strategy].Authenticate(ctx); err != nil {
-			switch {
-			case errors.Is(err, credentials.ErrUserNotFound), errors.Is(err, credentials.ErrIncorrectPassword):
-				return nil, invalidArgumentError(ctx, err, err.Error())
-			case errors.Is(err, credentials.ErrUserNotVerified):
-				return nil, notFoundError(ctx, err, err.Error())
-			default:
-				return nil, internalServerError(ctx, err)
-			}
-		}
-		accessToken, err = x.tokenMaker.MakeAccessToken(req.GetCredentials().Email, gen.Strategy_TypeCredentials)
-		if err != nil {
-			return nil, internalServerError(ctx, err)
-		}
-		refreshToken, err = x.tokenMaker.MakeRefreshToken(req.GetCredentials().Email, gen.Strategy_TypeCredentials)
-		if err != nil {
-			return nil, internalServerError(ctx, err)
-		}
+	m, err := x.mounted(strategy)
+	if err != nil {
+		return nil, invalidArgumentError(ctx, err, fmt.Sprintf("unsupported strategy %s", strategy))
+	}
 
-	case gen.Strategy_TypePersonalNumber:
-		ctx = personalnumber.NewContext(ctx, req.GetNumber().GetNumber())
+	id, err := m.Authenticate(ctx, req)
+	if err != nil {
+		return nil, strategyError(ctx, err)
+	}
+	// The identity may belong to another strategy than the one signed in with.
+	r, ok := auth.Lookup(id.Strategy)
+	if !ok {
+		return nil, internalServerError(ctx, fmt.Errorf("rpc: strategy %s is not registered", id.Strategy))
+	}
+	if err = r.Identifier.Check(id.Identifier); err != nil {
+		return nil, internalServerError(ctx, err)
+	}
 
-		if err = x.strategies[strategy].Authenticate(ctx); err != nil {
-			switch {
-			case errors.Is(err, personalnumber.ErrNumberNotFound):
-				return nil, invalidArgumentError(ctx, err, err.Error())
-			default:
-				return nil, internalServerError(ctx, err)
-			}
-		}
+	scopes := req.GetScopes()
+	if id.Scopes != nil && len(scopes) == 0 {
+		scopes = id.Scopes
+	}
+	g, err := x.grantFor(ctx, grant.Request{
+		Identifier: id.Identifier,
+		Strategy:   id.Strategy,
+		Scopes:     scopes,
+		Audience:   req.GetAudience(),
+		Allowed:    id.Scopes,
+	})
+	if err != nil {
+		return nil, grantError(ctx, err)
+	}
 
-		accessToken, err = x.tokenMaker.MakeAccessToken(
-			req.GetNumber().GetNumber(),
-			gen.Strategy_TypePersonalNumber,
-		)
+	var mfaPending bool
+	if r.MFA {
+		if mfaPending, err = x.totp.Enabled(ctx, fmt.Sprint(id.Identifier)); err != nil {
+			return nil, internalServerError(ctx, err)
+		}
+	}
+	if mfaPending {
+		mfaToken, err := x.tokenMaker.MakeMFAToken(id.Identifier, id.Strategy, g)
 		if err != nil {
 			return nil, internalServerError(ctx, err)
 		}
-		refreshToken, err = x.tokenMaker.MakeRefreshToken(
-			req.GetNumber().GetNumber(),
-			gen.Strategy_TypePersonalNumber,
-		)
+		return &gen.AuthenticateResponse{MfaToken: string(mfaToken)}, nil
+	}
+
+	if id.TokenTTL > 0 {
+		g.TTL = id.TokenTTL
+		accessToken, err := x.tokenMaker.MakeAccessToken(id.Identifier, id.Strategy, g)
 		if err != nil {
 			return nil, internalServerError(ctx, err)
 		}
+		return &gen.AuthenticateResponse{
+			AccessToken: string(accessToken),
+			ExpiresAt:   timestamppb.New(time.Now().Add(id.TokenTTL)),
+		}, nil
+	}
 
-	default:
-		return nil, internalServerError(ctx, fmt.Errorf("unsupported strategy %s", req.GetStrategy().String()))
+	resp, err := x.issueTokens(ctx, id.Identifier, id.Strategy, g)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
 	}
-	return &gen.AuthenticateResponse{
-		AccessToken:  string(accessToken),
-		RefreshToken: string(refreshToken),
-	}, nil
+	return resp, nil
 }
 
-// Refresh will exchange a valid refresh token for a new access token.
+// Refresh will exchange a valid refresh token for a new access token and the next refresh token of its family.
+// Every refresh token can only be used once, using one twice revokes its family.
 func (x *Identity) Refresh(ctx context.Context, req *gen.TokenRequest) (*gen.RefreshResponse, error) {
 	ctx, span := tracer.Start(ctx, "Refresh")
 	defer span.End()
@@ -191,9 +173,9 @@ func (x *Identity) Refresh(ctx context.Context, req *gen.TokenRequest) (*gen.Ref
 		return nil, requestIsNilError()
 	}
 
-	t, err := x.tokenMaker.Parse(req.GetToken())
+	t, err := x.parseToken(ctx, req.GetToken())
 	if err != nil {
-		return nil, internalServerError(ctx, err)
+		return nil, tokenError(ctx, err)
 	}
 
 	var tokenType string
@@ -213,46 +195,82 @@ func (x *Identity) Refresh(ctx context.Context, req *gen.TokenRequest) (*gen.Ref
 		return nil, internalServerError(ctx, err)
 	}
 
-	var accessToken token.SafeString
-	switch strategy {
-	case gen.Strategy_TypeCredentials:
-		var email string
-		if err = t.Get(token.PasetoIdentifierKey, &email); err != nil {
-			return nil, internalServerError(ctx, err)
-		}
-		accessToken, err = x.tokenMaker.MakeAccessToken(email, gen.Strategy_TypeCredentials)
-		if err != nil {
-			return nil, internalServerError(ctx, err)
-		}
-	case gen.Strategy_TypePersonalNumber:
-		var number uint64
-		if err = t.Get(token.PasetoIdentifierKey, &number); err != nil {
-			return nil, internalServerError(ctx, err)
-		}
-		accessToken, err = x.tokenMaker.MakeAccessToken(number, gen.Strategy_TypePersonalNumber)
-		if err != nil {
-			return nil, internalServerError(ctx, err)
+	m, err := x.mounted(strategy)
+	if err != nil {
+		return nil, unauthenticatedError(ctx, err, "incorrect token")
+	}
+	identifier, err := token.IdentifierFromToken(t)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+	if err = m.Identifier.Check(identifier); err != nil {
+		return nil, unauthenticatedError(ctx, err, "incorrect token")
+	}
+	issuedAt, err := t.GetIssuedAt()
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+	if err = m.Refresh(ctx, identifier, issuedAt); err != nil {
+		return nil, strategyError(ctx, err)
+	}
+
+	c, err := token.ClaimsFromToken(t)
+	if err != nil {
+		return nil, unauthenticatedError(ctx, err, "incorrect token")
+	}
+	g, err := x.grantFor(ctx, grant.Request{
+		Identifier: identifier,
+		Strategy:   strategy,
+		Scopes:     c.Scopes,
+		Audience:   c.Audience,
+	})
+	if err != nil {
+		return nil, grantError(ctx, err)
+	}
+
+	id, err := token.RefreshIDFromToken(t)
+	if err != nil {
+		return nil, unauthenticatedError(ctx, err, "incorrect token")
+	}
+	next, err := x.families.Rotate(ctx, id, fmt.Sprint(identifier), x.tokenMaker.RefreshTokenExpiration())
+	if err != nil {
+		if errors.Is(err, refresh.ErrNotFound) || errors.Is(err, refresh.ErrRevoked) || errors.Is(err, refresh.ErrReused) {
+			return nil, unauthenticatedError(ctx, err, "incorrect token")
 		}
+		return nil, internalServerError(ctx, err)
+	}
+
+	accessToken, err := x.tokenMaker.MakeAccessToken(identifier, strategy, g)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+	refreshToken, err := x.tokenMaker.MakeRefreshToken(identifier, strategy, next, g)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
 	}
 
 	return &gen.RefreshResponse{
-		Token:     string(accessToken),
-		ExpiresAt: timestamppb.New(x.tokenMaker.AccessTokenExpiration()),
+		Token:        string(accessToken),
+		ExpiresAt:    timestamppb.New(x.tokenMaker.AccessTokenExpiration()),
+		RefreshToken: string(refreshToken),
 	}, nil
 }
 
-// Validate an access token.
-func (x *Identity) Validate(ctx context.Context, req *gen.TokenRequest) (*emptypb.Empty, error) {
-	ctx, span := tracer.Start(ctx, "Refresh")
+// Validate an access token or API key, and that it was issued for the audience and scopes if any are given.
+func (x *Identity) Validate(ctx context.Context, req *gen.ValidateRequest) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "Validate")
 	defer span.End()
 
 	if req == nil {
 		return nil, requestIsNilError()
 	}
+	if apikey.IsKey(req.GetToken()) {
+		return x.validateAPIKey(ctx, req)
+	}
 
-	t, err := x.tokenMaker.Parse(req.GetToken())
+	t, err := x.parseToken(ctx, req.GetToken())
 	if err != nil {
-		return nil, internalServerError(ctx, err)
+		return nil, tokenError(ctx, err)
 	}
 
 	var tokenType string
@@ -266,5 +284,27 @@ func (x *Identity) Validate(ctx context.Context, req *gen.TokenRequest) (*emptyp
 			"incorrect token",
 		)
 	}
+
+	if req.GetAudience() == "" && len(req.GetScopes()) == 0 {
+		return &emptypb.Empty{}, nil
+	}
+	c, err := token.ClaimsFromToken(t)
+	if err != nil {
+		return nil, internalServerError(ctx, err)
+	}
+	if req.GetAudience() != "" && c.Audience != req.GetAudience() {
+		return nil, unauthenticatedError(
+			ctx,
+			fmt.Errorf("rpc: token audience is %q, expecting %q", c.Audience, req.GetAudience()),
+			"incorrect audience",
+		)
+	}
+	if !c.HasScopes(req.GetScopes()...) {
+		return nil, permissionDeniedError(
+			ctx,
+			fmt.Errorf("rpc: token scopes are %v, expecting %v", c.Scopes, req.GetScopes()),
+			"insufficient scope",
+		)
+	}
 	return &emptypb.Empty{}, nil
 }
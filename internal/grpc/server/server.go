@@ -7,8 +7,13 @@ import (
 	"log/slog"
 
 	"github.com/Salam4nder/identity/internal/auth"
-	"github.com/Salam4nder/identity/internal/auth/strategy/credentials"
-	"github.com/Salam4nder/identity/internal/auth/strategy/personalnumber"
+	"github.com/Salam4nder/identity/internal/auth/apikey"
+	"github.com/Salam4nder/identity/internal/auth/exchange"
+	"github.com/Salam4nder/identity/internal/auth/grant"
+	"github.com/Salam4nder/identity/internal/auth/mfa"
+	"github.com/Salam4nder/identity/internal/auth/refresh"
+	"github.com/Salam4nder/identity/internal/auth/revocation"
+	"github.com/Salam4nder/identity/internal/config"
 	"github.com/Salam4nder/identity/internal/token"
 	"github.com/Salam4nder/identity/proto/gen"
 	"github.com/nats-io/nats.go"
@@ -19,51 +24,87 @@ import (
 type Identity struct {
 	gen.IdentityServer
 
+	cfg        *config.Application
 	db         *sql.DB
 	health     *health.Server
 	natsConn   *nats.Conn
 	tokenMaker token.Maker
+	totp       *mfa.TOTP
+	families   *refresh.Families
+	keys       *apikey.Keys
+	revoked    *revocation.Store
+	policy     grant.Policy
+	enrich     grant.Enricher
+	exchanges  *exchange.Policies
 
-	strategies map[gen.Strategy]auth.Strategy
+	strategies map[gen.Strategy]*auth.Mounted
 }
 
 // NewIdentity returns a new [Identity] gRPC server.
 func NewIdentity(
+	cfg *config.Application,
 	db *sql.DB,
 	health *health.Server,
 	natsConn *nats.Conn,
 	tokenMaker token.Maker,
+	totp *mfa.TOTP,
+	revoked *revocation.Store,
+	policy grant.Policy,
+	exchanges *exchange.Policies,
 ) *Identity {
 	return &Identity{
+		cfg:        cfg,
 		tokenMaker: tokenMaker,
+		totp:       totp,
+		families:   refresh.NewFamilies(db, natsConn),
+		keys:       apikey.NewKeys(db, cfg.APIKeys),
+		revoked:    revoked,
+		policy:     policy,
+		exchanges:  exchanges,
 		health:     health,
 		natsConn:   natsConn,
 		db:         db,
 	}
 }
 
-// MountStrategies will parse the configured strategy string representations and mount them on the server.
-// Aborts and returns an error if any of them fails to parse.
+// MountStrategies will build the registered strategies with the configured names and mount them on the server.
+// Aborts and returns an error if any of them is not registered or fails to build.
 func (x *Identity) MountStrategies(s ...string) error {
-	m := make(map[gen.Strategy]auth.Strategy)
+	m := make(map[gen.Strategy]*auth.Mounted)
+	deps := auth.Deps{DB: x.db, NATS: x.natsConn, Config: x.cfg}
 
 	for _, v := range s {
-		strategy, err := auth.StrategyFromString(v)
+		strategy, err := auth.Mount(v, deps)
 		if err != nil {
-			return err
+			return fmt.Errorf("server: mounting strategy %s, %w", v, err)
 		}
+		m[strategy.Type] = strategy
 		slog.Info(fmt.Sprintf("mounted strategy %s", v))
-		switch strategy {
-		case gen.Strategy_TypeCredentials:
-			m[strategy] = credentials.New(x.db, x.natsConn)
-		case gen.Strategy_TypePersonalNumber:
-			m[strategy] = personalnumber.New(x.db)
-		default:
-			return errors.New("server: unsupported strategy")
-		}
 	}
 
 	x.strategies = m
 
 	return nil
 }
+
+// errNotMounted is returned by the helpers of strategy specific RPCs when their strategy is not configured.
+var errNotMounted = errors.New("strategy is not mounted")
+
+// mounted returns a strategy if it is mounted.
+func (x *Identity) mounted(t gen.Strategy) (*auth.Mounted, error) {
+	m, ok := x.strategies[t]
+	if !ok {
+		return nil, fmt.Errorf("rpc: strategy %s is not mounted", t)
+	}
+	return m, nil
+}
+
+// Strategies returns the mounted strategies, so that other transports can sign users in with them.
+func (x *Identity) Strategies() map[gen.Strategy]*auth.Mounted {
+	return x.strategies
+}
+
+// UseClaimsEnricher adds the claims of the given [grant.Enricher] to every access token issued from now on.
+func (x *Identity) UseClaimsEnricher(e grant.Enricher) {
+	x.enrich = e
+}
@@ -0,0 +1,99 @@
+//go:build testdb
+// +build testdb
+
+package server_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth/exchange"
+	"github.com/Salam4nder/identity/internal/auth/grant"
+	"github.com/Salam4nder/identity/internal/auth/mfa"
+	"github.com/Salam4nder/identity/internal/auth/revocation"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/credentials"
+	"github.com/Salam4nder/identity/internal/grpc/server"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/pkg/encryption"
+	"github.com/Salam4nder/identity/pkg/password"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The client exchanging tokens for exchangeAudience on behalf of users, which may pass on adminScope.
+const (
+	exchangeClient   = "billing-gateway"
+	exchangeSecret   = "billing-gateway-secret"
+	exchangeAudience = "billing"
+	adminScope       = "clients:admin"
+)
+
+// newIdentity returns a server without mounted strategies and the token maker and TOTP it uses.
+func newIdentity(t *testing.T, db *sql.DB) (*server.Identity, token.Maker, *mfa.TOTP) {
+	t.Helper()
+
+	tokens, err := token.BootstrapPasetoMaker(time.Minute, time.Hour, []byte(random.String(32)))
+	require.NoError(t, err)
+	totp, err := mfa.NewTOTP(db, []byte(random.String(encryption.KeySize)))
+	require.NoError(t, err)
+	policy, err := grant.NewConfigPolicy(config.Scopes{})
+	require.NoError(t, err)
+	exchanges, err := exchange.NewPolicies(config.TokenExchange{Clients: []config.ExchangeClient{{
+		ID:       exchangeClient,
+		Secret:   exchangeSecret,
+		Policies: []config.ExchangePolicy{{Audience: exchangeAudience, Scopes: []string{adminScope}, Delegation: true}},
+	}}})
+	require.NoError(t, err)
+
+	return server.NewIdentity(
+		&config.Application{Clients: config.Clients{AdminScope: adminScope}},
+		db,
+		nil,
+		nil,
+		tokens,
+		totp,
+		revocation.NewStore(db),
+		policy,
+		exchanges,
+	), tokens, totp
+}
+
+// insertUser inserts a verified credentials user and returns their email.
+func insertUser(t *testing.T, ctx context.Context, db *sql.DB) string {
+	t.Helper()
+
+	id, email := uuid.New(), random.Email()
+	require.NoError(t, credentials.Insert(ctx, db, credentials.InsertParams{
+		ID:        id,
+		Email:     email,
+		Password:  password.SafeString(random.String(10)),
+		CreatedAt: time.Now(),
+	}))
+	require.NoError(t, credentials.Verify(ctx, db, id))
+	return email
+}
+
+func TestUnmountedStrategies(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.Tablename)
+	t.Cleanup(cleanup)
+
+	srv, _, _ := newIdentity(t, db)
+
+	_, err := srv.BeginWebAuthnLogin(ctx, &gen.WebAuthnBeginRequest{Name: random.String(10)})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+	_, err = srv.RequestMagicLink(ctx, &gen.MagicLink{Email: random.Email()})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+	_, err = srv.RequestPasswordReset(ctx, &gen.PasswordResetRequest{Email: random.Email()})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+	_, err = srv.BeginFederatedLogin(ctx, &gen.FederatedBeginRequest{Provider: "upstream"})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
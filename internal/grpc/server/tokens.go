@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/Salam4nder/identity/internal/auth/exchange"
+	"github.com/Salam4nder/identity/internal/auth/grant"
+	"github.com/Salam4nder/identity/internal/auth/revocation"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// errIncorrectToken wraps every reason a token is rejected that is not an internal error.
+	errIncorrectToken = errors.New("rpc: incorrect token")
+	// errDelegatedToken is returned by [requireFirstParty] for tokens issued to another service.
+	errDelegatedToken = errors.New("rpc: token was issued for another service")
+)
+
+// issueTokens makes an access token and the first refresh token of a new family for an authenticated user.
+func (x *Identity) issueTokens(
+	ctx context.Context,
+	identifier any,
+	strategy gen.Strategy,
+	g token.Grant,
+) (*gen.AuthenticateResponse, error) {
+	id, err := x.families.Start(ctx, x.tokenMaker.RefreshTokenExpiration())
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := x.tokenMaker.MakeAccessToken(identifier, strategy, g)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := x.tokenMaker.MakeRefreshToken(identifier, strategy, id, g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gen.AuthenticateResponse{
+		AccessToken:  string(accessToken),
+		RefreshToken: string(refreshToken),
+		ExpiresAt:    timestamppb.New(x.tokenMaker.AccessTokenExpiration()),
+	}, nil
+}
+
+// grantFor decides what the tokens of a request are issued for and adds the enriched claims.
+// Errors are mapped to a response with [grantError].
+func (x *Identity) grantFor(ctx context.Context, r grant.Request) (token.Grant, error) {
+	g, err := x.policy.Decide(ctx, r)
+	if err != nil {
+		return token.Grant{}, err
+	}
+	if x.enrich == nil {
+		return g, nil
+	}
+
+	r.Scopes, r.Audience = g.Scopes, g.Audience
+	if g.Extra, err = x.enrich(ctx, r); err != nil {
+		return token.Grant{}, fmt.Errorf("rpc: enriching claims, %w", err)
+	}
+	return g, nil
+}
+
+// grantError maps an error of [Identity.grantFor] to a response.
+func grantError(ctx context.Context, err error) error {
+	if errors.Is(err, grant.ErrScopeNotAllowed) || errors.Is(err, grant.ErrAudienceNotAllowed) {
+		return permissionDeniedError(ctx, err, err.Error())
+	}
+	return internalServerError(ctx, err)
+}
+
+// parseToken parses a token and rejects it if it was revoked.
+// Errors wrapping [errIncorrectToken] or [revocation.ErrRevoked] are the caller's fault, see [tokenError].
+func (x *Identity) parseToken(ctx context.Context, t string) (*paseto.Token, error) {
+	parsed, err := x.tokenMaker.Parse(t)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %w", errIncorrectToken, err)
+	}
+
+	id, err := token.IDFromToken(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %w", errIncorrectToken, err)
+	}
+	if err = x.revoked.Check(ctx, id); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// requireFirstParty rejects access tokens with an audience or an actor, such as those from
+// [Identity.ExchangeToken], which a downstream service received to call its own API on behalf of the user.
+// Only tokens issued to users themselves manage their account, credentials or clients. See [tokenError].
+func requireFirstParty(t *paseto.Token, c token.Claims) error {
+	if _, ok := t.Claims()[exchange.ActorKey]; ok || c.Audience != "" {
+		return fmt.Errorf("%w, audience is %q", errDelegatedToken, c.Audience)
+	}
+	return nil
+}
+
+// revokeToken revokes a parsed token until it expires, and the family of a refresh token.
+func (x *Identity) revokeToken(ctx context.Context, t *paseto.Token) error {
+	id, err := token.IDFromToken(t)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := t.GetExpiration()
+	if err != nil {
+		return err
+	}
+	if err = x.revoked.Revoke(ctx, id, expiresAt); err != nil {
+		return err
+	}
+
+	var tokenType string
+	if err = t.Get(token.PasetoTokenTypeKey, &tokenType); err != nil {
+		return err
+	}
+	if tokenType != token.PasetoTokenTypeRefresh {
+		return nil
+	}
+	refreshID, err := token.RefreshIDFromToken(t)
+	if err != nil {
+		return err
+	}
+	return x.families.Revoke(ctx, refreshID.Family)
+}
+
+// tokenError maps an error of [Identity.parseToken] or [requireFirstParty] to a response.
+func tokenError(ctx context.Context, err error) error {
+	if errors.Is(err, errIncorrectToken) || errors.Is(err, revocation.ErrRevoked) {
+		return unauthenticatedError(ctx, err, "incorrect token")
+	}
+	if errors.Is(err, errDelegatedToken) {
+		return permissionDeniedError(ctx, err, "only tokens issued to users themselves are accepted")
+	}
+	return internalServerError(ctx, err)
+}
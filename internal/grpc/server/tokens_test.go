@@ -0,0 +1,83 @@
+//go:build testdb
+// +build testdb
+
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/credentials"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRequireFirstParty(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := database.SetupTestConn(credentials.Tablename)
+	t.Cleanup(cleanup)
+
+	srv, tokens, _ := newIdentity(t, db)
+
+	email := insertUser(t, ctx, db)
+	accessToken, err := tokens.MakeAccessToken(email, gen.Strategy_TypeCredentials, token.Grant{
+		Scopes: []string{adminScope},
+	})
+	require.NoError(t, err)
+	exchanged, err := srv.ExchangeToken(ctx, &gen.ExchangeTokenRequest{
+		SubjectToken: string(accessToken),
+		ClientId:     exchangeClient,
+		ClientSecret: exchangeSecret,
+		Audience:     exchangeAudience,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{adminScope}, exchanged.GetScopes())
+	delegated := exchanged.GetAccessToken()
+
+	for name, call := range map[string]func(t string) error{
+		"EnrollTOTP": func(t string) error {
+			_, err := srv.EnrollTOTP(ctx, &gen.TokenRequest{Token: t})
+			return err
+		},
+		"ConfirmTOTP": func(t string) error {
+			_, err := srv.ConfirmTOTP(ctx, &gen.TOTPRequest{Token: t, Code: "000000"})
+			return err
+		},
+		"ChangePassword": func(t string) error {
+			_, err := srv.ChangePassword(ctx, &gen.ChangePasswordRequest{Token: t})
+			return err
+		},
+		"ChangeEmail": func(t string) error {
+			_, err := srv.ChangeEmail(ctx, &gen.ChangeEmailRequest{Token: t})
+			return err
+		},
+		"CreateClient": func(t string) error {
+			_, err := srv.CreateClient(ctx, &gen.CreateClientRequest{Token: t})
+			return err
+		},
+		"RotateClientSecret": func(t string) error {
+			_, err := srv.RotateClientSecret(ctx, &gen.RotateClientSecretRequest{Token: t})
+			return err
+		},
+		"Logout": func(t string) error {
+			_, err := srv.Logout(ctx, &gen.LogoutRequest{AccessToken: t})
+			return err
+		},
+	} {
+		t.Run(name+" rejects exchanged token", func(t *testing.T) {
+			err := call(delegated)
+			require.Equal(t, codes.PermissionDenied, status.Code(err))
+			require.Equal(t, "only tokens issued to users themselves are accepted", status.Convert(err).Message())
+		})
+	}
+
+	t.Run("CreateClient accepts first-party admin token", func(t *testing.T) {
+		// The client_credentials strategy is not mounted, so the call gets past the token only.
+		_, err := srv.CreateClient(ctx, &gen.CreateClientRequest{Token: string(accessToken)})
+		require.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/strategy/webauthn"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/proto/gen"
+)
+
+// BeginWebAuthnRegistration starts a passkey registration ceremony.
+// Names are public handles rather than emails, there is no owner to notify of a taken one,
+// so registering reports it and names are not kept from enumeration.
+func (x *Identity) BeginWebAuthnRegistration(
+	ctx context.Context,
+	req *gen.WebAuthnBeginRequest,
+) (*gen.WebAuthnBeginResponse, error) {
+	ctx, span := tracer.Start(ctx, "BeginWebAuthnRegistration")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	s, err := x.webAuthnStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	options, sessionID, err := s.BeginRegistration(ctx, req.GetName())
+	if err != nil {
+		switch {
+		case errors.Is(err, webauthn.ErrNameTaken):
+			return nil, alreadyExistsError(ctx, err, "name is already taken")
+		case errors.As(err, &database.InputError{}):
+			return nil, invalidArgumentError(ctx, err, "name is empty")
+		default:
+			return nil, internalServerError(ctx, err)
+		}
+	}
+
+	return &gen.WebAuthnBeginResponse{SessionId: sessionID, Options: options}, nil
+}
+
+// FinishWebAuthnRegistration finishes a passkey registration ceremony.
+// It is equivalent to [Identity.Register] with the webauthn strategy.
+func (x *Identity) FinishWebAuthnRegistration(ctx context.Context, req *gen.WebAuthnInput) (*gen.RegisterResponse, error) {
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+	return x.Register(ctx, &gen.RegisterRequest{
+		Strategy: gen.Strategy_TypeWebAuthn,
+		Data:     &gen.RegisterRequest_Webauthn{Webauthn: req},
+	})
+}
+
+// BeginWebAuthnLogin starts a passkey assertion ceremony.
+// Unknown names are reported, as [Identity.BeginWebAuthnRegistration] reports taken ones anyway.
+func (x *Identity) BeginWebAuthnLogin(ctx context.Context, req *gen.WebAuthnBeginRequest) (*gen.WebAuthnBeginResponse, error) {
+	ctx, span := tracer.Start(ctx, "BeginWebAuthnLogin")
+	defer span.End()
+
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+
+	s, err := x.webAuthnStrategy()
+	if err != nil {
+		return nil, unmountedError(ctx, err)
+	}
+
+	options, sessionID, err := s.BeginLogin(ctx, req.GetName())
+	if err != nil {
+		if errors.Is(err, webauthn.ErrUserNotFound) {
+			return nil, notFoundError(ctx, err, "user does not exist")
+		}
+		return nil, internalServerError(ctx, err)
+	}
+
+	return &gen.WebAuthnBeginResponse{SessionId: sessionID, Options: options}, nil
+}
+
+// FinishWebAuthnLogin finishes a passkey assertion ceremony.
+// It is equivalent to [Identity.Authenticate] with the webauthn strategy.
+func (x *Identity) FinishWebAuthnLogin(ctx context.Context, req *gen.WebAuthnInput) (*gen.AuthenticateResponse, error) {
+	if req == nil {
+		return nil, requestIsNilError()
+	}
+	return x.Authenticate(ctx, &gen.AuthenticateRequest{
+		Strategy: gen.Strategy_TypeWebAuthn,
+		Data:     &gen.AuthenticateRequest_Webauthn{Webauthn: req},
+	})
+}
+
+func (x *Identity) webAuthnStrategy() (*webauthn.Strategy, error) {
+	s, ok := x.strategies[gen.Strategy_TypeWebAuthn]
+	if !ok {
+		return nil, fmt.Errorf("rpc: webauthn %w", errNotMounted)
+	}
+	w, ok := auth.As[*webauthn.Strategy](s)
+	if !ok {
+		return nil, errors.New("rpc: strategy is not webauthn")
+	}
+	return w, nil
+}
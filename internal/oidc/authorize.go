@@ -0,0 +1,358 @@
+package oidc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/grant"
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/auth/mfa"
+	"github.com/Salam4nder/identity/internal/auth/strategy/credentials"
+	"github.com/Salam4nder/identity/internal/auth/strategy/personalnumber"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	codeChallengeMethod = "S256"
+	// codeSize is the number of random bytes of an authorization code and of the anti-CSRF cookie.
+	codeSize = 32
+
+	// csrfCookie binds the login form to the browser it was served to, csrfField carries the token of the form.
+	csrfCookie = "oidc_csrf"
+	csrfField  = "csrf_token"
+)
+
+var (
+	//go:embed login.html
+	templates embed.FS
+
+	// errLoginFailed is returned for incorrect input of users, as opposed to internal errors.
+	errLoginFailed = errors.New("oidc: login failed")
+
+	// authorizeParams are carried from the authorization request through the login form.
+	authorizeParams = []string{
+		"response_type",
+		"client_id",
+		"redirect_uri",
+		"scope",
+		"state",
+		"nonce",
+		"code_challenge",
+		"code_challenge_method",
+	}
+
+	// loginStrategies are the strategies users can sign in with through the login form, if mounted.
+	loginStrategies = []string{auth.StrategyCredentials, auth.StrategyPersonalNumber}
+)
+
+// loginPage is rendered by the login template.
+type loginPage struct {
+	Params     map[string]string
+	Strategies []string
+	Error      string
+	CSRF       string
+}
+
+// authorize serves the authorization endpoint. GET renders the login form, which posts
+// back to it, and a successful sign-in redirects to the client with an authorization code.
+func (x *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "authorize")
+	defer span.End()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "malformed request", http.StatusBadRequest)
+		return
+	}
+	clientID, redirectURI := r.Form.Get("client_id"), r.Form.Get("redirect_uri")
+	span.SetAttributes(attribute.String("client_id", clientID))
+
+	// Errors are only redirected to registered redirect URIs of known clients.
+	client, ok := x.clients[clientID]
+	if !ok || !slices.Contains(client.RedirectURIs, redirectURI) {
+		http.Error(w, "unknown client or redirect URI", http.StatusBadRequest)
+		return
+	}
+	state := r.Form.Get("state")
+
+	if r.Form.Get("response_type") != "code" {
+		x.redirect(w, r, redirectURI, state, url.Values{
+			"error":             {"unsupported_response_type"},
+			"error_description": {"only the code response type is supported"},
+		})
+		return
+	}
+	scopes := strings.Fields(r.Form.Get("scope"))
+	if !slices.Contains(scopes, ScopeOpenID) {
+		x.redirect(w, r, redirectURI, state, url.Values{
+			"error":             {"invalid_scope"},
+			"error_description": {"the openid scope is required"},
+		})
+		return
+	}
+	if r.Form.Get("code_challenge") == "" || r.Form.Get("code_challenge_method") != codeChallengeMethod {
+		x.redirect(w, r, redirectURI, state, url.Values{
+			"error":             {"invalid_request"},
+			"error_description": {"PKCE with the S256 method is required"},
+		})
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		x.renderLogin(w, r, http.StatusOK, "")
+		return
+	}
+	// Other sites must not post credentials to sign users in or to guess passwords from their browsers.
+	if !x.verifyCSRF(r) {
+		slog.InfoContext(ctx, "oidc: login without a valid csrf token")
+		x.renderLogin(w, r, http.StatusForbidden, "Your sign-in expired, please try again.")
+		return
+	}
+
+	// Strategies read the client IP of gRPC requests, failed logins are counted per IP.
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", r.RemoteAddr))
+	strategy, identifier, err := x.authenticate(ctx, r.Form)
+	if err != nil {
+		var locked *lockout.LockedError
+		if errors.As(err, &locked) {
+			slog.InfoContext(ctx, "oidc: login blocked", "err", err)
+			w.Header().Set("Retry-After", strconv.Itoa(int(locked.RetryAfter.Seconds())))
+			x.renderLogin(w, r, http.StatusTooManyRequests, fmt.Sprintf("Too many failed attempts, try again in %s.", locked.RetryAfter))
+			return
+		}
+		if errors.Is(err, errLoginFailed) {
+			slog.InfoContext(ctx, "oidc: login failed", "err", err)
+			x.renderLogin(w, r, http.StatusUnauthorized, "Incorrect sign-in details.")
+			return
+		}
+		slog.ErrorContext(ctx, "oidc: authenticating", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	g, err := x.policy.Decide(ctx, grant.Request{
+		Identifier: identifier,
+		Strategy:   strategy,
+		Scopes:     slices.DeleteFunc(scopes, func(s string) bool { return s == ScopeOpenID }),
+	})
+	if err != nil {
+		if errors.Is(err, grant.ErrScopeNotAllowed) || errors.Is(err, grant.ErrAudienceNotAllowed) {
+			x.redirect(w, r, redirectURI, state, url.Values{
+				"error":             {"invalid_scope"},
+				"error_description": {"the requested scopes are not allowed"},
+			})
+			return
+		}
+		slog.ErrorContext(ctx, "oidc: deciding grant", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	code, err := random.Token(codeSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "oidc: generating code", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	if err = x.codes.Save(ctx, hashCode(code), Code{
+		ClientID:      clientID,
+		RedirectURI:   redirectURI,
+		CodeChallenge: r.Form.Get("code_challenge"),
+		Nonce:         r.Form.Get("nonce"),
+		Strategy:      strategy,
+		Identifier:    identifier,
+		Scopes:        append([]string{ScopeOpenID}, g.Scopes...),
+		AuthTime:      now,
+		ExpiresAt:     now.Add(x.cfg.CodeTTL),
+	}); err != nil {
+		slog.ErrorContext(ctx, "oidc: saving code", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	x.redirect(w, r, redirectURI, state, url.Values{"code": {code}})
+}
+
+// authenticate signs a user in with the strategy chosen in the login form.
+// Returns an error wrapping [errLoginFailed] if the user provided incorrect input.
+func (x *Provider) authenticate(ctx context.Context, form url.Values) (gen.Strategy, any, error) {
+	name := form.Get("strategy")
+	if !slices.Contains(loginStrategies, name) {
+		return gen.Strategy_TypeNoStrategy, nil, fmt.Errorf("%w, strategy %q can not be used to sign in", errLoginFailed, name)
+	}
+	strategy, err := auth.StrategyFromString(name)
+	if err != nil {
+		return strategy, nil, err
+	}
+	if !x.logins.mounted(name) {
+		return strategy, nil, fmt.Errorf("%w, strategy %s is not mounted", errLoginFailed, name)
+	}
+
+	switch strategy {
+	case gen.Strategy_TypeCredentials:
+		out, err := x.logins.Credentials.Authenticate(ctx, credentials.Input{
+			Email:    form.Get("email"),
+			Password: form.Get("password"),
+		})
+		if err != nil {
+			return strategy, nil, loginError(err)
+		}
+		if err = x.verifyMFA(ctx, out.Email, form.Get("totp")); err != nil {
+			return strategy, nil, loginError(err)
+		}
+		return strategy, out.Email, nil
+	default:
+		n, err := strconv.ParseUint(form.Get("number"), 10, 64)
+		if err != nil {
+			return strategy, nil, fmt.Errorf("%w, parsing personal number, %w", errLoginFailed, err)
+		}
+		if n, err = x.logins.PersonalNumber.Authenticate(ctx, n); err != nil {
+			return strategy, nil, loginError(err)
+		}
+		return strategy, n, nil
+	}
+}
+
+// verifyMFA verifies the TOTP code of users that enrolled it. The password alone does not forget
+// the failed logins of these users, invalid codes count as failures, see [mfa.TOTP.UseLockout].
+func (x *Provider) verifyMFA(ctx context.Context, email, code string) error {
+	if x.mfa == nil {
+		return nil
+	}
+	enabled, err := x.mfa.Enabled(ctx, email)
+	if err != nil || !enabled {
+		return err
+	}
+	return x.mfa.Verify(ctx, email, code)
+}
+
+// loginError wraps errors caused by incorrect input with [errLoginFailed].
+func loginError(err error) error {
+	for _, target := range []error{
+		credentials.ErrUserNotFound,
+		credentials.ErrIncorrectPassword,
+		credentials.ErrUserNotVerified,
+		personalnumber.ErrNumberNotFound,
+		mfa.ErrInvalidCode,
+	} {
+		if errors.Is(err, target) {
+			return fmt.Errorf("%w, %w", errLoginFailed, err)
+		}
+	}
+	return err
+}
+
+func (x *Provider) renderLogin(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	csrf, err := x.csrfToken(w, r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "oidc: making csrf token", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	page := loginPage{Params: make(map[string]string, len(authorizeParams)), Error: msg, CSRF: csrf}
+	for _, p := range authorizeParams {
+		page.Params[p] = r.Form.Get(p)
+	}
+	for _, name := range loginStrategies {
+		if x.logins.mounted(name) {
+			page.Strategies = append(page.Strategies, name)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+	if err := x.login.Execute(w, page); err != nil {
+		slog.ErrorContext(r.Context(), "oidc: rendering login", "err", err)
+	}
+}
+
+// csrfToken returns the anti-CSRF token of the login form of the authorization request.
+// It is bound to the browser by a random cookie, which is set if the request does not carry one yet.
+func (x *Provider) csrfToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	if c, err := r.Cookie(csrfCookie); err == nil && c.Value != "" {
+		return x.csrfMAC(c.Value, r.Form), nil
+	}
+
+	nonce, err := random.Token(codeSize)
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    nonce,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(x.cfg.Issuer, "https://"),
+		SameSite: http.SameSiteStrictMode,
+	})
+	return x.csrfMAC(nonce, r.Form), nil
+}
+
+// verifyCSRF reports whether a posted login form carries the token of the cookie of the browser
+// for the authorization request it posts.
+func (x *Provider) verifyCSRF(r *http.Request) bool {
+	c, err := r.Cookie(csrfCookie)
+	if err != nil || c.Value == "" {
+		return false
+	}
+	return hmac.Equal([]byte(r.PostForm.Get(csrfField)), []byte(x.csrfMAC(c.Value, r.Form)))
+}
+
+// csrfMAC binds the cookie nonce to the parameters of the authorization request.
+func (x *Provider) csrfMAC(nonce string, form url.Values) string {
+	mac := hmac.New(sha256.New, []byte(x.cfg.SubjectKey))
+	fmt.Fprintf(mac, "csrf:%s", nonce)
+	for _, p := range authorizeParams {
+		fmt.Fprintf(mac, "\x00%s", form.Get(p))
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// redirect sends the user back to the client with the given parameters, the state and the issuer.
+func (x *Provider) redirect(w http.ResponseWriter, r *http.Request, redirectURI, state string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "malformed redirect URI", http.StatusBadRequest)
+		return
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	if state != "" {
+		q.Set("state", state)
+	}
+	// The issuer lets clients detect mix-up attacks, see RFC 9207.
+	q.Set("iss", x.cfg.Issuer)
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// verifyCodeChallenge reports whether the verifier hashes to the S256 challenge, see RFC 7636.
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
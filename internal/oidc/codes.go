@@ -0,0 +1,107 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/database"
+	"github.com/Salam4nder/identity/internal/database/authcode"
+	"github.com/Salam4nder/identity/proto/gen"
+)
+
+// ErrCodeNotFound is returned for authorization codes that do not exist, expired or were redeemed.
+var ErrCodeNotFound = errors.New("oidc: authorization code not found")
+
+type (
+	// Code is what an authorization code was issued for.
+	Code struct {
+		ClientID      string
+		RedirectURI   string
+		CodeChallenge string
+		Nonce         string
+		Strategy      gen.Strategy
+		Identifier    any
+		Scopes        []string
+		AuthTime      time.Time
+		ExpiresAt     time.Time
+	}
+
+	// CodeStore keeps authorization codes by their hash until they are redeemed.
+	CodeStore interface {
+		Save(ctx context.Context, hash string, c Code) error
+		// Take returns and deletes the unexpired code with the given hash, or [ErrCodeNotFound].
+		Take(ctx context.Context, hash string) (*Code, error)
+	}
+
+	// PostgresCodes is a [CodeStore] backed by the authorization_codes table.
+	PostgresCodes struct {
+		db *sql.DB
+	}
+)
+
+// NewPostgresCodes creates a new [PostgresCodes].
+func NewPostgresCodes(db *sql.DB) *PostgresCodes {
+	return &PostgresCodes{db: db}
+}
+
+func (x *PostgresCodes) Save(ctx context.Context, hash string, c Code) error {
+	if err := authcode.Insert(ctx, x.db, authcode.Entry{
+		CodeHash:      hash,
+		ClientID:      c.ClientID,
+		RedirectURI:   c.RedirectURI,
+		CodeChallenge: c.CodeChallenge,
+		Nonce:         c.Nonce,
+		Strategy:      int32(c.Strategy),
+		Identifier:    fmt.Sprint(c.Identifier),
+		Scopes:        c.Scopes,
+		AuthTime:      c.AuthTime,
+		ExpiresAt:     c.ExpiresAt,
+	}); err != nil {
+		return fmt.Errorf("oidc: inserting authorization code, %w", err)
+	}
+	return nil
+}
+
+func (x *PostgresCodes) Take(ctx context.Context, hash string) (*Code, error) {
+	e, err := authcode.Take(ctx, x.db, hash)
+	if err != nil {
+		if errors.As(err, &database.NotFoundError{}) || errors.As(err, &database.InputError{}) {
+			return nil, ErrCodeNotFound
+		}
+		return nil, fmt.Errorf("oidc: taking authorization code, %w", err)
+	}
+
+	strategy := gen.Strategy(e.Strategy)
+	r, ok := auth.Lookup(strategy)
+	if !ok {
+		return nil, fmt.Errorf("oidc: strategy %s is not registered", strategy)
+	}
+	identifier, err := r.Identifier.Parse(e.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: parsing identifier, %w", err)
+	}
+
+	return &Code{
+		ClientID:      e.ClientID,
+		RedirectURI:   e.RedirectURI,
+		CodeChallenge: e.CodeChallenge,
+		Nonce:         e.Nonce,
+		Strategy:      strategy,
+		Identifier:    identifier,
+		Scopes:        e.Scopes,
+		AuthTime:      e.AuthTime,
+		ExpiresAt:     e.ExpiresAt,
+	}, nil
+}
+
+// hashCode hashes an authorization code for storage. Codes are random, a plain hash suffices.
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,203 @@
+// Package oidc serves an OpenID Connect provider over HTTP. Users sign in with the mounted
+// [auth.Strategy] implementations and relying parties receive ID tokens through the
+// authorization code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/grant"
+	"github.com/Salam4nder/identity/internal/auth/strategy/credentials"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("oidc")
+
+const (
+	ScopeOpenID = "openid"
+	ScopeEmail  = "email"
+
+	// minSubjectKeyLen is the minimum length of the key deriving subjects.
+	minSubjectKeyLen = 32
+)
+
+type (
+	// Revocations reports whether an access token was revoked, see [revocation.Store].
+	Revocations interface {
+		Check(ctx context.Context, jti uuid.UUID) error
+	}
+
+	// MFA verifies the second factor of users that enrolled one, see [mfa.TOTP].
+	MFA interface {
+		Enabled(ctx context.Context, email string) (bool, error)
+		Verify(ctx context.Context, email, code string) error
+	}
+
+	// Logins are the strategies users sign in with on the login form, nil if they are not mounted.
+	Logins struct {
+		Credentials    auth.Strategy[credentials.Input, credentials.Output]
+		PersonalNumber auth.Strategy[uint64, uint64]
+	}
+
+	// Provider is an OpenID Connect provider.
+	Provider struct {
+		cfg     config.OIDC
+		clients map[string]config.OIDCClient
+		logins  Logins
+		tokens  token.Maker
+		signer  *token.JWTMaker
+		policy  grant.Policy
+		revoked Revocations
+		codes   CodeStore
+		mfa     MFA
+		login   *template.Template
+	}
+)
+
+// NewLogins returns the [Logins] among the mounted strategies.
+func NewLogins(strategies map[gen.Strategy]*auth.Mounted) Logins {
+	var l Logins
+	if m, ok := strategies[gen.Strategy_TypeCredentials]; ok {
+		l.Credentials, _ = auth.As[auth.Strategy[credentials.Input, credentials.Output]](m)
+	}
+	if m, ok := strategies[gen.Strategy_TypePersonalNumber]; ok {
+		l.PersonalNumber, _ = auth.As[auth.Strategy[uint64, uint64]](m)
+	}
+	return l
+}
+
+// mounted reports whether the login strategy with the given name is mounted.
+func (x Logins) mounted(name string) bool {
+	switch name {
+	case auth.StrategyCredentials:
+		return x.Credentials != nil
+	case auth.StrategyPersonalNumber:
+		return x.PersonalNumber != nil
+	}
+	return false
+}
+
+// New creates a new [Provider]. Access tokens are made by tokens and ID tokens are signed by signer,
+// whose keys are published as the JWKS of the provider.
+func New(
+	cfg config.OIDC,
+	logins Logins,
+	tokens token.Maker,
+	signer *token.JWTMaker,
+	policy grant.Policy,
+	revoked Revocations,
+	codes CodeStore,
+) (*Provider, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("oidc: issuer is empty")
+	}
+	if len(cfg.SubjectKey) < minSubjectKeyLen {
+		return nil, fmt.Errorf("oidc: subject key must be at least %d bytes", minSubjectKeyLen)
+	}
+	if cfg.CodeTTL <= 0 || cfg.IDTokenTTL <= 0 {
+		return nil, errors.New("oidc: code and ID token TTLs must be positive")
+	}
+
+	clients := make(map[string]config.OIDCClient, len(cfg.Clients))
+	for _, c := range cfg.Clients {
+		if c.ID == "" || len(c.RedirectURIs) == 0 {
+			return nil, errors.New("oidc: clients need an ID and redirect URIs")
+		}
+		if _, ok := clients[c.ID]; ok {
+			return nil, fmt.Errorf("oidc: duplicate client %q", c.ID)
+		}
+		clients[c.ID] = c
+	}
+
+	login, err := template.ParseFS(templates, "login.html")
+	if err != nil {
+		return nil, fmt.Errorf("oidc: parsing login template, %w", err)
+	}
+
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Provider{
+		cfg:     cfg,
+		clients: clients,
+		logins:  logins,
+		tokens:  tokens,
+		signer:  signer,
+		policy:  policy,
+		revoked: revoked,
+		codes:   codes,
+		login:   login,
+	}, nil
+}
+
+// UseMFA asks users that enrolled a second factor for it when signing in with credentials.
+func (x *Provider) UseMFA(m MFA) {
+	x.mfa = m
+}
+
+// Handler returns the handler serving the endpoints of the provider.
+func (x *Provider) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", x.discovery)
+	mux.Handle("GET /jwks", token.JWKSHandler(x.signer))
+	mux.HandleFunc("GET /authorize", x.authorize)
+	mux.HandleFunc("POST /authorize", x.authorize)
+	mux.HandleFunc("POST /token", x.token)
+	mux.HandleFunc("GET /userinfo", x.userinfo)
+	mux.HandleFunc("POST /userinfo", x.userinfo)
+	return mux
+}
+
+// discovery serves the provider metadata, see OpenID Connect Discovery 1.0.
+func (x *Provider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                x.cfg.Issuer,
+		"authorization_endpoint":                x.cfg.Issuer + "/authorize",
+		"token_endpoint":                        x.cfg.Issuer + "/token",
+		"userinfo_endpoint":                     x.cfg.Issuer + "/userinfo",
+		"jwks_uri":                              x.cfg.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{x.signer.Algorithm()},
+		"scopes_supported":                      []string{ScopeOpenID, ScopeEmail, "profile"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{codeChallengeMethod},
+		"claims_supported":                      []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "email", "email_verified"},
+	})
+}
+
+// subject derives the subject of a user from their strategy and identifier.
+// It is stable across sign-ins but does not reveal the identifier to clients.
+func (x *Provider) subject(strategy gen.Strategy, identifier any) string {
+	mac := hmac.New(sha256.New, []byte(x.cfg.SubjectKey))
+	fmt.Fprintf(mac, "%d:%v", strategy, identifier)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("oidc: encoding response", "err", err)
+	}
+}
+
+// writeError writes an OAuth 2.0 error response, see RFC 6749 section 5.2.
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
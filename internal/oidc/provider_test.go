@@ -0,0 +1,477 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ed25519"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/auth"
+	"github.com/Salam4nder/identity/internal/auth/grant"
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/auth/strategy/credentials"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+const redirectURI = "https://app.example.com/callback"
+
+// fakeCredentials accepts every sign-in, or rejects it with err.
+type fakeCredentials struct {
+	err error
+}
+
+func (x *fakeCredentials) Register(_ context.Context, in credentials.Input) (credentials.Output, error) {
+	return credentials.Output{Email: in.Email}, nil
+}
+
+func (x *fakeCredentials) Authenticate(_ context.Context, in credentials.Input) (credentials.Output, error) {
+	return credentials.Output{Email: in.Email}, x.err
+}
+
+// fakeNumbers accepts every personal number.
+type fakeNumbers struct{}
+
+func (fakeNumbers) Register(_ context.Context, n uint64) (uint64, error)     { return n, nil }
+func (fakeNumbers) Authenticate(_ context.Context, n uint64) (uint64, error) { return n, nil }
+
+type memoryCodes struct {
+	mu    sync.Mutex
+	codes map[string]Code
+}
+
+func (x *memoryCodes) Save(_ context.Context, hash string, c Code) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.codes[hash] = c
+	return nil
+}
+
+func (x *memoryCodes) Take(_ context.Context, hash string) (*Code, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	c, ok := x.codes[hash]
+	if !ok || time.Now().After(c.ExpiresAt) {
+		return nil, ErrCodeNotFound
+	}
+	delete(x.codes, hash)
+	return &c, nil
+}
+
+type noRevocations struct{}
+
+func (noRevocations) Check(context.Context, uuid.UUID) error { return nil }
+
+// relyingParty is a client of the provider under test.
+type relyingParty struct {
+	provider *oidc.Provider
+	oauth    oauth2.Config
+	// browser does not follow redirects, so that codes can be read from them, and keeps cookies.
+	browser *http.Client
+}
+
+func newRelyingParty(t *testing.T, logins Logins) *relyingParty {
+	t.Helper()
+
+	tokens, err := token.BootstrapPasetoMaker(time.Minute, time.Hour, []byte(random.String(32)))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err.Error())
+	}
+	signer, err := token.BootstrapJWTMaker(time.Minute, time.Hour, token.JWTAlgorithmEdDSA, []byte(random.String(ed25519.SeedSize)))
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err.Error())
+	}
+	policy, err := grant.NewConfigPolicy(config.Scopes{
+		Default: []string{"profile"},
+		Allowed: map[string][]string{
+			auth.StrategyCredentials:    {"profile", ScopeEmail},
+			auth.StrategyPersonalNumber: {"profile"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err.Error())
+	}
+
+	var handler http.Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	p, err := New(config.OIDC{
+		Issuer:     srv.URL,
+		CodeTTL:    time.Minute,
+		IDTokenTTL: time.Hour,
+		SubjectKey: random.String(32),
+		Clients: []config.OIDCClient{
+			{ID: "app", Secret: "app-secret", RedirectURIs: []string{redirectURI}},
+		},
+	}, logins, tokens, signer, policy, noRevocations{}, &memoryCodes{codes: make(map[string]Code)})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err.Error())
+	}
+	handler = p.Handler()
+
+	provider, err := oidc.NewProvider(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("expected discovery to succeed, got %s", err.Error())
+	}
+	return &relyingParty{
+		provider: provider,
+		oauth: oauth2.Config{
+			ClientID:     "app",
+			ClientSecret: "app-secret",
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  redirectURI,
+			Scopes:       []string{oidc.ScopeOpenID, ScopeEmail},
+		},
+		browser: newBrowser(t),
+	}
+}
+
+func newBrowser(t *testing.T) *http.Client {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{
+		Jar: jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+var csrfInput = regexp.MustCompile(`name="csrf_token" value="([^"]*)"`)
+
+// loginForm gets the login form of the authorization request with the browser and returns its anti-CSRF token,
+// empty if the request is rejected before the form is rendered.
+func (x *relyingParty) loginForm(t *testing.T, browser *http.Client, authURL string) string {
+	t.Helper()
+
+	resp, err := browser.Get(authURL)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err.Error())
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := csrfInput.FindSubmatch(body); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// signIn posts the login form of the authorization request and returns the redirect.
+func (x *relyingParty) signIn(t *testing.T, authURL string, form url.Values) *http.Response {
+	t.Helper()
+
+	form.Set(csrfField, x.loginForm(t, x.browser, authURL))
+	return x.post(t, x.browser, authURL, form)
+}
+
+// post posts the form with the parameters of the authorization request as it is.
+func (x *relyingParty) post(t *testing.T, browser *http.Client, authURL string, form url.Values) *http.Response {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range u.Query() {
+		form[k] = v
+	}
+	resp, err := browser.PostForm(x.oauth.Endpoint.AuthURL, form)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err.Error())
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// code signs in and returns the code of the redirect.
+func (x *relyingParty) code(t *testing.T, authURL string, form url.Values) string {
+	t.Helper()
+
+	resp := x.signIn(t, authURL, form)
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected redirect, got %d", resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(loc.String(), redirectURI) {
+		t.Fatalf("expected redirect to %s, got %s", redirectURI, loc)
+	}
+	if loc.Query().Get("state") != "state" {
+		t.Errorf("expected state to be returned, got %q", loc.Query().Get("state"))
+	}
+	code := loc.Query().Get("code")
+	if code == "" {
+		t.Fatalf("expected code, got error %q", loc.Query().Get("error"))
+	}
+	return code
+}
+
+func credentialsForm(email string) url.Values {
+	return url.Values{
+		"strategy": {auth.StrategyCredentials},
+		"email":    {email},
+		"password": {"password"},
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	rp := newRelyingParty(t, Logins{
+		Credentials:    &fakeCredentials{},
+		PersonalNumber: fakeNumbers{},
+	})
+	verifier := rp.provider.Verifier(&oidc.Config{ClientID: "app"})
+
+	t.Run("credentials", func(t *testing.T) {
+		email, nonce, pkce := random.Email(), random.String(16), oauth2.GenerateVerifier()
+		authURL := rp.oauth.AuthCodeURL("state", oauth2.S256ChallengeOption(pkce), oidc.Nonce(nonce))
+
+		tok, err := rp.oauth.Exchange(ctx, rp.code(t, authURL, credentialsForm(email)), oauth2.VerifierOption(pkce))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		raw, ok := tok.Extra("id_token").(string)
+		if !ok {
+			t.Fatal("expected an ID token")
+		}
+		idToken, err := verifier.Verify(ctx, raw)
+		if err != nil {
+			t.Fatalf("expected ID token to verify, got %s", err.Error())
+		}
+		if idToken.Nonce != nonce {
+			t.Errorf("expected nonce %s, got %s", nonce, idToken.Nonce)
+		}
+		var claims struct {
+			Email    string `json:"email"`
+			AuthTime int64  `json:"auth_time"`
+		}
+		if err = idToken.Claims(&claims); err != nil {
+			t.Fatal(err)
+		}
+		if claims.Email != email {
+			t.Errorf("expected email %s, got %s", email, claims.Email)
+		}
+		if claims.AuthTime == 0 {
+			t.Error("expected auth_time claim")
+		}
+		if idToken.Subject == "" || idToken.Subject == email {
+			t.Errorf("expected a subject not revealing the email, got %q", idToken.Subject)
+		}
+
+		info, err := rp.provider.UserInfo(ctx, oauth2.StaticTokenSource(tok))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		if info.Subject != idToken.Subject || info.Email != email {
+			t.Errorf("expected userinfo of %s, got %s with %s", idToken.Subject, info.Subject, info.Email)
+		}
+	})
+
+	t.Run("personal number", func(t *testing.T) {
+		// Personal numbers are not granted the email scope.
+		c := rp.oauth
+		c.Scopes = []string{oidc.ScopeOpenID, "profile"}
+		pkce := oauth2.GenerateVerifier()
+		authURL := c.AuthCodeURL("state", oauth2.S256ChallengeOption(pkce))
+		code := rp.code(t, authURL, url.Values{
+			"strategy": {auth.StrategyPersonalNumber},
+			"number":   {"9999999999999999"},
+		})
+
+		tok, err := c.Exchange(ctx, code, oauth2.VerifierOption(pkce))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		idToken, err := verifier.Verify(ctx, tok.Extra("id_token").(string))
+		if err != nil {
+			t.Fatalf("expected ID token to verify, got %s", err.Error())
+		}
+		var claims map[string]any
+		if err = idToken.Claims(&claims); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := claims["email"]; ok {
+			t.Error("expected no email claim")
+		}
+	})
+
+	t.Run("code can only be redeemed once", func(t *testing.T) {
+		pkce := oauth2.GenerateVerifier()
+		code := rp.code(t, rp.oauth.AuthCodeURL("state", oauth2.S256ChallengeOption(pkce)), credentialsForm(random.Email()))
+
+		if _, err := rp.oauth.Exchange(ctx, code, oauth2.VerifierOption(pkce)); err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		if _, err := rp.oauth.Exchange(ctx, code, oauth2.VerifierOption(pkce)); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		pkce := oauth2.GenerateVerifier()
+		code := rp.code(t, rp.oauth.AuthCodeURL("state", oauth2.S256ChallengeOption(pkce)), credentialsForm(random.Email()))
+
+		if _, err := rp.oauth.Exchange(ctx, code, oauth2.VerifierOption(oauth2.GenerateVerifier())); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("wrong client secret", func(t *testing.T) {
+		pkce := oauth2.GenerateVerifier()
+		code := rp.code(t, rp.oauth.AuthCodeURL("state", oauth2.S256ChallengeOption(pkce)), credentialsForm(random.Email()))
+
+		c := rp.oauth
+		c.ClientSecret = "wrong"
+		if _, err := c.Exchange(ctx, code, oauth2.VerifierOption(pkce)); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("PKCE is required", func(t *testing.T) {
+		resp := rp.signIn(t, rp.oauth.AuthCodeURL("state"), credentialsForm(random.Email()))
+		loc, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := loc.Query().Get("error"); got != "invalid_request" {
+			t.Errorf("expected invalid_request, got %q", got)
+		}
+	})
+
+	t.Run("unregistered redirect URI is not redirected to", func(t *testing.T) {
+		c := rp.oauth
+		c.RedirectURL = "https://attacker.example.com/callback"
+		authURL := c.AuthCodeURL("state", oauth2.S256ChallengeOption(oauth2.GenerateVerifier()))
+
+		resp := rp.signIn(t, authURL, credentialsForm(random.Email()))
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("userinfo rejects ID tokens", func(t *testing.T) {
+		pkce := oauth2.GenerateVerifier()
+		code := rp.code(t, rp.oauth.AuthCodeURL("state", oauth2.S256ChallengeOption(pkce)), credentialsForm(random.Email()))
+		tok, err := rp.oauth.Exchange(ctx, code, oauth2.VerifierOption(pkce))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+
+		idToken := &oauth2.Token{AccessToken: tok.Extra("id_token").(string), TokenType: "Bearer"}
+		if _, err = rp.provider.UserInfo(ctx, oauth2.StaticTokenSource(idToken)); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestAuthorizeLogin(t *testing.T) {
+	rp := newRelyingParty(t, Logins{
+		Credentials: &fakeCredentials{err: credentials.ErrIncorrectPassword},
+	})
+	authURL := rp.oauth.AuthCodeURL("state", oauth2.S256ChallengeOption(oauth2.GenerateVerifier()))
+
+	t.Run("form lists mounted strategies", func(t *testing.T) {
+		resp, err := rp.browser.Get(authURL)
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+	})
+
+	t.Run("incorrect password", func(t *testing.T) {
+		resp := rp.signIn(t, authURL, credentialsForm(random.Email()))
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("locked account", func(t *testing.T) {
+		locked := newRelyingParty(t, Logins{
+			Credentials: &fakeCredentials{err: &lockout.LockedError{Scope: lockout.ScopeAccount, RetryAfter: time.Minute}},
+		})
+		resp := locked.signIn(t, locked.oauth.AuthCodeURL("state", oauth2.S256ChallengeOption(oauth2.GenerateVerifier())), credentialsForm(random.Email()))
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("expected %d, got %d", http.StatusTooManyRequests, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Retry-After"); got != "60" {
+			t.Errorf("expected Retry-After 60, got %q", got)
+		}
+	})
+
+	t.Run("missing csrf token", func(t *testing.T) {
+		resp := rp.post(t, rp.browser, authURL, credentialsForm(random.Email()))
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected %d, got %d", http.StatusForbidden, resp.StatusCode)
+		}
+	})
+
+	t.Run("csrf token of another browser", func(t *testing.T) {
+		form := credentialsForm(random.Email())
+		form.Set(csrfField, rp.loginForm(t, newBrowser(t), authURL))
+		resp := rp.post(t, rp.browser, authURL, form)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected %d, got %d", http.StatusForbidden, resp.StatusCode)
+		}
+	})
+
+	t.Run("csrf token of another authorization request", func(t *testing.T) {
+		form := credentialsForm(random.Email())
+		form.Set(csrfField, rp.loginForm(t, rp.browser, rp.oauth.AuthCodeURL("other", oauth2.S256ChallengeOption(oauth2.GenerateVerifier()))))
+		resp := rp.post(t, rp.browser, authURL, form)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected %d, got %d", http.StatusForbidden, resp.StatusCode)
+		}
+	})
+
+	t.Run("unmounted strategy", func(t *testing.T) {
+		resp := rp.signIn(t, authURL, url.Values{
+			"strategy": {auth.StrategyPersonalNumber},
+			"number":   {"1"},
+		})
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	verifier := oauth2.GenerateVerifier()
+	challenge := oauth2.S256ChallengeFromVerifier(verifier)
+
+	if !verifyCodeChallenge(challenge, verifier) {
+		t.Error("expected verifier to match")
+	}
+	if verifyCodeChallenge(challenge, oauth2.GenerateVerifier()) {
+		t.Error("expected other verifier not to match")
+	}
+	if verifyCodeChallenge(verifier, verifier) {
+		t.Error("expected plain challenge not to match")
+	}
+}
@@ -0,0 +1,142 @@
+package oidc
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/internal/token"
+	"github.com/Salam4nder/identity/proto/gen"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var errInvalidClient = errors.New("oidc: invalid client")
+
+// token serves the token endpoint, redeeming authorization codes for an access token and an ID token.
+func (x *Provider) token(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "token")
+	defer span.End()
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "malformed request")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "only the authorization_code grant is supported")
+		return
+	}
+
+	client, err := x.authenticateClient(r)
+	if err != nil {
+		slog.InfoContext(ctx, "oidc: client authentication failed", "err", err)
+		if _, _, ok := r.BasicAuth(); ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+		}
+		writeError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	span.SetAttributes(attribute.String("client_id", client.ID))
+
+	c, err := x.codes.Take(ctx, hashCode(r.PostForm.Get("code")))
+	if err != nil {
+		if errors.Is(err, ErrCodeNotFound) {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "the code is invalid, expired or was redeemed")
+			return
+		}
+		slog.ErrorContext(ctx, "oidc: taking code", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	if c.ClientID != client.ID || c.RedirectURI != r.PostForm.Get("redirect_uri") {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "the code was issued to another client or redirect URI")
+		return
+	}
+	if !verifyCodeChallenge(c.CodeChallenge, r.PostForm.Get("code_verifier")) {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "the code verifier does not match the challenge")
+		return
+	}
+
+	accessToken, err := x.tokens.MakeAccessToken(c.Identifier, c.Strategy, token.Grant{Scopes: c.Scopes})
+	if err != nil {
+		slog.ErrorContext(ctx, "oidc: making access token", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+	idToken, err := x.idToken(client.ID, c)
+	if err != nil {
+		slog.ErrorContext(ctx, "oidc: making ID token", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": string(accessToken),
+		"token_type":   "Bearer",
+		"expires_in":   int(time.Until(x.tokens.AccessTokenExpiration()).Seconds()),
+		"id_token":     string(idToken),
+		"scope":        strings.Join(c.Scopes, " "),
+	})
+}
+
+// idToken makes the ID token of a redeemed code for the given client.
+func (x *Provider) idToken(clientID string, c *Code) (token.SafeString, error) {
+	now := time.Now()
+	claims := map[string]any{
+		"iss":       x.cfg.Issuer,
+		"sub":       x.subject(c.Strategy, c.Identifier),
+		"aud":       clientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(x.cfg.IDTokenTTL).Unix(),
+		"auth_time": c.AuthTime.Unix(),
+	}
+	if c.Nonce != "" {
+		claims["nonce"] = c.Nonce
+	}
+	for k, v := range emailClaims(c.Strategy, c.Identifier, c.Scopes) {
+		claims[k] = v
+	}
+	return x.signer.SignClaims(claims)
+}
+
+// authenticateClient authenticates the client of a token request with client_secret_basic
+// or client_secret_post. Public clients only send their ID.
+func (x *Provider) authenticateClient(r *http.Request) (config.OIDCClient, error) {
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		// Credentials are form encoded before they are put in the header, see RFC 6749 section 2.3.1.
+		var err error
+		if id, err = url.QueryUnescape(id); err != nil {
+			return config.OIDCClient{}, errInvalidClient
+		}
+		if secret, err = url.QueryUnescape(secret); err != nil {
+			return config.OIDCClient{}, errInvalidClient
+		}
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	client, found := x.clients[id]
+	if !found {
+		return config.OIDCClient{}, errInvalidClient
+	}
+	if client.Secret != "" && subtle.ConstantTimeCompare([]byte(client.Secret), []byte(secret)) != 1 {
+		return config.OIDCClient{}, errInvalidClient
+	}
+	return client, nil
+}
+
+// emailClaims returns the email claims of users that signed in with credentials and were granted the email scope.
+// Credentials can only be used to sign in once the email is verified.
+func emailClaims(strategy gen.Strategy, identifier any, scopes []string) map[string]any {
+	email, ok := identifier.(string)
+	if strategy != gen.Strategy_TypeCredentials || !ok || !slices.Contains(scopes, ScopeEmail) {
+		return nil
+	}
+	return map[string]any{"email": email, "email_verified": true}
+}
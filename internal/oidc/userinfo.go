@@ -0,0 +1,60 @@
+package oidc
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Salam4nder/identity/internal/auth/revocation"
+	"github.com/Salam4nder/identity/internal/token"
+)
+
+// userinfo serves the claims of the user an access token was issued to, see OpenID Connect Core 1.0 section 5.3.
+func (x *Provider) userinfo(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "userinfo")
+	defer span.End()
+
+	raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		writeError(w, http.StatusUnauthorized, "invalid_request", "a bearer token is required")
+		return
+	}
+
+	t, err := x.tokens.Parse(raw)
+	if err != nil {
+		invalidToken(w)
+		return
+	}
+	claims, err := token.ClaimsFromToken(t)
+	if err != nil || claims.TokenType != token.PasetoTokenTypeAccess {
+		invalidToken(w)
+		return
+	}
+	if err = x.revoked.Check(ctx, claims.ID); err != nil {
+		if errors.Is(err, revocation.ErrRevoked) {
+			invalidToken(w)
+			return
+		}
+		slog.ErrorContext(ctx, "oidc: checking revocation", "err", err)
+		writeError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	identifier, err := token.IdentifierFromToken(t)
+	if err != nil {
+		invalidToken(w)
+		return
+	}
+	resp := map[string]any{"sub": x.subject(claims.Strategy, identifier)}
+	for k, v := range emailClaims(claims.Strategy, identifier, claims.Scopes) {
+		resp[k] = v
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func invalidToken(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	writeError(w, http.StatusUnauthorized, "invalid_token", "the access token is invalid, expired or revoked")
+}
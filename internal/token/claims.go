@@ -0,0 +1,104 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/google/uuid"
+)
+
+// Claims are the decoded claims of a parsed token.
+type Claims struct {
+	ID        uuid.UUID
+	Subject   string
+	Strategy  gen.Strategy
+	TokenType string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	Scopes    []string
+	Audience  string
+}
+
+// HasScopes reports whether the claims carry every one of the given scopes.
+func (x Claims) HasScopes(scopes ...string) bool {
+	for _, s := range scopes {
+		if !slices.Contains(x.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// ClaimsFromToken decodes the claims of a parsed token. The subject is the identifier
+// of the strategy that issued the token, personal numbers are formatted in base 10.
+// Scopes and audience are empty if the token does not carry them.
+func ClaimsFromToken(t *paseto.Token) (Claims, error) {
+	var (
+		c   Claims
+		err error
+	)
+	if c.ID, err = IDFromToken(t); err != nil {
+		return Claims{}, err
+	}
+	if err = t.Get(PasetoStrategyKey, &c.Strategy); err != nil {
+		return Claims{}, fmt.Errorf("token: getting strategy, %w", err)
+	}
+	identifier, err := IdentifierFromToken(t)
+	if err != nil {
+		return Claims{}, err
+	}
+	c.Subject = fmt.Sprint(identifier)
+	if err = t.Get(PasetoTokenTypeKey, &c.TokenType); err != nil {
+		return Claims{}, fmt.Errorf("token: getting token type, %w", err)
+	}
+	if c.IssuedAt, err = t.GetIssuedAt(); err != nil {
+		return Claims{}, fmt.Errorf("token: getting issued at, %w", err)
+	}
+	if c.ExpiresAt, err = t.GetExpiration(); err != nil {
+		return Claims{}, fmt.Errorf("token: getting expiration, %w", err)
+	}
+
+	claims := t.Claims()
+	if _, ok := claims[PasetoScopeKey]; ok {
+		scope, err := t.GetString(PasetoScopeKey)
+		if err != nil {
+			return Claims{}, fmt.Errorf("token: getting scope, %w", err)
+		}
+		c.Scopes = strings.Fields(scope)
+	}
+	if _, ok := claims[PasetoAudienceKey]; ok {
+		if c.Audience, err = t.GetAudience(); err != nil {
+			return Claims{}, fmt.Errorf("token: getting audience, %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// IdentifierFromToken reads the identifier claim as the type it was set with,
+// a uint64 for numbers, such as personal numbers, and a string otherwise.
+func IdentifierFromToken(t *paseto.Token) (any, error) {
+	var raw json.RawMessage
+	if err := t.Get(PasetoIdentifierKey, &raw); err != nil {
+		return nil, fmt.Errorf("token: getting identifier, %w", err)
+	}
+
+	if len(raw) > 0 && raw[0] != '"' {
+		var n uint64
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return nil, fmt.Errorf("token: getting identifier, %w", err)
+		}
+		return n, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("token: getting identifier, %w", err)
+	}
+	return s, nil
+}
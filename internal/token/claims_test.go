@@ -0,0 +1,132 @@
+package token
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+)
+
+func TestClaimsFromToken(t *testing.T) {
+	b := bootstrap(t)
+
+	t.Run("credentials", func(t *testing.T) {
+		email := random.Email()
+		s, err := b.MakeAccessToken(email, gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		tt, err := b.Parse(string(s))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		c, err := ClaimsFromToken(tt)
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		if c.Subject != email {
+			t.Errorf("expected subject %s, got %s", email, c.Subject)
+		}
+		if c.Strategy != gen.Strategy_TypeCredentials {
+			t.Errorf("expected strategy %s, got %s", gen.Strategy_TypeCredentials, c.Strategy)
+		}
+		if c.TokenType != PasetoTokenTypeAccess {
+			t.Errorf("expected token type %s, got %s", PasetoTokenTypeAccess, c.TokenType)
+		}
+		if !c.IssuedAt.Before(c.ExpiresAt) {
+			t.Error("expected token to be issued before it expires")
+		}
+		if c.Scopes != nil || c.Audience != "" {
+			t.Error("expected no scopes and audience")
+		}
+	})
+	t.Run("personal number keeps precision", func(t *testing.T) {
+		var n uint64 = 9999999999999999
+		s, err := b.MakeRefreshToken(n, gen.Strategy_TypePersonalNumber, NewRefreshID(), Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		tt, err := b.Parse(string(s))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		c, err := ClaimsFromToken(tt)
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		if c.Subject != "9999999999999999" {
+			t.Errorf("expected subject 9999999999999999, got %s", c.Subject)
+		}
+		if c.TokenType != PasetoTokenTypeRefresh {
+			t.Errorf("expected token type %s, got %s", PasetoTokenTypeRefresh, c.TokenType)
+		}
+	})
+	t.Run("identifier of a strategy registered elsewhere", func(t *testing.T) {
+		strategy := gen.Strategy(100)
+		var n uint64 = 42
+		s, err := b.MakeAccessToken(n, strategy, Grant{})
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		tt, err := b.Parse(string(s))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		got, err := IdentifierFromToken(tt)
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		if got != n {
+			t.Errorf("expected identifier %d, got %v", n, got)
+		}
+		c, err := ClaimsFromToken(tt)
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		if c.Strategy != strategy {
+			t.Errorf("expected strategy %s, got %s", strategy, c.Strategy)
+		}
+	})
+	t.Run("scopes and audience", func(t *testing.T) {
+		s, err := b.MakeAccessToken(random.Email(), gen.Strategy_TypeMagicLink, Grant{
+			Scopes:   []string{"read", "write"},
+			Audience: "billing",
+			Extra:    map[string]any{"tenant": "acme"},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		tt, err := b.Parse(string(s))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+
+		c, err := ClaimsFromToken(tt)
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		if !slices.Equal(c.Scopes, []string{"read", "write"}) {
+			t.Errorf("expected scopes read and write, got %v", c.Scopes)
+		}
+		if !c.HasScopes("write") || !c.HasScopes() || c.HasScopes("read", "admin") {
+			t.Error("expected scopes to be checked")
+		}
+		if c.Audience != "billing" {
+			t.Errorf("expected audience billing, got %s", c.Audience)
+		}
+		if tenant, err := tt.GetString("tenant"); err != nil || tenant != "acme" {
+			t.Errorf("expected extra claim tenant acme, got %s", tenant)
+		}
+	})
+	t.Run("extra claims can not override reserved claims", func(t *testing.T) {
+		for _, k := range []string{PasetoIdentifierKey, PasetoScopeKey, "exp"} {
+			_, err := b.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{
+				Extra: map[string]any{k: "x"},
+			})
+			if err == nil {
+				t.Errorf("expected error for claim %s", k)
+			}
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package token
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+// reservedClaims can not be set by the extra claims of a [Grant].
+var reservedClaims = []string{
+	PasetoTokenTypeKey,
+	PasetoIdentifierKey,
+	PasetoStrategyKey,
+	PasetoTokenIDKey,
+	PasetoFamilyKey,
+	PasetoScopeKey,
+	PasetoAudienceKey,
+	"iss", "sub", "exp", "nbf", "iat",
+}
+
+// Grant is what a token is issued for on top of its identifier and strategy.
+// The zero value grants nothing, tokens then carry no scope and audience.
+type Grant struct {
+	Scopes   []string
+	Audience string
+	// Extra claims, they can not override any of the claims set by the service.
+	Extra map[string]any
+	// TTL overrides the lifetime of the token if positive, such as the token lifetime of a client.
+	TTL time.Duration
+}
+
+func (x Grant) set(t *paseto.Token) error {
+	if len(x.Scopes) > 0 {
+		t.SetString(PasetoScopeKey, strings.Join(x.Scopes, " "))
+	}
+	if x.Audience != "" {
+		t.SetAudience(x.Audience)
+	}
+	for k, v := range x.Extra {
+		if slices.Contains(reservedClaims, k) {
+			return fmt.Errorf("token: extra claim %s is reserved", k)
+		}
+		if err := t.Set(k, v); err != nil {
+			return fmt.Errorf("token: setting extra claim %s, %w", k, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// JWK is a public key in JSON Web Key format that verifies JWTs.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Modulus   string `json:"n,omitempty"`
+	Exponent  string `json:"e,omitempty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	// KeyID is the RFC 7638 thumbprint of the key.
+	KeyID string `json:"kid"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// newJWK serializes an Ed25519 or RSA public key into a [JWK].
+func newJWK(pub crypto.PublicKey, alg string) (JWK, error) {
+	var (
+		k JWK
+		// thumbprint holds the required members of the key in lexicographic order, see RFC 7638.
+		thumbprint string
+	)
+	switch p := pub.(type) {
+	case ed25519.PublicKey:
+		k = JWK{KeyType: "OKP", Curve: "Ed25519", X: base64.RawURLEncoding.EncodeToString(p)}
+		thumbprint = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s"}`, k.Curve, k.KeyType, k.X)
+	case *rsa.PublicKey:
+		k = JWK{
+			KeyType:  "RSA",
+			Modulus:  base64.RawURLEncoding.EncodeToString(p.N.Bytes()),
+			Exponent: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.E)).Bytes()),
+		}
+		thumbprint = fmt.Sprintf(`{"e":"%s","kty":"%s","n":"%s"}`, k.Exponent, k.KeyType, k.Modulus)
+	default:
+		return JWK{}, fmt.Errorf("token: unsupported public key %T", pub)
+	}
+
+	sum := sha256.Sum256([]byte(thumbprint))
+	k.KeyID = base64.RawURLEncoding.EncodeToString(sum[:])
+	k.Algorithm = alg
+	k.Use = "sig"
+	return k, nil
+}
+
+// JWKS returns the keys that verify the JWTs of the maker, sorted by key ID.
+func (x *JWTMaker) JWKS() JWKS {
+	signers := x.keys.list()
+	set := JWKS{Keys: make([]JWK, 0, len(signers))}
+	for _, s := range signers {
+		// Keys were serialized once already when they were rotated in.
+		k, _ := newJWK(s.Public(), x.method.Alg())
+		set.Keys = append(set.Keys, k)
+	}
+	slices.SortFunc(set.Keys, func(a, b JWK) int {
+		return strings.Compare(a.KeyID, b.KeyID)
+	})
+	return set
+}
+
+// JWKSHandler serves the [JWKS] of a [JWTMaker],
+// so that other services can verify tokens without calling this one.
+func JWKSHandler(m *JWTMaker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(m.JWKS())
+	})
+}
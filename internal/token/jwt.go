@@ -0,0 +1,297 @@
+package token
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/Salam4nder/identity/internal/config"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var _ Maker = (*JWTMaker)(nil)
+
+const (
+	JWTAlgorithmEdDSA = "EdDSA"
+	JWTAlgorithmRS256 = "RS256"
+
+	// rsaKeyBits is the minimum size of RSA keys.
+	rsaKeyBits = 2048
+)
+
+// timeClaims are RFC3339 strings in PASETO tokens and NumericDates in JWTs.
+var timeClaims = []string{"iat", "nbf", "exp"}
+
+// JWTMaker makes signed JWTs for consumers that do not understand PASETO.
+// Tokens carry the same claims as the ones of [PasetoMaker], except for times being NumericDates,
+// and can be verified by anyone holding its [JWTMaker.JWKS].
+type JWTMaker struct {
+	accessDur  time.Duration
+	refreshDur time.Duration
+	method     jwt.SigningMethod
+	keys       *keyring[crypto.Signer]
+	parser     *jwt.Parser
+}
+
+// BootstrapJWTMaker creates a [JWTMaker] signing with the given algorithm, either [JWTAlgorithmEdDSA]
+// with a 32 byte Ed25519 seed or [JWTAlgorithmRS256] with a PEM encoded RSA private key.
+// Tokens signed with any of the previous keys are accepted, and their keys published, until they retire.
+func BootstrapJWTMaker(
+	accessDur, refreshDur time.Duration,
+	algorithm string,
+	key []byte,
+	previous ...PreviousKey,
+) (*JWTMaker, error) {
+	var method jwt.SigningMethod
+	switch algorithm {
+	case JWTAlgorithmEdDSA:
+		method = jwt.SigningMethodEdDSA
+	case JWTAlgorithmRS256:
+		method = jwt.SigningMethodRS256
+	default:
+		return nil, fmt.Errorf("token: unsupported JWT algorithm %q", algorithm)
+	}
+
+	x := &JWTMaker{
+		accessDur:  accessDur,
+		refreshDur: refreshDur,
+		method:     method,
+		keys:       newKeyring[crypto.Signer](refreshDur),
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{method.Alg()}),
+			jwt.WithIssuer(config.ApplicationName),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+			jwt.WithJSONNumber(),
+		),
+	}
+	if err := addPrevious(x.keys, previous, x.decodeKey); err != nil {
+		return nil, err
+	}
+	if err := x.Rotate(key); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Rotate makes the given key active for signing tokens. The previously active key
+// keeps being accepted and published until the refresh token lifetime has passed.
+func (x *JWTMaker) Rotate(key []byte) error {
+	id, signer, err := x.decodeKey(key)
+	if err != nil {
+		return err
+	}
+	x.keys.rotate(id, signer)
+	return nil
+}
+
+// decodeKey returns the signer of an Ed25519 seed or a PEM encoded RSA key, depending on the algorithm.
+func (x *JWTMaker) decodeKey(key []byte) (string, crypto.Signer, error) {
+	var signer crypto.Signer
+	switch x.method {
+	case jwt.SigningMethodEdDSA:
+		if len(key) != ed25519.SeedSize {
+			return "", nil, fmt.Errorf("token: Ed25519 seed must be %d bytes, got %d", ed25519.SeedSize, len(key))
+		}
+		signer = ed25519.NewKeyFromSeed(key)
+	default:
+		k, err := parseRSAPrivateKey(key)
+		if err != nil {
+			return "", nil, err
+		}
+		signer = k
+	}
+
+	jwk, err := newJWK(signer.Public(), x.method.Alg())
+	if err != nil {
+		return "", nil, err
+	}
+	return jwk.KeyID, signer, nil
+}
+
+func (x *JWTMaker) MakeAccessToken(identifier any, strategy gen.Strategy, grant Grant) (SafeString, error) {
+	return x.makeToken(identifier, strategy, PasetoTokenTypeAccess, x.accessDur, grant)
+}
+
+// MakeRefreshToken makes a refresh token carrying the given [RefreshID].
+func (x *JWTMaker) MakeRefreshToken(
+	identifier any,
+	strategy gen.Strategy,
+	id RefreshID,
+	grant Grant,
+) (SafeString, error) {
+	token, err := newPasetoToken(identifier, strategy, PasetoTokenTypeRefresh, x.refreshDur, grant)
+	if err != nil {
+		return "", err
+	}
+	if err = id.set(token); err != nil {
+		return "", err
+	}
+	return x.sign(token)
+}
+
+// MakeMFAToken makes a short-lived token proving that the first factor succeeded.
+// It can only be exchanged for access and refresh tokens once the second factor is verified.
+func (x *JWTMaker) MakeMFAToken(identifier any, strategy gen.Strategy, grant Grant) (SafeString, error) {
+	return x.makeToken(identifier, strategy, PasetoTokenTypeMFAPending, MFATokenDuration, grant)
+}
+
+func (x *JWTMaker) makeToken(
+	identifier any,
+	strategy gen.Strategy,
+	tokenType string,
+	dur time.Duration,
+	grant Grant,
+) (SafeString, error) {
+	token, err := newPasetoToken(identifier, strategy, tokenType, dur, grant)
+	if err != nil {
+		return "", err
+	}
+	return x.sign(token)
+}
+
+// sign signs the claims of the token with the active key and puts its ID in the header.
+func (x *JWTMaker) sign(token *paseto.Token) (SafeString, error) {
+	claims, err := decodeClaims(token.ClaimsJSON())
+	if err != nil {
+		return "", err
+	}
+	for _, k := range timeClaims {
+		s, ok := claims[k].(string)
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return "", fmt.Errorf("token: parsing %s claim, %w", k, err)
+		}
+		claims[k] = t.Unix()
+	}
+
+	return x.SignClaims(claims)
+}
+
+// SignClaims signs the given claims as they are with the active key, such as for OpenID Connect ID tokens.
+// The claims of access, refresh and MFA tokens are set by the make methods instead.
+func (x *JWTMaker) SignClaims(claims map[string]any) (SafeString, error) {
+	id, k := x.keys.current()
+	t := jwt.NewWithClaims(x.method, jwt.MapClaims(claims))
+	t.Header["kid"] = id
+	s, err := t.SignedString(k)
+	if err != nil {
+		return "", fmt.Errorf("token: signing JWT, %w", err)
+	}
+	return fromString(s), nil
+}
+
+// Algorithm returns the JWS algorithm tokens are signed with.
+func (x *JWTMaker) Algorithm() string {
+	return x.method.Alg()
+}
+
+// Parse will parse a JWT and return it if it is valid. Its claims are returned
+// as a PASETO token, so that callers read them the same way as those of [PasetoMaker].
+func (x *JWTMaker) Parse(s string) (*paseto.Token, error) {
+	t, err := x.parser.Parse(s, func(t *jwt.Token) (any, error) {
+		id, _ := t.Header["kid"].(string)
+		k, err := x.keys.get(id)
+		if err != nil {
+			return nil, err
+		}
+		return k.Public(), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("token: parsing token, %w", err)
+	}
+	claims, ok := t.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("token: unexpected JWT claims")
+	}
+
+	for _, k := range timeClaims {
+		n, ok := claims[k].(json.Number)
+		if !ok {
+			continue
+		}
+		sec, err := n.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("token: parsing %s claim, %w", k, err)
+		}
+		claims[k] = time.Unix(sec, 0).Format(time.RFC3339)
+	}
+
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("token: marshaling claims, %w", err)
+	}
+	parsed, err := paseto.NewTokenFromClaimsJSON(b, nil)
+	if err != nil {
+		return nil, fmt.Errorf("token: reading claims, %w", err)
+	}
+	return parsed, nil
+}
+
+func (x *JWTMaker) RefreshTokenExpiration() time.Time {
+	return time.Now().Add(x.refreshDur)
+}
+
+func (x *JWTMaker) AccessTokenExpiration() time.Time {
+	return time.Now().Add(x.accessDur)
+}
+
+// PublicKeys returns no keys, JWTs are verified with the keys of [JWTMaker.JWKS] instead.
+func (x *JWTMaker) PublicKeys() []PublicKey {
+	return nil
+}
+
+// decodeClaims decodes JSON claims keeping numbers as they are,
+// personal numbers do not fit in a float64.
+func decodeClaims(b []byte) (map[string]any, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var claims map[string]any
+	if err := d.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("token: decoding claims, %w", err)
+	}
+	return claims, nil
+}
+
+// parseRSAPrivateKey parses a PEM encoded PKCS #8 or PKCS #1 RSA private key.
+func parseRSAPrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("token: RSA private key is not PEM encoded")
+	}
+
+	var k *rsa.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("token: parsing RSA private key, %w", err)
+		}
+		k = key
+	default:
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("token: parsing RSA private key, %w", err)
+		}
+		var ok bool
+		if k, ok = key.(*rsa.PrivateKey); !ok {
+			return nil, fmt.Errorf("token: expected RSA private key, got %T", key)
+		}
+	}
+	if k.N.BitLen() < rsaKeyBits {
+		return nil, fmt.Errorf("token: RSA key must be at least %d bits, got %d", rsaKeyBits, k.N.BitLen())
+	}
+	return k, nil
+}
@@ -0,0 +1,197 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func rsaKey(t *testing.T, bits int) []byte {
+	t.Helper()
+
+	k, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)})
+}
+
+// verifyWithJWK verifies a JWT with a key of the set, the way a consumer would.
+func verifyWithJWK(t *testing.T, s string, set JWKS) {
+	t.Helper()
+
+	_, err := jwt.Parse(s, func(tt *jwt.Token) (any, error) {
+		for _, k := range set.Keys {
+			if k.KeyID != tt.Header["kid"] {
+				continue
+			}
+			if k.KeyType == "OKP" {
+				x, err := base64.RawURLEncoding.DecodeString(k.X)
+				return ed25519.PublicKey(x), err
+			}
+			n, err := base64.RawURLEncoding.DecodeString(k.Modulus)
+			if err != nil {
+				return nil, err
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.Exponent)
+			if err != nil {
+				return nil, err
+			}
+			return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+		}
+		return nil, ErrUnknownKey
+	})
+	if err != nil {
+		t.Fatalf("expected token to verify with the JWKS, got %s", err.Error())
+	}
+}
+
+func TestJWTMaker(t *testing.T) {
+	for _, tc := range []struct {
+		algorithm string
+		key, next []byte
+	}{
+		{
+			algorithm: JWTAlgorithmEdDSA,
+			key:       []byte(random.String(ed25519.SeedSize)),
+			next:      []byte(random.String(ed25519.SeedSize)),
+		},
+		{algorithm: JWTAlgorithmRS256, key: rsaKey(t, rsaKeyBits), next: rsaKey(t, rsaKeyBits)},
+	} {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			m, err := BootstrapJWTMaker(time.Minute, time.Hour, tc.algorithm, tc.key)
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err.Error())
+			}
+
+			t.Run("access token has the PASETO claim layout", func(t *testing.T) {
+				email := random.Email()
+				s, err := m.MakeAccessToken(email, gen.Strategy_TypeCredentials, Grant{
+					Scopes:   []string{"profile"},
+					Audience: "billing",
+				})
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err.Error())
+				}
+				tt, err := m.Parse(string(s))
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err.Error())
+				}
+				c, err := ClaimsFromToken(tt)
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err.Error())
+				}
+				if c.Subject != email || c.Strategy != gen.Strategy_TypeCredentials {
+					t.Errorf("expected subject %s with credentials, got %s with %s", email, c.Subject, c.Strategy)
+				}
+				if c.TokenType != PasetoTokenTypeAccess {
+					t.Errorf("expected token type %s, got %s", PasetoTokenTypeAccess, c.TokenType)
+				}
+				if !slices.Equal(c.Scopes, []string{"profile"}) || c.Audience != "billing" {
+					t.Errorf("expected scope profile for billing, got %v for %s", c.Scopes, c.Audience)
+				}
+				if time.Until(c.ExpiresAt) <= 0 || time.Until(c.ExpiresAt) > time.Minute {
+					t.Errorf("expected token to expire within a minute, got %s", c.ExpiresAt)
+				}
+				verifyWithJWK(t, string(s), m.JWKS())
+			})
+			t.Run("refresh token keeps personal number and refresh ID", func(t *testing.T) {
+				var n uint64 = 9999999999999999
+				id := NewRefreshID()
+				s, err := m.MakeRefreshToken(n, gen.Strategy_TypePersonalNumber, id, Grant{})
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err.Error())
+				}
+				tt, err := m.Parse(string(s))
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err.Error())
+				}
+				var got uint64
+				if err = tt.Get(PasetoIdentifierKey, &got); err != nil || got != n {
+					t.Errorf("expected identifier %d, got %d", n, got)
+				}
+				gotID, err := RefreshIDFromToken(tt)
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err.Error())
+				}
+				if gotID != id {
+					t.Errorf("expected %v, got %v", id, gotID)
+				}
+			})
+			t.Run("tampered token", func(t *testing.T) {
+				s, err := m.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+				if err != nil {
+					t.Fatal("expected no error")
+				}
+				b := []byte(s)
+				b[len(b)-5] ^= 1
+				if _, err = m.Parse(string(b)); err == nil {
+					t.Error("expected error")
+				}
+			})
+			t.Run("rotation keeps previous key", func(t *testing.T) {
+				old, err := m.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+				if err != nil {
+					t.Fatal("expected no error")
+				}
+				if err = m.Rotate(tc.next); err != nil {
+					t.Fatalf("expected no error, got %s", err.Error())
+				}
+				if _, err = m.Parse(string(old)); err != nil {
+					t.Errorf("expected no error, got %s", err.Error())
+				}
+				if n := len(m.JWKS().Keys); n != 2 {
+					t.Errorf("expected 2 keys, got %d", n)
+				}
+			})
+		})
+	}
+}
+
+func TestJWTMakerRejects(t *testing.T) {
+	seed := []byte(random.String(ed25519.SeedSize))
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		if _, err := BootstrapJWTMaker(time.Minute, time.Hour, "HS256", seed); err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("short seed", func(t *testing.T) {
+		if _, err := BootstrapJWTMaker(time.Minute, time.Hour, JWTAlgorithmEdDSA, seed[:31]); err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("small RSA key", func(t *testing.T) {
+		if _, err := BootstrapJWTMaker(time.Minute, time.Hour, JWTAlgorithmRS256, rsaKey(t, 1024)); err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("unsigned token", func(t *testing.T) {
+		m, err := BootstrapJWTMaker(time.Minute, time.Hour, JWTAlgorithmEdDSA, seed)
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		s, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+			"iss": "identity",
+			"exp": time.Now().Add(time.Minute).Unix(),
+			"iat": time.Now().Unix(),
+		}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = m.Parse(s); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
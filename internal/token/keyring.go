@@ -0,0 +1,148 @@
+package token
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KeySize is the size of symmetric keys and Ed25519 seeds.
+const KeySize = 32
+
+var (
+	ErrUnknownKey = errors.New("token: unknown or retired key")
+	ErrNoRetireAt = errors.New("token: previous key has no retirement time")
+)
+
+// PreviousKey is a key that tokens are no longer issued with.
+// Tokens issued with it are accepted until RetireAt, which does not move when the service restarts.
+type PreviousKey struct {
+	Key      []byte
+	RetireAt time.Time
+}
+
+// footer is the PASETO footer carrying the ID of the key a token was issued with.
+type footer struct {
+	KeyID string `json:"kid"`
+}
+
+func marshalFooter(keyID string) []byte {
+	// A struct with a single string field can not fail to marshal.
+	b, _ := json.Marshal(footer{KeyID: keyID})
+	return b
+}
+
+func unmarshalFooter(b []byte) (string, error) {
+	if len(b) == 0 {
+		return "", nil
+	}
+	var f footer
+	if err := json.Unmarshal(b, &f); err != nil {
+		return "", fmt.Errorf("token: unmarshaling footer, %w", err)
+	}
+	return f.KeyID, nil
+}
+
+type ringKey[K any] struct {
+	key K
+	// retireAt is zero until the key stops being active.
+	retireAt time.Time
+}
+
+// keyring holds an active key to issue tokens with and older keys
+// that are accepted for parsing until they retire.
+type keyring[K any] struct {
+	mu          sync.RWMutex
+	active      string
+	keys        map[string]*ringKey[K]
+	retireAfter time.Duration
+}
+
+func newKeyring[K any](retireAfter time.Duration) *keyring[K] {
+	return &keyring[K]{keys: make(map[string]*ringKey[K]), retireAfter: retireAfter}
+}
+
+// rotate makes the given key active. The previously active key
+// is retired once every token it issued has expired.
+func (x *keyring[K]) rotate(id string, key K) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	now := time.Now()
+	if prev, ok := x.keys[x.active]; ok && x.active != id {
+		prev.retireAt = now.Add(x.retireAfter)
+	}
+	for kid, k := range x.keys {
+		if !k.retireAt.IsZero() && now.After(k.retireAt) {
+			delete(x.keys, kid)
+		}
+	}
+	x.keys[id] = &ringKey[K]{key: key}
+	x.active = id
+}
+
+// retire adds a key that is not active and is accepted until retireAt.
+func (x *keyring[K]) retire(id string, key K, retireAt time.Time) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.keys[id] = &ringKey[K]{key: key, retireAt: retireAt}
+}
+
+// addPrevious adds the previous keys decoded with decode to the keyring.
+// Returns [ErrNoRetireAt] if any of them has no retirement time.
+func addPrevious[K any](ring *keyring[K], previous []PreviousKey, decode func([]byte) (string, K, error)) error {
+	for _, p := range previous {
+		if p.RetireAt.IsZero() {
+			return ErrNoRetireAt
+		}
+		id, k, err := decode(p.Key)
+		if err != nil {
+			return err
+		}
+		ring.retire(id, k, p.RetireAt)
+	}
+	return nil
+}
+
+// current returns the active key and its ID.
+func (x *keyring[K]) current() (string, K) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return x.active, x.keys[x.active].key
+}
+
+// get returns the key with the given ID unless it is retired.
+// An empty ID returns the active key, for tokens issued without a footer.
+func (x *keyring[K]) get(id string) (K, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	if id == "" {
+		id = x.active
+	}
+	k, ok := x.keys[id]
+	if !ok || (!k.retireAt.IsZero() && time.Now().After(k.retireAt)) {
+		var zero K
+		return zero, ErrUnknownKey
+	}
+	return k.key, nil
+}
+
+// list returns all keys that are not retired.
+func (x *keyring[K]) list() []K {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]K, 0, len(x.keys))
+	for _, k := range x.keys {
+		if k.retireAt.IsZero() || !now.After(k.retireAt) {
+			keys = append(keys, k.key)
+		}
+	}
+	return keys
+}
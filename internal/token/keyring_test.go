@@ -0,0 +1,177 @@
+package token
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+)
+
+func TestRotate(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{'o'}, KeySize)
+	newKey := bytes.Repeat([]byte{'n'}, KeySize)
+
+	t.Run("previous key is accepted", func(t *testing.T) {
+		m, err := BootstrapPasetoMaker(time.Minute, time.Hour, oldKey)
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		s, err := m.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+
+		if err = m.Rotate(newKey); err != nil {
+			t.Fatal("expected no error")
+		}
+		if _, err = m.Parse(string(s)); err != nil {
+			t.Errorf("expected no error, got %s", err.Error())
+		}
+
+		s2, err := m.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		tt, err := m.Parse(string(s2))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		id, err := unmarshalFooter(tt.Footer())
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		activeID, _ := m.keys.current()
+		if id != activeID {
+			t.Errorf("expected key ID %s in footer, got %s", activeID, id)
+		}
+	})
+
+	t.Run("bootstrapped previous key is accepted", func(t *testing.T) {
+		old, err := BootstrapPasetoMaker(time.Minute, time.Hour, oldKey)
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		s, err := old.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+
+		m, err := BootstrapPasetoMaker(time.Minute, time.Hour, newKey, PreviousKey{
+			Key:      oldKey,
+			RetireAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		if _, err = m.Parse(string(s)); err != nil {
+			t.Errorf("expected no error, got %s", err.Error())
+		}
+	})
+
+	t.Run("bootstrapped previous key retires at its time", func(t *testing.T) {
+		old, err := BootstrapPasetoMaker(time.Minute, time.Hour, oldKey)
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		s, err := old.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+
+		// Restarting does not extend the lifetime of a retired key.
+		m, err := BootstrapPasetoMaker(time.Minute, time.Hour, newKey, PreviousKey{
+			Key:      oldKey,
+			RetireAt: time.Now().Add(-time.Second),
+		})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		if _, err = m.Parse(string(s)); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("expected %v, got %v", ErrUnknownKey, err)
+		}
+	})
+
+	t.Run("previous key without retirement time returns error", func(t *testing.T) {
+		_, err := BootstrapPasetoMaker(time.Minute, time.Hour, newKey, PreviousKey{Key: oldKey})
+		if !errors.Is(err, ErrNoRetireAt) {
+			t.Errorf("expected %v, got %v", ErrNoRetireAt, err)
+		}
+	})
+
+	t.Run("retired key returns error", func(t *testing.T) {
+		m, err := BootstrapPasetoMaker(time.Minute, time.Millisecond, oldKey)
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		s, err := m.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+
+		if err = m.Rotate(newKey); err != nil {
+			t.Fatal("expected no error")
+		}
+		time.Sleep(5 * time.Millisecond)
+		if _, err = m.Parse(string(s)); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("expected %v, got %v", ErrUnknownKey, err)
+		}
+	})
+
+	t.Run("unknown key returns error", func(t *testing.T) {
+		other, err := BootstrapPasetoMaker(time.Minute, time.Hour, oldKey)
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		s, err := other.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+
+		m, err := BootstrapPasetoMaker(time.Minute, time.Hour, newKey)
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		if _, err = m.Parse(string(s)); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("expected %v, got %v", ErrUnknownKey, err)
+		}
+	})
+
+	t.Run("token without footer uses active key", func(t *testing.T) {
+		m, err := BootstrapPasetoMaker(time.Minute, time.Hour, oldKey)
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		tt, err := newPasetoToken(random.Email(), gen.Strategy_TypeCredentials, PasetoTokenTypeAccess, time.Minute, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		_, k := m.keys.current()
+		if _, err = m.Parse(tt.V4Encrypt(k, nil)); err != nil {
+			t.Errorf("expected no error, got %s", err.Error())
+		}
+	})
+
+	t.Run("public keys overlap until retired", func(t *testing.T) {
+		m, err := BootstrapPasetoPublicMaker(time.Minute, time.Hour, oldKey)
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		s, err := m.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+
+		if err = m.Rotate(newKey); err != nil {
+			t.Fatal("expected no error")
+		}
+		if len(m.PublicKeys()) != 2 {
+			t.Errorf("expected 2 published keys, got %d", len(m.PublicKeys()))
+		}
+		if _, err = m.Parse(string(s)); err != nil {
+			t.Errorf("expected no error, got %s", err.Error())
+		}
+	})
+}
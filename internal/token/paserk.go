@@ -0,0 +1,62 @@
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"aidanwoods.dev/go-paseto"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	paserkLocalHeader  = "k4.local."
+	paserkLIDHeader    = "k4.lid."
+	paserkPublicHeader = "k4.public."
+	paserkPIDHeader    = "k4.pid."
+	// paserkIDSize is the size of BLAKE2b digests in PASERK key IDs.
+	paserkIDSize = 33
+)
+
+// PublicKey is a public key in PASERK format that verifies v4.public tokens.
+type PublicKey struct {
+	// ID is the k4.pid PASERK identifying the key.
+	ID string `json:"id"`
+	// PASERK is the k4.public PASERK of the key.
+	PASERK string `json:"paserk"`
+}
+
+// NewPublicKey serializes a v4 public key into a [PublicKey].
+func NewPublicKey(k paseto.V4AsymmetricPublicKey) PublicKey {
+	p := paserkPublicHeader + base64.RawURLEncoding.EncodeToString(k.ExportBytes())
+	return PublicKey{ID: paserkID(paserkPIDHeader, p), PASERK: p}
+}
+
+// localKeyID returns the k4.lid key ID of a symmetric key, without revealing the key.
+func localKeyID(k paseto.V4SymmetricKey) string {
+	return paserkID(paserkLIDHeader, paserkLocalHeader+base64.RawURLEncoding.EncodeToString(k.ExportBytes()))
+}
+
+// paserkID derives a key ID such as k4.pid or k4.lid from the PASERK of a key.
+func paserkID(header, paserk string) string {
+	// The digest size is valid, New only fails on bad sizes or keys.
+	h, _ := blake2b.New(paserkIDSize, nil)
+	h.Write([]byte(header))
+	h.Write([]byte(paserk))
+	return header + base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// PublicKeysHandler serves the public keys of a [Maker] as JSON,
+// so that other services can verify tokens without calling this one.
+func PublicKeysHandler(m Maker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		keys := m.PublicKeys()
+		if keys == nil {
+			keys = []PublicKey{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Keys []PublicKey `json:"keys"`
+		}{Keys: keys})
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"aidanwoods.dev/go-paseto"
 	"github.com/Salam4nder/identity/internal/config"
 	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/google/uuid"
 )
 
 var _ Maker = (*PasetoMaker)(nil)
@@ -17,124 +18,135 @@ const (
 	PasetoTokenTypeKey  = "token_type"
 	PasetoIdentifierKey = "token_identifier"
 	PasetoStrategyKey   = "token_strategy"
+	PasetoTokenIDKey    = "jti"
+	PasetoFamilyKey     = "token_family"
+	PasetoScopeKey      = "scope"
+	PasetoAudienceKey   = "aud"
 
 	// nolint:gosec
 	PasetoTokenTypeAccess = "token_type_access"
 	// nolint:gosec
 	PasetoTokenTypeRefresh = "token_type_refresh"
+	// nolint:gosec
+	PasetoTokenTypeMFAPending = "token_type_mfa_pending"
+
+	// MFATokenDuration is how long a user has to complete the second factor.
+	MFATokenDuration = 5 * time.Minute
 )
 
 // PasetoMaker makes PASETO tokens.
 type PasetoMaker struct {
-	accessDur    time.Duration
-	refreshDur   time.Duration
-	symmetricKey paseto.V4SymmetricKey
-	parser       *paseto.Parser
+	accessDur  time.Duration
+	refreshDur time.Duration
+	keys       *keyring[paseto.V4SymmetricKey]
+	parser     *paseto.Parser
 }
 
+// BootstrapPasetoMaker creates a [PasetoMaker] issuing tokens with symmetricKey.
+// Tokens issued with any of the previous keys are accepted until they retire.
 func BootstrapPasetoMaker(
 	accessDur, refreshDur time.Duration,
 	symmetricKey []byte,
+	previous ...PreviousKey,
 ) (*PasetoMaker, error) {
-	k, err := paseto.V4SymmetricKeyFromBytes(symmetricKey)
+	x := &PasetoMaker{
+		accessDur:  accessDur,
+		refreshDur: refreshDur,
+		keys:       newKeyring[paseto.V4SymmetricKey](refreshDur),
+		parser:     newPasetoParser(),
+	}
+	if err := addPrevious(x.keys, previous, decodeSymmetricKey); err != nil {
+		return nil, err
+	}
+	if err := x.Rotate(symmetricKey); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Rotate makes the given key active for issuing tokens. The previously active key
+// keeps being accepted for parsing until the refresh token lifetime has passed.
+func (x *PasetoMaker) Rotate(symmetricKey []byte) error {
+	id, k, err := decodeSymmetricKey(symmetricKey)
 	if err != nil {
-		return nil, fmt.Errorf("token: creating symmetric key, %w", err)
+		return err
 	}
+	x.keys.rotate(id, k)
+	return nil
+}
 
-	p := paseto.MakeParser([]paseto.Rule{
-		paseto.IssuedBy(config.ApplicationName),
-		paseto.NotExpired(),
-		paseto.ValidAt(time.Now()),
-	},
-	)
+func decodeSymmetricKey(b []byte) (string, paseto.V4SymmetricKey, error) {
+	k, err := paseto.V4SymmetricKeyFromBytes(b)
+	if err != nil {
+		return "", k, fmt.Errorf("token: creating symmetric key, %w", err)
+	}
+	return localKeyID(k), k, nil
+}
 
-	return &PasetoMaker{
-		accessDur:    accessDur,
-		refreshDur:   refreshDur,
-		symmetricKey: k,
-		parser:       &p,
-	}, nil
+func (x *PasetoMaker) MakeAccessToken(identifier any, strategy gen.Strategy, grant Grant) (SafeString, error) {
+	return x.makeToken(identifier, strategy, PasetoTokenTypeAccess, x.accessDur, grant)
 }
 
-func (x *PasetoMaker) MakeAccessToken(identifier any, strategy gen.Strategy) (SafeString, error) {
-	token := paseto.NewToken()
-	switch strategy {
-	case gen.Strategy_TypeCredentials:
-		s, ok := identifier.(string)
-		if !ok {
-			return "", fmt.Errorf("token: expected identifier to be string, got %T", identifier)
-		}
-		if err := token.Set(PasetoStrategyKey, gen.Strategy_TypeCredentials); err != nil {
-			return "", err
-		}
-		if err := token.Set(PasetoIdentifierKey, s); err != nil {
-			return "", err
-		}
-	case gen.Strategy_TypePersonalNumber:
-		d, ok := identifier.(uint64)
-		if !ok {
-			return "", fmt.Errorf("token: expected identifier to be uint64, got %T", identifier)
-		}
-		if err := token.Set(PasetoStrategyKey, gen.Strategy_TypePersonalNumber); err != nil {
-			return "", err
-		}
-		if err := token.Set(PasetoIdentifierKey, d); err != nil {
-			return "", err
-		}
-	default:
-		return "", errors.New("unsupported strategy")
+// MakeRefreshToken makes a refresh token carrying the given [RefreshID].
+func (x *PasetoMaker) MakeRefreshToken(
+	identifier any,
+	strategy gen.Strategy,
+	id RefreshID,
+	grant Grant,
+) (SafeString, error) {
+	token, err := newPasetoToken(identifier, strategy, PasetoTokenTypeRefresh, x.refreshDur, grant)
+	if err != nil {
+		return "", err
 	}
-	if err := token.Set(PasetoTokenTypeKey, PasetoTokenTypeAccess); err != nil {
+	if err = id.set(token); err != nil {
 		return "", err
 	}
-	token.SetIssuer(config.ApplicationName)
-	token.SetIssuedAt(time.Now())
-	token.SetNotBefore(time.Now())
-	token.SetExpiration(time.Now().Add(x.accessDur))
-	return fromString(token.V4Encrypt(x.symmetricKey, nil)), nil
+	return x.encrypt(token), nil
 }
 
-func (x *PasetoMaker) MakeRefreshToken(identifier any, strategy gen.Strategy) (SafeString, error) {
-	token := paseto.NewToken()
-	switch strategy {
-	case gen.Strategy_TypeCredentials:
-		s, ok := identifier.(string)
-		if !ok {
-			return "", fmt.Errorf("token: expected identifier to be string, got %T", identifier)
-		}
-		if err := token.Set(PasetoStrategyKey, gen.Strategy_TypeCredentials); err != nil {
-			return "", err
-		}
-		if err := token.Set(PasetoIdentifierKey, s); err != nil {
-			return "", err
-		}
-	case gen.Strategy_TypePersonalNumber:
-		d, ok := identifier.(uint64)
-		if !ok {
-			return "", fmt.Errorf("token: expected identifier to be uint64, got %T", identifier)
-		}
-		if err := token.Set(PasetoStrategyKey, gen.Strategy_TypePersonalNumber); err != nil {
-			return "", err
-		}
-		if err := token.Set(PasetoIdentifierKey, d); err != nil {
-			return "", err
-		}
-	default:
-		return "", errors.New("unsupported strategy")
-	}
-	if err := token.Set(PasetoTokenTypeKey, PasetoTokenTypeRefresh); err != nil {
+// MakeMFAToken makes a short-lived token proving that the first factor succeeded.
+// It can only be exchanged for access and refresh tokens once the second factor is verified.
+func (x *PasetoMaker) MakeMFAToken(identifier any, strategy gen.Strategy, grant Grant) (SafeString, error) {
+	return x.makeToken(identifier, strategy, PasetoTokenTypeMFAPending, MFATokenDuration, grant)
+}
+
+func (x *PasetoMaker) makeToken(
+	identifier any,
+	strategy gen.Strategy,
+	tokenType string,
+	dur time.Duration,
+	grant Grant,
+) (SafeString, error) {
+	token, err := newPasetoToken(identifier, strategy, tokenType, dur, grant)
+	if err != nil {
 		return "", err
 	}
-	token.SetIssuer(config.ApplicationName)
-	token.SetIssuedAt(time.Now())
-	token.SetNotBefore(time.Now())
-	token.SetExpiration(time.Now().Add(x.refreshDur))
-	return fromString(token.V4Encrypt(x.symmetricKey, nil)), nil
+	return x.encrypt(token), nil
+}
+
+// encrypt encrypts the token with the active key and puts its ID in the footer.
+func (x *PasetoMaker) encrypt(token *paseto.Token) SafeString {
+	id, k := x.keys.current()
+	token.SetFooter(marshalFooter(id))
+	return fromString(token.V4Encrypt(k, nil))
 }
 
 // Parse will parse a Paseto token and return it if it is valid.
 func (x *PasetoMaker) Parse(t string) (*paseto.Token, error) {
-	parsed, err := x.parser.ParseV4Local(x.symmetricKey, t, nil)
+	f, err := x.parser.UnsafeParseFooter(paseto.V4Local, t)
+	if err != nil {
+		return nil, fmt.Errorf("token: parsing footer, %w", err)
+	}
+	id, err := unmarshalFooter(f)
+	if err != nil {
+		return nil, err
+	}
+	k, err := x.keys.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := x.parser.ParseV4Local(k, t, nil)
 	if err != nil {
 		return nil, fmt.Errorf("token: parsing token, %w", err)
 	}
@@ -148,3 +160,66 @@ func (x *PasetoMaker) RefreshTokenExpiration() time.Time {
 func (x *PasetoMaker) AccessTokenExpiration() time.Time {
 	return time.Now().Add(x.accessDur)
 }
+
+// PublicKeys returns no keys, v4.local tokens can only be verified with the symmetric key.
+func (x *PasetoMaker) PublicKeys() []PublicKey {
+	return nil
+}
+
+// newPasetoToken sets the claims shared by all PASETO tokens.
+func newPasetoToken(
+	identifier any,
+	strategy gen.Strategy,
+	tokenType string,
+	dur time.Duration,
+	grant Grant,
+) (*paseto.Token, error) {
+	token := paseto.NewToken()
+	if err := grant.set(&token); err != nil {
+		return nil, err
+	}
+	if grant.TTL > 0 {
+		dur = grant.TTL
+	}
+	// Strategies declare the type of their identifiers when they register,
+	// tokens carry any identifier that survives a round trip through JSON.
+	if strategy == gen.Strategy_TypeNoStrategy {
+		return nil, errors.New("unsupported strategy")
+	}
+	switch identifier.(type) {
+	case string, uint64:
+	default:
+		return nil, fmt.Errorf("token: expected identifier to be string or uint64, got %T", identifier)
+	}
+	if err := token.Set(PasetoStrategyKey, strategy); err != nil {
+		return nil, err
+	}
+	if err := token.Set(PasetoIdentifierKey, identifier); err != nil {
+		return nil, err
+	}
+	if err := token.Set(PasetoTokenTypeKey, tokenType); err != nil {
+		return nil, err
+	}
+	token.SetJti(uuid.New().String())
+	token.SetIssuer(config.ApplicationName)
+	token.SetIssuedAt(time.Now())
+	token.SetNotBefore(time.Now())
+	token.SetExpiration(time.Now().Add(dur))
+	return &token, nil
+}
+
+// newPasetoParser returns a parser validating the claims set by [newPasetoToken].
+func newPasetoParser() *paseto.Parser {
+	p := paseto.MakeParser([]paseto.Rule{
+		paseto.IssuedBy(config.ApplicationName),
+		paseto.NotExpired(),
+		validNow,
+	})
+	return &p
+}
+
+// validNow checks the time claims against the time of parsing,
+// [paseto.ValidAt] alone would fix it to when the parser was made.
+func validNow(token paseto.Token) error {
+	return paseto.ValidAt(time.Now())(token)
+}
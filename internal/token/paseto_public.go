@@ -0,0 +1,154 @@
+package token
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/Salam4nder/identity/proto/gen"
+)
+
+var _ Maker = (*PasetoPublicMaker)(nil)
+
+// PasetoPublicMaker makes v4.public PASETO tokens signed with Ed25519,
+// which can be verified by anyone holding one of its [PublicKeys].
+type PasetoPublicMaker struct {
+	accessDur  time.Duration
+	refreshDur time.Duration
+	keys       *keyring[paseto.V4AsymmetricSecretKey]
+	parser     *paseto.Parser
+}
+
+// BootstrapPasetoPublicMaker creates a [PasetoPublicMaker] signing with the 32 byte Ed25519 seed.
+// Tokens signed with any of the previous seeds are accepted, and their keys published, until they retire.
+func BootstrapPasetoPublicMaker(
+	accessDur, refreshDur time.Duration,
+	seed []byte,
+	previous ...PreviousKey,
+) (*PasetoPublicMaker, error) {
+	x := &PasetoPublicMaker{
+		accessDur:  accessDur,
+		refreshDur: refreshDur,
+		keys:       newKeyring[paseto.V4AsymmetricSecretKey](refreshDur),
+		parser:     newPasetoParser(),
+	}
+	if err := addPrevious(x.keys, previous, decodeSeed); err != nil {
+		return nil, err
+	}
+	if err := x.Rotate(seed); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Rotate makes the key of the given seed active for signing tokens. The previously active key
+// keeps being accepted and published until the refresh token lifetime has passed.
+func (x *PasetoPublicMaker) Rotate(seed []byte) error {
+	id, k, err := decodeSeed(seed)
+	if err != nil {
+		return err
+	}
+	x.keys.rotate(id, k)
+	return nil
+}
+
+func decodeSeed(seed []byte) (string, paseto.V4AsymmetricSecretKey, error) {
+	k, err := paseto.NewV4AsymmetricSecretKeyFromSeed(hex.EncodeToString(seed))
+	if err != nil {
+		return "", k, fmt.Errorf("token: creating asymmetric key, %w", err)
+	}
+	return NewPublicKey(k.Public()).ID, k, nil
+}
+
+func (x *PasetoPublicMaker) MakeAccessToken(identifier any, strategy gen.Strategy, grant Grant) (SafeString, error) {
+	return x.makeToken(identifier, strategy, PasetoTokenTypeAccess, x.accessDur, grant)
+}
+
+// MakeRefreshToken makes a refresh token carrying the given [RefreshID].
+func (x *PasetoPublicMaker) MakeRefreshToken(
+	identifier any,
+	strategy gen.Strategy,
+	id RefreshID,
+	grant Grant,
+) (SafeString, error) {
+	token, err := newPasetoToken(identifier, strategy, PasetoTokenTypeRefresh, x.refreshDur, grant)
+	if err != nil {
+		return "", err
+	}
+	if err = id.set(token); err != nil {
+		return "", err
+	}
+	return x.sign(token), nil
+}
+
+// MakeMFAToken makes a short-lived token proving that the first factor succeeded.
+// It can only be exchanged for access and refresh tokens once the second factor is verified.
+func (x *PasetoPublicMaker) MakeMFAToken(identifier any, strategy gen.Strategy, grant Grant) (SafeString, error) {
+	return x.makeToken(identifier, strategy, PasetoTokenTypeMFAPending, MFATokenDuration, grant)
+}
+
+func (x *PasetoPublicMaker) makeToken(
+	identifier any,
+	strategy gen.Strategy,
+	tokenType string,
+	dur time.Duration,
+	grant Grant,
+) (SafeString, error) {
+	token, err := newPasetoToken(identifier, strategy, tokenType, dur, grant)
+	if err != nil {
+		return "", err
+	}
+	return x.sign(token), nil
+}
+
+// sign signs the token with the active key and puts its ID in the footer.
+func (x *PasetoPublicMaker) sign(token *paseto.Token) SafeString {
+	id, k := x.keys.current()
+	token.SetFooter(marshalFooter(id))
+	return fromString(token.V4Sign(k, nil))
+}
+
+// Parse will parse a Paseto token and return it if it is valid.
+func (x *PasetoPublicMaker) Parse(t string) (*paseto.Token, error) {
+	f, err := x.parser.UnsafeParseFooter(paseto.V4Public, t)
+	if err != nil {
+		return nil, fmt.Errorf("token: parsing footer, %w", err)
+	}
+	id, err := unmarshalFooter(f)
+	if err != nil {
+		return nil, err
+	}
+	k, err := x.keys.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := x.parser.ParseV4Public(k.Public(), t, nil)
+	if err != nil {
+		return nil, fmt.Errorf("token: parsing token, %w", err)
+	}
+	return parsed, nil
+}
+
+func (x *PasetoPublicMaker) RefreshTokenExpiration() time.Time {
+	return time.Now().Add(x.refreshDur)
+}
+
+func (x *PasetoPublicMaker) AccessTokenExpiration() time.Time {
+	return time.Now().Add(x.accessDur)
+}
+
+// PublicKeys returns the keys verifying the tokens of this maker, including
+// previous keys that are not retired yet.
+func (x *PasetoPublicMaker) PublicKeys() []PublicKey {
+	secrets := x.keys.list()
+	keys := make([]PublicKey, 0, len(secrets))
+	for _, k := range secrets {
+		keys = append(keys, NewPublicKey(k.Public()))
+	}
+	slices.SortFunc(keys, func(a, b PublicKey) int { return strings.Compare(a.ID, b.ID) })
+	return keys
+}
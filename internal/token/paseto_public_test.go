@@ -0,0 +1,150 @@
+package token
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/Salam4nder/identity/pkg/random"
+	"github.com/Salam4nder/identity/proto/gen"
+)
+
+func bootstrapPublic(t *testing.T) *PasetoPublicMaker {
+	t.Helper()
+
+	t.Run("invalid seed", func(t *testing.T) {
+		if _, err := BootstrapPasetoPublicMaker(time.Second*10, time.Minute, bytes.Repeat([]byte{'s'}, 31)); err == nil {
+			t.Error("expected err with invalid seed")
+		}
+	})
+
+	maker, err := BootstrapPasetoPublicMaker(time.Second*10, time.Minute, bytes.Repeat([]byte{'s'}, 32))
+	if err != nil {
+		t.Fatalf("expected no err, got %s", err.Error())
+	}
+	return maker
+}
+
+func TestPasetoPublicMaker(t *testing.T) {
+	b := bootstrapPublic(t)
+
+	t.Run("OK", func(t *testing.T) {
+		e := random.Email()
+		s, err := b.MakeAccessToken(e, gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		if !strings.HasPrefix(string(s), "v4.public.") {
+			t.Error("expected v4.public token")
+		}
+
+		tt, err := b.Parse(string(s))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		var i string
+		if err = tt.Get(PasetoIdentifierKey, &i); err != nil {
+			t.Error("expected no error")
+		}
+		if i != e {
+			t.Error("wrong identifier")
+		}
+	})
+
+	t.Run("verifies with published key", func(t *testing.T) {
+		s, err := b.MakeRefreshToken(random.Email(), gen.Strategy_TypeCredentials, NewRefreshID(), Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+
+		keys := b.PublicKeys()
+		if len(keys) != 1 {
+			t.Fatalf("expected 1 key, got %d", len(keys))
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(keys[0].PASERK, paserkPublicHeader))
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		k, err := paseto.NewV4AsymmetricPublicKeyFromBytes(raw)
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		if _, err = newPasetoParser().ParseV4Public(k, string(s), nil); err != nil {
+			t.Errorf("expected no error, got %s", err.Error())
+		}
+	})
+
+	t.Run("other key returns error", func(t *testing.T) {
+		other, err := BootstrapPasetoPublicMaker(time.Second*10, time.Minute, bytes.Repeat([]byte{'o'}, 32))
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		s, err := other.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		if _, err = b.Parse(string(s)); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("local token returns error", func(t *testing.T) {
+		s, err := bootstrap(t).MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		if _, err = b.Parse(string(s)); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestPublicKey(t *testing.T) {
+	b := bootstrapPublic(t)
+	_, secret := b.keys.current()
+	k := NewPublicKey(secret.Public())
+
+	if !strings.HasPrefix(k.PASERK, paserkPublicHeader) {
+		t.Errorf("expected %s prefix, got %s", paserkPublicHeader, k.PASERK)
+	}
+	if !strings.HasPrefix(k.ID, paserkPIDHeader) {
+		t.Errorf("expected %s prefix, got %s", paserkPIDHeader, k.ID)
+	}
+	if len(strings.TrimPrefix(k.ID, paserkPIDHeader)) != base64.RawURLEncoding.EncodedLen(paserkIDSize) {
+		t.Error("unexpected key ID length")
+	}
+	if k != NewPublicKey(secret.Public()) {
+		t.Error("expected key serialization to be deterministic")
+	}
+}
+
+func TestPublicKeysHandler(t *testing.T) {
+	t.Run("public", func(t *testing.T) {
+		b := bootstrapPublic(t)
+		rec := httptest.NewRecorder()
+		PublicKeysHandler(b).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		var body struct {
+			Keys []PublicKey `json:"keys"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal("expected no error")
+		}
+		if len(body.Keys) != 1 || body.Keys[0] != b.PublicKeys()[0] {
+			t.Error("expected published key")
+		}
+	})
+
+	t.Run("local", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		PublicKeysHandler(bootstrap(t)).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if strings.TrimSpace(rec.Body.String()) != `{"keys":[]}` {
+			t.Errorf("expected empty key set, got %s", rec.Body.String())
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Salam4nder/identity/pkg/random"
 	"github.com/Salam4nder/identity/proto/gen"
+	"github.com/google/uuid"
 )
 
 func bootstrap(t *testing.T) *PasetoMaker {
@@ -43,7 +44,7 @@ func bootstrap(t *testing.T) *PasetoMaker {
 
 func TestMakeAccessToken(t *testing.T) {
 	b := bootstrap(t)
-	s, err := b.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials)
+	s, err := b.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
 	if err != nil {
 		t.Error("expected no error")
 	}
@@ -53,7 +54,7 @@ func TestMakeAccessToken(t *testing.T) {
 
 	t.Run("bad identifier", func(t *testing.T) {
 		b := bootstrap(t)
-		s, err := b.MakeAccessToken(100, gen.Strategy_TypeCredentials)
+		s, err := b.MakeAccessToken(100, gen.Strategy_TypeCredentials, Grant{})
 		if err == nil {
 			t.Error("expected error")
 		}
@@ -63,7 +64,7 @@ func TestMakeAccessToken(t *testing.T) {
 	})
 	t.Run("bad strategy", func(t *testing.T) {
 		b := bootstrap(t)
-		s, err := b.MakeAccessToken(100, gen.Strategy_TypeNoStrategy)
+		s, err := b.MakeAccessToken(100, gen.Strategy_TypeNoStrategy, Grant{})
 		if err == nil {
 			t.Error("expected error")
 		}
@@ -71,12 +72,30 @@ func TestMakeAccessToken(t *testing.T) {
 			t.Error("expected empty string")
 		}
 	})
+	t.Run("grant TTL overrides lifetime", func(t *testing.T) {
+		b := bootstrap(t)
+		s, err := b.MakeAccessToken(uuid.NewString(), gen.Strategy_TypeClientCredentials, Grant{TTL: time.Hour})
+		if err != nil {
+			t.Fatalf("expected no err, got %s", err.Error())
+		}
+		parsed, err := b.Parse(string(s))
+		if err != nil {
+			t.Fatalf("expected no err, got %s", err.Error())
+		}
+		exp, err := parsed.GetExpiration()
+		if err != nil {
+			t.Fatalf("expected no err, got %s", err.Error())
+		}
+		if time.Until(exp) < 59*time.Minute {
+			t.Errorf("expected token to expire in an hour, expires at %s", exp)
+		}
+	})
 }
 
 func TestMakeRefreshToken(t *testing.T) {
 	t.Run("OK", func(t *testing.T) {
 		b := bootstrap(t)
-		s, err := b.MakeRefreshToken(random.Email(), gen.Strategy_TypeCredentials)
+		s, err := b.MakeRefreshToken(random.Email(), gen.Strategy_TypeCredentials, NewRefreshID(), Grant{})
 		if err != nil {
 			t.Error("expected no error")
 		}
@@ -84,9 +103,53 @@ func TestMakeRefreshToken(t *testing.T) {
 			t.Error("token is empty")
 		}
 	})
+	t.Run("carries refresh ID", func(t *testing.T) {
+		b := bootstrap(t)
+		id := NewRefreshID()
+		s, err := b.MakeRefreshToken(random.Email(), gen.Strategy_TypeCredentials, id, Grant{})
+		if err != nil {
+			t.Fatal("expected no error")
+		}
+		tt, err := b.Parse(string(s))
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		got, err := RefreshIDFromToken(tt)
+		if err != nil {
+			t.Fatalf("expected no error, got %s", err.Error())
+		}
+		if got != id {
+			t.Errorf("expected %v, got %v", id, got)
+		}
+		if next := id.Next(); next.Family != id.Family || next.ID == id.ID {
+			t.Error("expected next ID in the same family")
+		}
+	})
+	t.Run("access tokens carry unique IDs", func(t *testing.T) {
+		b := bootstrap(t)
+		ids := make(map[uuid.UUID]struct{})
+		for range 2 {
+			s, err := b.MakeAccessToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+			if err != nil {
+				t.Fatal("expected no error")
+			}
+			tt, err := b.Parse(string(s))
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err.Error())
+			}
+			id, err := IDFromToken(tt)
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err.Error())
+			}
+			ids[id] = struct{}{}
+		}
+		if len(ids) != 2 {
+			t.Error("expected two distinct IDs")
+		}
+	})
 	t.Run("bad identifier", func(t *testing.T) {
 		b := bootstrap(t)
-		s, err := b.MakeRefreshToken(100, gen.Strategy_TypeCredentials)
+		s, err := b.MakeRefreshToken(100, gen.Strategy_TypeCredentials, NewRefreshID(), Grant{})
 		if err == nil {
 			t.Error("expected error")
 		}
@@ -96,7 +159,7 @@ func TestMakeRefreshToken(t *testing.T) {
 	})
 	t.Run("bad strategy", func(t *testing.T) {
 		b := bootstrap(t)
-		s, err := b.MakeRefreshToken(100, gen.Strategy_TypeNoStrategy)
+		s, err := b.MakeRefreshToken(100, gen.Strategy_TypeNoStrategy, NewRefreshID(), Grant{})
 		if err == nil {
 			t.Error("expected error")
 		}
@@ -111,7 +174,7 @@ func TestVerify(t *testing.T) {
 		b := bootstrap(t)
 		t.Run("credentials", func(t *testing.T) {
 			e := random.Email()
-			s, err := b.MakeAccessToken(e, gen.Strategy_TypeCredentials)
+			s, err := b.MakeAccessToken(e, gen.Strategy_TypeCredentials, Grant{})
 			if err != nil {
 				t.Error("expected no error")
 			}
@@ -144,7 +207,7 @@ func TestVerify(t *testing.T) {
 				t.Error("expected no error")
 			}
 
-			s, err := b.MakeAccessToken(number, gen.Strategy_TypePersonalNumber)
+			s, err := b.MakeAccessToken(number, gen.Strategy_TypePersonalNumber, Grant{})
 			if err != nil {
 				t.Error("expected no error")
 			}
@@ -180,3 +243,23 @@ func TestVerify(t *testing.T) {
 		}
 	})
 }
+
+func TestMakeMFAToken(t *testing.T) {
+	b := bootstrap(t)
+	s, err := b.MakeMFAToken(random.Email(), gen.Strategy_TypeCredentials, Grant{})
+	if err != nil {
+		t.Error("expected no error")
+	}
+
+	tt, err := b.Parse(string(s))
+	if err != nil {
+		t.Errorf("expected no error, got %s", err.Error())
+	}
+	var tokenType string
+	if err = tt.Get(PasetoTokenTypeKey, &tokenType); err != nil {
+		t.Error("expected no error")
+	}
+	if tokenType != PasetoTokenTypeMFAPending {
+		t.Errorf("expected %s, got %s", PasetoTokenTypeMFAPending, tokenType)
+	}
+}
@@ -0,0 +1,15 @@
+package token
+
+import "github.com/google/uuid"
+
+// NewPasswordResetToken returns a token to reset the password of the user with the given ID.
+// It shares its layout with email verification tokens.
+func NewPasswordResetToken(id string) string {
+	return NewEmailVerificationToken(id)
+}
+
+// ParsePasswordResetToken returns the user ID of a token made by [NewPasswordResetToken].
+func ParsePasswordResetToken(t string) (uuid.UUID, error) {
+	id, _, err := ParseVerificationToken(t)
+	return id, err
+}
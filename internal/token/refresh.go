@@ -0,0 +1,62 @@
+package token
+
+import (
+	"fmt"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+)
+
+// RefreshID identifies a refresh token and the family of refresh tokens
+// it belongs to. Every refresh of a token issues the next one in its family.
+type RefreshID struct {
+	ID     uuid.UUID
+	Family uuid.UUID
+}
+
+// NewRefreshID returns the ID of the first refresh token of a new family.
+func NewRefreshID() RefreshID {
+	return RefreshID{ID: uuid.New(), Family: uuid.New()}
+}
+
+// Next returns the ID of the refresh token that replaces this one.
+func (x RefreshID) Next() RefreshID {
+	return RefreshID{ID: uuid.New(), Family: x.Family}
+}
+
+// IDFromToken reads the ID every parsed token carries in its jti claim.
+func IDFromToken(t *paseto.Token) (uuid.UUID, error) {
+	jti, err := t.GetJti()
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("token: getting token ID, %w", err)
+	}
+	id, err := uuid.Parse(jti)
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("token: parsing token ID, %w", err)
+	}
+	return id, nil
+}
+
+// RefreshIDFromToken reads the [RefreshID] of a parsed refresh token.
+func RefreshIDFromToken(t *paseto.Token) (RefreshID, error) {
+	id, err := IDFromToken(t)
+	if err != nil {
+		return RefreshID{}, err
+	}
+
+	var family string
+	if err = t.Get(PasetoFamilyKey, &family); err != nil {
+		return RefreshID{}, fmt.Errorf("token: getting token family, %w", err)
+	}
+
+	r := RefreshID{ID: id}
+	if r.Family, err = uuid.Parse(family); err != nil {
+		return RefreshID{}, fmt.Errorf("token: parsing token family, %w", err)
+	}
+	return r, nil
+}
+
+func (x RefreshID) set(t *paseto.Token) error {
+	t.SetJti(x.ID.String())
+	return t.Set(PasetoFamilyKey, x.Family.String())
+}
@@ -28,9 +28,12 @@ func fromString(s string) SafeString {
 
 // Maker is an abstract interface for making and verifying access and refresh tokens.
 type Maker interface {
-	MakeAccessToken(identifier any, strategy gen.Strategy) (SafeString, error)
-	MakeRefreshToken(identifier any, strategy gen.Strategy) (SafeString, error)
+	MakeAccessToken(identifier any, strategy gen.Strategy, grant Grant) (SafeString, error)
+	MakeRefreshToken(identifier any, strategy gen.Strategy, id RefreshID, grant Grant) (SafeString, error)
+	MakeMFAToken(identifier any, strategy gen.Strategy, grant Grant) (SafeString, error)
 	Parse(token string) (*paseto.Token, error)
 	RefreshTokenExpiration() time.Time
 	AccessTokenExpiration() time.Time
+	// PublicKeys returns the keys other services can verify tokens with, if any.
+	PublicKeys() []PublicKey
 }
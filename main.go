@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
@@ -12,8 +13,15 @@ import (
 	"syscall"
 	"time"
 
+	_ "github.com/Salam4nder/identity/internal/auth/strategy"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 
+	"github.com/Salam4nder/identity/internal/auth/exchange"
+	"github.com/Salam4nder/identity/internal/auth/grant"
+	"github.com/Salam4nder/identity/internal/auth/lockout"
+	"github.com/Salam4nder/identity/internal/auth/mfa"
+	"github.com/Salam4nder/identity/internal/auth/refresh"
+	"github.com/Salam4nder/identity/internal/auth/revocation"
 	"github.com/Salam4nder/identity/internal/config"
 	"github.com/Salam4nder/identity/internal/database"
 	"github.com/Salam4nder/identity/internal/database/migrations"
@@ -23,8 +31,10 @@ import (
 	"github.com/Salam4nder/identity/internal/grpc/server"
 	"github.com/Salam4nder/identity/internal/observability/metrics"
 	"github.com/Salam4nder/identity/internal/observability/otel"
+	"github.com/Salam4nder/identity/internal/oidc"
 	"github.com/Salam4nder/identity/internal/token"
 	"github.com/Salam4nder/identity/pkg/logger"
+	"github.com/Salam4nder/identity/pkg/password"
 	"github.com/Salam4nder/identity/proto/gen"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
@@ -51,6 +61,8 @@ const (
 	refreshTokenDuration = 7 * 24 * time.Hour
 	migrationFolder      = "db/migrations"
 	natsTimeout          = 5 * time.Second
+	// purgeInterval is how often expired revoked token IDs and refresh tokens are deleted.
+	purgeInterval = time.Hour
 )
 
 // serviceID is the unique identifier of the service.
@@ -113,15 +125,36 @@ func main() {
 	// Worker.
 	go event.NewWorker(email.NewNoOpSender()).Work(ctx, natsChan)
 
+	hasher, err := newPasswordHasher(cfg.Password)
+	exitOnError(ctx, err)
+	password.SetHasher(hasher)
+
 	// Token maker.
-	tokenMaker, err := token.BootstrapPasetoMaker(
-		accessTokenDuration,
-		refreshTokenDuration,
-		[]byte(cfg.SymmetricKey))
+	tokenMaker, err := newTokenMaker(cfg)
+	if err != nil {
+		exitOnError(ctx, err)
+	}
+
+	totp, err := mfa.NewTOTP(psqlDB, []byte(cfg.EncryptionKey))
+	if err != nil {
+		exitOnError(ctx, err)
+	}
+	totp.UseLockout(lockout.FromConfig(psqlDB, cfg.Lockout))
+
+	policy, err := grant.NewConfigPolicy(cfg.Scopes)
+	if err != nil {
+		exitOnError(ctx, err)
+	}
+
+	exchanges, err := exchange.NewPolicies(cfg.TokenExchange)
 	if err != nil {
 		exitOnError(ctx, err)
 	}
 
+	revoked := revocation.NewStore(psqlDB)
+	go revoked.PurgeEvery(ctx, purgeInterval)
+	go refresh.NewFamilies(psqlDB, natsClient).PurgeEvery(ctx, purgeInterval)
+
 	grpcListener, err := net.Listen("tcp", cfg.Server.GRPCAddr())
 	exitOnError(ctx, err)
 	grpcServer := grpc.NewServer(
@@ -134,10 +167,15 @@ func main() {
 	healthServer := health.NewServer()
 	healthgen.RegisterHealthServer(grpcServer, healthServer)
 	srv := server.NewIdentity(
+		cfg,
 		psqlDB,
 		healthServer,
 		natsClient,
 		tokenMaker,
+		totp,
+		revoked,
+		policy,
+		exchanges,
 	)
 	if err = srv.MountStrategies(cfg.Strategies...); err != nil {
 		exitOnError(ctx, err)
@@ -153,10 +191,44 @@ func main() {
 	}()
 	slog.InfoContext(ctx, "main: gRPC server is running", "address", cfg.Server.GRPCAddr())
 
+	var oidcSrv *http.Server
+	if cfg.OIDC.Addr != "" {
+		signer, err := newIDTokenSigner(cfg, tokenMaker)
+		if err != nil {
+			exitOnError(ctx, err)
+		}
+		provider, err := oidc.New(
+			cfg.OIDC,
+			oidc.NewLogins(srv.Strategies()),
+			tokenMaker,
+			signer,
+			policy,
+			revoked,
+			oidc.NewPostgresCodes(psqlDB),
+		)
+		if err != nil {
+			exitOnError(ctx, err)
+		}
+		provider.UseMFA(totp)
+		oidcSrv = &http.Server{
+			Addr:        cfg.OIDC.Addr,
+			Handler:     provider.Handler(),
+			ReadTimeout: time.Second * 10,
+		}
+		go func() {
+			srvErrChan <- oidcSrv.ListenAndServe()
+		}()
+		slog.InfoContext(ctx, "main: OIDC provider is running", "address", cfg.OIDC.Addr, "issuer", cfg.OIDC.Issuer)
+	}
+
 	if err = metrics.Register(); err != nil {
 		exitOnError(ctx, err)
 	}
 	http.Handle("/metrics", promhttp.Handler())
+	http.Handle("/.well-known/paserk", token.PublicKeysHandler(tokenMaker))
+	if j, ok := tokenMaker.(*token.JWTMaker); ok {
+		http.Handle("/.well-known/jwks.json", token.JWKSHandler(j))
+	}
 	promSrv := http.Server{
 		Addr:        "0.0.0.0:8090",
 		ReadTimeout: time.Second * 10,
@@ -173,6 +245,9 @@ func main() {
 		slog.InfoContext(ctx, "main: context done, shutting down...")
 	}
 	grpcServer.GracefulStop()
+	if oidcSrv != nil {
+		err = errors.Join(err, oidcSrv.Shutdown(context.Background()))
+	}
 	err = errors.Join(err, psqlDB.Close())
 	err = errors.Join(err, userSub.Unsubscribe())
 	natsClient.Close()
@@ -182,6 +257,106 @@ func main() {
 	}
 }
 
+// newPasswordHasher returns the hasher of new passwords of the configured algorithm.
+func newPasswordHasher(cfg config.Password) (password.Hasher, error) {
+	switch cfg.Hasher {
+	case "argon2id", "":
+		params := password.DefaultArgon2idParams
+		if cfg.Argon2id.Memory > 0 {
+			params.Memory = cfg.Argon2id.Memory
+		}
+		if cfg.Argon2id.Iterations > 0 {
+			params.Iterations = cfg.Argon2id.Iterations
+		}
+		if cfg.Argon2id.Parallelism > 0 {
+			params.Parallelism = cfg.Argon2id.Parallelism
+		}
+		return password.NewArgon2id(params), nil
+	case "bcrypt":
+		cost := password.DefaultBcryptCost
+		if cfg.BcryptCost > 0 {
+			cost = cfg.BcryptCost
+		}
+		return password.NewBcrypt(cost), nil
+	default:
+		return nil, fmt.Errorf("main: unknown password hasher %q", cfg.Hasher)
+	}
+}
+
+// newTokenMaker returns the token maker of the configured format.
+func newTokenMaker(cfg *config.Application) (token.Maker, error) {
+	switch cfg.TokenFormat {
+	case "jwt":
+		return newJWTMaker(cfg)
+	case "paseto", "":
+		return newPasetoMaker(cfg)
+	default:
+		return nil, fmt.Errorf("main: unknown token format %q", cfg.TokenFormat)
+	}
+}
+
+// newPasetoMaker returns the PASETO maker of the configured purpose.
+func newPasetoMaker(cfg *config.Application) (token.Maker, error) {
+	switch cfg.TokenPurpose {
+	case "public":
+		return token.BootstrapPasetoPublicMaker(
+			accessTokenDuration,
+			refreshTokenDuration,
+			[]byte(cfg.AsymmetricKey),
+			previousKeys(cfg.PreviousAsymmetricKeys)...)
+	case "local", "":
+		return token.BootstrapPasetoMaker(
+			accessTokenDuration,
+			refreshTokenDuration,
+			[]byte(cfg.SymmetricKey),
+			previousKeys(cfg.PreviousSymmetricKeys)...)
+	default:
+		return nil, fmt.Errorf("main: unknown token purpose %q", cfg.TokenPurpose)
+	}
+}
+
+// newJWTMaker returns a JWT maker signing with the asymmetric key, or the RSA key files for RS256.
+func newJWTMaker(cfg *config.Application) (token.Maker, error) {
+	key, previous := []byte(cfg.AsymmetricKey), previousKeys(cfg.PreviousAsymmetricKeys)
+	if cfg.JWT.Algorithm == token.JWTAlgorithmRS256 {
+		var err error
+		if key, err = os.ReadFile(cfg.JWT.RSAKeyFile); err != nil {
+			return nil, fmt.Errorf("main: reading RSA key, %w", err)
+		}
+		previous = make([]token.PreviousKey, 0, len(cfg.JWT.PreviousRSAKeyFiles))
+		for _, f := range cfg.JWT.PreviousRSAKeyFiles {
+			k, err := os.ReadFile(f.File)
+			if err != nil {
+				return nil, fmt.Errorf("main: reading previous RSA key, %w", err)
+			}
+			previous = append(previous, token.PreviousKey{Key: k, RetireAt: f.RetireAt})
+		}
+	}
+	return token.BootstrapJWTMaker(accessTokenDuration, refreshTokenDuration, cfg.JWT.Algorithm, key, previous...)
+}
+
+// newIDTokenSigner returns the JWT maker signing ID tokens, the token maker itself
+// if it makes JWTs, otherwise an EdDSA maker with the asymmetric key.
+func newIDTokenSigner(cfg *config.Application, tokenMaker token.Maker) (*token.JWTMaker, error) {
+	if j, ok := tokenMaker.(*token.JWTMaker); ok {
+		return j, nil
+	}
+	return token.BootstrapJWTMaker(
+		accessTokenDuration,
+		refreshTokenDuration,
+		token.JWTAlgorithmEdDSA,
+		[]byte(cfg.AsymmetricKey),
+		previousKeys(cfg.PreviousAsymmetricKeys)...)
+}
+
+func previousKeys(keys []config.PreviousKey) []token.PreviousKey {
+	previous := make([]token.PreviousKey, 0, len(keys))
+	for _, k := range keys {
+		previous = append(previous, token.PreviousKey{Key: []byte(k.Key), RetireAt: k.RetireAt})
+	}
+	return previous
+}
+
 func exitOnError(ctx context.Context, err error) {
 	if err != nil {
 		slog.ErrorContext(ctx, "main: exit on error", "error", err)
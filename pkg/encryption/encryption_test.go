@@ -0,0 +1,52 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), KeySize)
+	plaintext := []byte("JBSWY3DPEHPK3PXP")
+
+	t.Run("OK", func(t *testing.T) {
+		ciphertext, err := Seal(key, plaintext)
+		if err != nil {
+			t.Errorf("expected no error, got %s", err.Error())
+		}
+		if bytes.Contains(ciphertext, plaintext) {
+			t.Error("ciphertext contains plaintext")
+		}
+
+		got, err := Open(key, ciphertext)
+		if err != nil {
+			t.Errorf("expected no error, got %s", err.Error())
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Error("expected plaintext to round trip")
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		ciphertext, err := Seal(key, plaintext)
+		if err != nil {
+			t.Error("expected no error")
+		}
+		if _, err := Open(bytes.Repeat([]byte("x"), KeySize), ciphertext); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("invalid key size", func(t *testing.T) {
+		if _, err := Seal([]byte("short"), plaintext); !errors.Is(err, ErrInvalidKey) {
+			t.Error("expected ErrInvalidKey")
+		}
+	})
+
+	t.Run("short ciphertext", func(t *testing.T) {
+		if _, err := Open(key, []byte("ass")); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBcryptCost is the cost of new bcrypt hashes.
+const DefaultBcryptCost = bcrypt.DefaultCost
+
+// bcryptMaxBytes is the length bcrypt truncates passwords at.
+const bcryptMaxBytes = 72
+
+// Bcrypt hashes passwords with bcrypt, whose modular crypt format
+// such as $2a$10$<salt and hash> identifies its cost as well.
+// It is kept to compare hashes made before argon2id was the default.
+type Bcrypt struct {
+	cost int
+}
+
+// NewBcrypt returns a [Bcrypt] hasher using the given cost for new hashes.
+func NewBcrypt(cost int) *Bcrypt {
+	return &Bcrypt{cost: cost}
+}
+
+// MaxBytes returns the length of the longest password bcrypt hashes without truncating it.
+func (x *Bcrypt) MaxBytes() int {
+	return bcryptMaxBytes
+}
+
+// Hash returns the bcrypt hash of the password.
+// Returns [TooLongError] for passwords bcrypt would truncate.
+func (x *Bcrypt) Hash(password string) (string, error) {
+	if len(password) > bcryptMaxBytes {
+		return "", TooLongError{}
+	}
+	b, err := bcrypt.GenerateFromPassword([]byte(password), x.cost)
+	if err != nil {
+		return "", fmt.Errorf("password: generating bcrypt hash, %w", err)
+	}
+	return string(b), nil
+}
+
+// Compare the password with a bcrypt hash.
+func (x *Bcrypt) Compare(encoded, password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatchedHashAndPassword
+		}
+		return fmt.Errorf("%w, %w", ErrMalformedHash, err)
+	}
+	return nil
+}
+
+// Recognizes reports whether the hash is in one of the bcrypt formats.
+func (x *Bcrypt) Recognizes(encoded string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(encoded, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsRehash reports whether the hash was made with another cost.
+func (x *Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != x.cost
+}
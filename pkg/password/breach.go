@@ -0,0 +1,108 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1" // nolint:gosec
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bits-and-blooms/bloom/v3"
+)
+
+// breachesMagic starts files written by [Breaches.WriteTo].
+const breachesMagic = "identity-breaches-v1\n"
+
+var ErrNotBreaches = errors.New("password: not a breaches file")
+
+// BreachChecker reports whether a password appeared in known data breaches.
+type BreachChecker interface {
+	Contains(password string) bool
+}
+
+// Breaches is a compact set of the SHA-1 hashes of breached passwords, as Have I Been Pwned publishes them.
+// It is a bloom filter, so passwords are falsely reported as breached at the rate it was built for,
+// but breached ones are never missed. Build it with the breaches command and load it with [LoadBreaches].
+type Breaches struct {
+	filter *bloom.BloomFilter
+}
+
+// NewBreaches returns an empty set sized for n hashes with the given false positive rate.
+func NewBreaches(n uint, falsePositiveRate float64) *Breaches {
+	return &Breaches{filter: bloom.NewWithEstimates(n, falsePositiveRate)}
+}
+
+// Add the SHA-1 hash of a breached password.
+func (x *Breaches) Add(sum [sha1.Size]byte) {
+	x.filter.Add(sum[:])
+}
+
+// Contains reports whether the password is probably breached.
+func (x *Breaches) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password)) // nolint:gosec
+	return x.filter.Test(sum[:])
+}
+
+// WriteTo writes the set in a format [ReadBreaches] reads.
+func (x *Breaches) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, breachesMagic)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := x.filter.WriteTo(w)
+	return int64(n) + m, err
+}
+
+// ReadBreaches reads a set written by [Breaches.WriteTo].
+// Returns [ErrNotBreaches] if r does not start like one.
+func ReadBreaches(r io.Reader) (*Breaches, error) {
+	magic := make([]byte, len(breachesMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != breachesMagic {
+		return nil, ErrNotBreaches
+	}
+
+	x := &Breaches{filter: &bloom.BloomFilter{}}
+	if _, err := x.filter.ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("password: reading breaches, %w", err)
+	}
+	return x, nil
+}
+
+// LoadBreaches reads the set in the file at path.
+func LoadBreaches(path string) (*Breaches, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("password: opening breaches, %w", err)
+	}
+	defer f.Close()
+
+	return ReadBreaches(bufio.NewReader(f))
+}
+
+// ParseBreachLine parses a line of the Pwned Passwords SHA-1 downloads of Have I Been Pwned,
+// a hex encoded hash and how often it was seen separated by a colon, such as
+// 000000005AD76BD555C1D6D771DE417A4B87E4B4:10. The count is 1 if the line has none.
+func ParseBreachLine(line string) ([sha1.Size]byte, int, error) {
+	var sum [sha1.Size]byte
+
+	hash, count, found := strings.Cut(strings.TrimSpace(line), ":")
+	if len(hash) != hex.EncodedLen(sha1.Size) {
+		return sum, 0, fmt.Errorf("password: parsing breach line, hash is %d characters long", len(hash))
+	}
+	if _, err := hex.Decode(sum[:], []byte(hash)); err != nil {
+		return sum, 0, fmt.Errorf("password: parsing breach line, %w", err)
+	}
+
+	if !found {
+		return sum, 1, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return sum, 0, fmt.Errorf("password: parsing breach count, %w", err)
+	}
+	return sum, n, nil
+}
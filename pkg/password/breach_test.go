@@ -0,0 +1,74 @@
+package password
+
+import (
+	"bytes"
+	"crypto/sha1" // nolint:gosec
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBreaches(t *testing.T) {
+	b := NewBreaches(100, 0.001)
+	for _, s := range []string{"P@ssw0rd123", "Summer2024!"} {
+		b.Add(sha1.Sum([]byte(s))) // nolint:gosec
+	}
+
+	t.Run("contains", func(t *testing.T) {
+		if !b.Contains("P@ssw0rd123") {
+			t.Error("expected breached password to be contained")
+		}
+		if b.Contains("myC00lp4zzW0rd") {
+			t.Error("expected password not to be contained")
+		}
+	})
+
+	t.Run("write and read", func(t *testing.T) {
+		var buf bytes.Buffer
+		if _, err := b.WriteTo(&buf); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		read, err := ReadBreaches(&buf)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !read.Contains("Summer2024!") {
+			t.Error("expected breached password to be contained")
+		}
+	})
+
+	t.Run("not a breaches file", func(t *testing.T) {
+		if _, err := ReadBreaches(strings.NewReader("hello")); !errors.Is(err, ErrNotBreaches) {
+			t.Errorf("expected ErrNotBreaches, got %v", err)
+		}
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		_, err := Policy{Breaches: b}.New("P@ssw0rd123")
+		if !equalRules(rules(err), []Rule{RuleBreached}) {
+			t.Errorf("expected breached violation, got %v", err)
+		}
+	})
+}
+
+func TestParseBreachLine(t *testing.T) {
+	want := sha1.Sum([]byte("password")) // nolint:gosec
+
+	sum, n, err := ParseBreachLine("5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sum != want || n != 9545824 {
+		t.Errorf("unexpected hash %x or count %d", sum, n)
+	}
+
+	if _, n, err = ParseBreachLine("5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8"); err != nil || n != 1 {
+		t.Errorf("expected a count of 1 and no error, got %d and %v", n, err)
+	}
+
+	for _, line := range []string{"", "5BAA61E4:1", "ZZAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:1", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:x"} {
+		if _, _, err := ParseBreachLine(line); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package password
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrMismatchedHashAndPassword = errors.New("password: hash and password do not match")
+	ErrUnknownHash               = errors.New("password: hash of an unknown algorithm")
+	ErrMalformedHash             = errors.New("password: malformed hash")
+)
+
+// Hasher hashes passwords into encoded strings that identify the algorithm
+// and the parameters they were made with, so that they can be compared later
+// regardless of what hashes new passwords.
+type Hasher interface {
+	// Hash returns the encoded hash of the password.
+	Hash(password string) (string, error)
+	// Compare returns [ErrMismatchedHashAndPassword] if the password does not match the encoded hash.
+	Compare(encoded, password string) error
+	// Recognizes reports whether the encoded hash was made with the algorithm of the hasher.
+	Recognizes(encoded string) bool
+	// NeedsRehash reports whether a recognized hash was made with other parameters than the hasher uses.
+	NeedsRehash(encoded string) bool
+}
+
+// current hashes new passwords, see [SetHasher].
+var current Hasher = NewArgon2id(DefaultArgon2idParams)
+
+// known compare hashes current does not recognize.
+var known = []Hasher{NewArgon2id(DefaultArgon2idParams), NewBcrypt(DefaultBcryptCost)}
+
+// dummy is a hash of the current hasher no password matches, see [CompareDummy].
+var dummy = newDummy()
+
+// SetHasher replaces the hasher of new passwords, which is argon2id with [DefaultArgon2idParams]
+// by default. Hashes of other algorithms can still be compared and are reported by [NeedsRehash].
+// It is not safe to call while passwords are hashed, call it on startup.
+func SetHasher(h Hasher) {
+	current = h
+	dummy = newDummy()
+}
+
+// hasherMaxBytes returns the most bytes the current hasher hashes, at most [MaxBytes].
+func hasherMaxBytes() int {
+	if h, ok := current.(interface{ MaxBytes() int }); ok {
+		return min(h.MaxBytes(), MaxBytes)
+	}
+	return MaxBytes
+}
+
+// Hash returns the encoded hash of the password, made by the current hasher.
+func Hash(password string) (string, error) {
+	return current.Hash(password)
+}
+
+// Compare returns nil if the password matches the encoded hash, whichever supported algorithm made it.
+// Returns [ErrMismatchedHashAndPassword] if it does not and [ErrUnknownHash] if no hasher recognizes it.
+func Compare(encoded, password string) error {
+	if current.Recognizes(encoded) {
+		return current.Compare(encoded, password)
+	}
+	for _, h := range known {
+		if h.Recognizes(encoded) {
+			return h.Compare(encoded, password)
+		}
+	}
+	return ErrUnknownHash
+}
+
+// NeedsRehash reports whether the encoded hash was made with another algorithm
+// or other parameters than the current hasher uses, and should be replaced after
+// the password is compared successfully.
+func NeedsRehash(encoded string) bool {
+	return !current.Recognizes(encoded) || current.NeedsRehash(encoded)
+}
+
+// CompareDummy compares the password with a hash of the current hasher that no password matches.
+// It takes as long as comparing the hash of a user would, so that callers can not tell
+// from the time a failed sign-in takes whether the user exists.
+func CompareDummy(password string) {
+	h, err := dummy()
+	if err != nil {
+		return
+	}
+	_ = current.Compare(h, password)
+}
+
+func newDummy() func() (string, error) {
+	return sync.OnceValues(func() (string, error) {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return current.Hash(hex.EncodeToString(b))
+	})
+}
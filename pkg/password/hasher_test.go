@@ -0,0 +1,174 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestArgon2id(t *testing.T) {
+	h := NewArgon2id(DefaultArgon2idParams)
+
+	encoded, err := h.Hash("myC00lp4zzW0rd")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	t.Run("PHC format", func(t *testing.T) {
+		if !strings.HasPrefix(encoded, "$argon2id$v=19$m=19456,t=2,p=1$") {
+			t.Errorf("unexpected encoding %s", encoded)
+		}
+		if !h.Recognizes(encoded) {
+			t.Error("expected hash to be recognized")
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		if err := h.Compare(encoded, "myC00lp4zzW0rd"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		if err := h.Compare(encoded, "myC00lp4zzW0rD"); !errors.Is(err, ErrMismatchedHashAndPassword) {
+			t.Errorf("expected ErrMismatchedHashAndPassword, got %v", err)
+		}
+	})
+
+	t.Run("salted", func(t *testing.T) {
+		other, err := h.Hash("myC00lp4zzW0rd")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if other == encoded {
+			t.Error("expected hashes of the same password to differ")
+		}
+	})
+
+	t.Run("needs rehash with other parameters", func(t *testing.T) {
+		if h.NeedsRehash(encoded) {
+			t.Error("expected no rehash with the same parameters")
+		}
+		params := DefaultArgon2idParams
+		params.Iterations++
+		if !NewArgon2id(params).NeedsRehash(encoded) {
+			t.Error("expected rehash with more iterations")
+		}
+	})
+
+	t.Run("compares with the parameters of the hash", func(t *testing.T) {
+		params := DefaultArgon2idParams
+		params.Memory = 8 * 1024
+		if err := NewArgon2id(params).Compare(encoded, "myC00lp4zzW0rd"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, s := range []string{
+			"$argon2id$",
+			"$argon2id$v=19$m=19456,t=2,p=1$salt",
+			"$argon2id$v=18$m=19456,t=2,p=1$c2FsdA$aGFzaA",
+			"$argon2id$v=19$m=x,t=2,p=1$c2FsdA$aGFzaA",
+			"$argon2id$v=19$m=19456,t=2,p=1$!!!$aGFzaA",
+		} {
+			if err := h.Compare(s, "myC00lp4zzW0rd"); !errors.Is(err, ErrMalformedHash) {
+				t.Errorf("expected ErrMalformedHash for %s, got %v", s, err)
+			}
+		}
+	})
+}
+
+func TestBcrypt(t *testing.T) {
+	h := NewBcrypt(4)
+
+	encoded, err := h.Hash("myC00lp4zzW0rd")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !h.Recognizes(encoded) {
+		t.Error("expected hash to be recognized")
+	}
+	if err := h.Compare(encoded, "myC00lp4zzW0rd"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := h.Compare(encoded, "myC00lp4zzW0rD"); !errors.Is(err, ErrMismatchedHashAndPassword) {
+		t.Errorf("expected ErrMismatchedHashAndPassword, got %v", err)
+	}
+	if h.NeedsRehash(encoded) {
+		t.Error("expected no rehash with the same cost")
+	}
+	if !NewBcrypt(5).NeedsRehash(encoded) {
+		t.Error("expected rehash with another cost")
+	}
+	if _, err := h.Hash(strings.Repeat("a", 73)); !errors.As(err, &TooLongError{}) {
+		t.Errorf("expected TooLongError, got %T", err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	legacy, err := NewBcrypt(4).Hash("myC00lp4zzW0rd")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	current, err := Hash("myC00lp4zzW0rd")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	t.Run("legacy bcrypt hash", func(t *testing.T) {
+		if err := Compare(legacy, "myC00lp4zzW0rd"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if err := Compare(legacy, "myC00lp4zzW0rD"); !errors.Is(err, ErrMismatchedHashAndPassword) {
+			t.Errorf("expected ErrMismatchedHashAndPassword, got %v", err)
+		}
+		if !NeedsRehash(legacy) {
+			t.Error("expected bcrypt hash to need a rehash")
+		}
+	})
+
+	t.Run("current hash", func(t *testing.T) {
+		if err := Compare(current, "myC00lp4zzW0rd"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if NeedsRehash(current) {
+			t.Error("expected current hash not to need a rehash")
+		}
+	})
+
+	t.Run("unknown hash", func(t *testing.T) {
+		if err := Compare("$1$salt$hash", "myC00lp4zzW0rd"); !errors.Is(err, ErrUnknownHash) {
+			t.Errorf("expected ErrUnknownHash, got %v", err)
+		}
+	})
+}
+
+func TestValue(t *testing.T) {
+	v, err := SafeString("myC00lp4zzW0rd").Value()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	encoded, ok := v.(string)
+	if !ok {
+		t.Fatalf("expected string, got %T", v)
+	}
+	if err := Compare(encoded, "myC00lp4zzW0rd"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestCompareDummy(t *testing.T) {
+	h, err := dummy()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !current.Recognizes(h) {
+		t.Error("expected dummy hash of the current hasher")
+	}
+	if err := Compare(h, "myC00lp4zzW0rd"); !errors.Is(err, ErrMismatchedHashAndPassword) {
+		t.Errorf("expected ErrMismatchedHashAndPassword, got %v", err)
+	}
+	CompareDummy("myC00lp4zzW0rd")
+}
@@ -5,17 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"regexp"
 	"unicode/utf8"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	// MaxBytes defines the maximum amount of bytes for a valid password.
-	// It should always be 72 to satisfy BCRYPT.
+	// It bounds the work of hashing rather than satisfying an algorithm.
 	// Make sure to compare this with len().
-	MaxBytes = 72
+	MaxBytes = 256
 
 	// MinChars defines the minimum amount of utf8 runes for a valid password.
 	// Make sure to compare this with [utf8.RuneCountInString()].
@@ -24,13 +21,18 @@ const (
 
 // SafeString defines a safe password string.
 // It cannot exceed [MaxBytes] or [MaxChars] and can not fall short of [MinChars].
-// Its [Value()] will hash the underlying string with bcrypt.
+// Its [Value()] will hash the underlying string with the current [Hasher].
 // Its [String()] and [LogValue()] will mask the underlying string.
 type SafeString string
 
-type TooShortError struct{}
+type TooShortError struct {
+	chars int
+}
 
 func (x TooShortError) Error() string {
+	if x.chars > 0 {
+		return fmt.Sprintf("password: must be at least %d characters long", x.chars)
+	}
 	return fmt.Sprintf("password: must be at least %d characters long", MinChars)
 }
 
@@ -46,26 +48,11 @@ func (x TooLongError) Error() string {
 	return fmt.Sprintf("password: must be at most %d bytes long", MaxBytes)
 }
 
-// FromString will attempt to create a [SafeString] from a string.
-// It will make sure the password is at least [MinChars] and at most [MaxBytes] long.
-// Returns either [TooLongError] or [TooShortError] on error.
+// FromString will attempt to create a [SafeString] from a string satisfying [DefaultPolicy].
+// Returns a [PolicyError] on error, which unwraps to [TooLongError] or [TooShortError]
+// for passwords of the wrong length.
 func FromString(s string) (SafeString, error) {
-	if utf8.RuneCountInString(s) < MinChars {
-		return "", TooShortError{}
-	}
-	if len(s) > MaxBytes {
-		return "", TooLongError{displayedForUser: true}
-	}
-
-	// Password must contain at least one uppercase letter,
-	// one lowercase letter and one digit.
-	if !regexp.MustCompile(`[a-z]`).MatchString(s) ||
-		!regexp.MustCompile(`[A-Z]`).MatchString(s) ||
-		!regexp.MustCompile(`[0-9]`).MatchString(s) {
-		return "", errors.New("password: must contain an uppercase and lowercase letter and a digit")
-	}
-
-	return SafeString(s), nil
+	return DefaultPolicy.New(s)
 }
 
 // String will mask the underlying password string.
@@ -99,7 +86,7 @@ func (x *SafeString) Scan(src any) error {
 }
 
 // Value implements the Valuer interface.
-// It will hash the password with BCRYPT.
+// It will hash the password with the current [Hasher], see [SetHasher].
 func (x SafeString) Value() (driver.Value, error) {
 	if x == "" {
 		return nil, errors.New("password is empty")
@@ -111,5 +98,5 @@ func (x SafeString) Value() (driver.Value, error) {
 		return nil, TooLongError{}
 	}
 
-	return bcrypt.GenerateFromPassword([]byte(x), bcrypt.DefaultCost)
+	return Hash(string(x))
 }
@@ -8,10 +8,10 @@ import (
 )
 
 func TestFromString(t *testing.T) {
-	t.Run("String is longer than 73 bytes)", func(t *testing.T) {
-		tooLong := strings.Repeat("a", 73)
-		if len(tooLong) != 73 {
-			t.Error("string is not 73 bytes")
+	t.Run("String is longer than MaxBytes)", func(t *testing.T) {
+		tooLong := strings.Repeat("a", MaxBytes+1)
+		if len(tooLong) != MaxBytes+1 {
+			t.Errorf("string is not %d bytes", MaxBytes+1)
 		}
 		_, err := FromString(tooLong)
 		if !errors.As(err, &TooLongError{}) {
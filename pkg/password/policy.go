@@ -0,0 +1,191 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// Rule names a requirement of a [Policy].
+type Rule string
+
+const (
+	RuleMinLength  Rule = "min_length"
+	RuleMaxLength  Rule = "max_length"
+	RuleUppercase  Rule = "uppercase"
+	RuleLowercase  Rule = "lowercase"
+	RuleDigit      Rule = "digit"
+	RuleSymbol     Rule = "symbol"
+	RuleStrength   Rule = "strength"
+	RuleBannedWord Rule = "banned_word"
+	RuleBreached   Rule = "breached"
+	RuleReused     Rule = "reused"
+)
+
+// minBannedChars is the length below which banned words are ignored,
+// as nearly every password would contain them.
+const minBannedChars = 3
+
+// DefaultPolicy requires [MinChars] characters and an uppercase and lowercase letter and a digit.
+var DefaultPolicy = Policy{
+	MinChars:     MinChars,
+	MaxBytes:     MaxBytes,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+}
+
+// Policy decides which passwords are accepted.
+type Policy struct {
+	// MinChars is the minimum amount of utf8 runes, at least [MinChars].
+	MinChars int
+	// MaxBytes is the maximum amount of bytes, at most [MaxBytes] and what the current hasher hashes,
+	// such as 72 bytes for bcrypt.
+	MaxBytes int
+
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// MinScore is the minimum zxcvbn score from 0, too guessable, to 4, very unguessable.
+	MinScore int
+	// BannedWords may not be contained in passwords regardless of case.
+	BannedWords []string
+	// Breaches reject passwords that appeared in known data breaches if not nil.
+	Breaches BreachChecker
+	// History is how many of the most recent passwords of a user, the current one included,
+	// can not be reused. Zero allows reusing any.
+	History int
+}
+
+// Violation is a rule a password does not satisfy.
+type Violation struct {
+	Rule        Rule
+	Description string
+}
+
+// PolicyError lists the rules of a [Policy] a password violates.
+// It unwraps to [TooShortError] and [TooLongError] for violated length bounds.
+type PolicyError struct {
+	Violations []Violation
+	errs       []error
+}
+
+func (x PolicyError) Error() string {
+	descriptions := make([]string, 0, len(x.Violations))
+	for _, v := range x.Violations {
+		descriptions = append(descriptions, v.Description)
+	}
+	return "password: " + strings.Join(descriptions, ", ")
+}
+
+func (x PolicyError) Unwrap() []error {
+	return x.errs
+}
+
+func (x *PolicyError) add(rule Rule, err error, format string, args ...any) {
+	x.Violations = append(x.Violations, Violation{Rule: rule, Description: fmt.Sprintf(format, args...)})
+	if err != nil {
+		x.errs = append(x.errs, err)
+	}
+}
+
+// New creates a [SafeString] from a string satisfying the policy.
+// Words are banned for this password only, such as the email local part of the user.
+// Returns a [PolicyError] listing every violated rule.
+func (x Policy) New(s string, words ...string) (SafeString, error) {
+	var e PolicyError
+
+	chars := max(x.MinChars, MinChars)
+	if utf8.RuneCountInString(s) < chars {
+		e.add(RuleMinLength, TooShortError{chars: chars}, "must be at least %d characters long", chars)
+	}
+	maxBytes := hasherMaxBytes()
+	if x.MaxBytes > 0 {
+		maxBytes = min(x.MaxBytes, maxBytes)
+	}
+	if len(s) > maxBytes {
+		// Don't score overly long passwords, it gets slow.
+		e.add(RuleMaxLength, TooLongError{displayedForUser: true}, "is too long")
+		return "", e
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	if x.RequireUpper && !upper {
+		e.add(RuleUppercase, nil, "must contain an uppercase letter")
+	}
+	if x.RequireLower && !lower {
+		e.add(RuleLowercase, nil, "must contain a lowercase letter")
+	}
+	if x.RequireDigit && !digit {
+		e.add(RuleDigit, nil, "must contain a digit")
+	}
+	if x.RequireSymbol && !symbol {
+		e.add(RuleSymbol, nil, "must contain a symbol")
+	}
+
+	banned := make([]string, 0, len(x.BannedWords)+len(words))
+	for _, list := range [][]string{x.BannedWords, words} {
+		for _, w := range list {
+			if utf8.RuneCountInString(w) >= minBannedChars {
+				banned = append(banned, strings.ToLower(w))
+			}
+		}
+	}
+	lowered := strings.ToLower(s)
+	for _, w := range banned {
+		if strings.Contains(lowered, w) {
+			e.add(RuleBannedWord, nil, "must not contain %s", w)
+		}
+	}
+
+	if x.Breaches != nil && x.Breaches.Contains(s) {
+		e.add(RuleBreached, nil, "has appeared in a data breach")
+	}
+
+	if x.MinScore > 0 && zxcvbn.PasswordStrength(s, banned).Score < x.MinScore {
+		e.add(RuleStrength, nil, "is too easy to guess")
+	}
+
+	if len(e.Violations) > 0 {
+		return "", e
+	}
+	return SafeString(s), nil
+}
+
+// CheckHistory returns a [PolicyError] if the password matches one of the encoded hashes
+// of the previous passwords of a user, newest first. Only the [Policy.History] newest are compared.
+func (x Policy) CheckHistory(s string, hashes []string) error {
+	if len(hashes) > x.History {
+		hashes = hashes[:x.History]
+	}
+	for _, h := range hashes {
+		err := Compare(h, s)
+		if err == nil {
+			var e PolicyError
+			e.add(RuleReused, nil, "must not be one of the last %d passwords", x.History)
+			return e
+		}
+		if !errors.Is(err, ErrMismatchedHashAndPassword) {
+			return fmt.Errorf("password: comparing previous password, %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func rules(err error) []Rule {
+	var e PolicyError
+	if !errors.As(err, &e) {
+		return nil
+	}
+	rules := make([]Rule, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func equalRules(a, b []Rule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPolicy(t *testing.T) {
+	t.Run("lists every violation", func(t *testing.T) {
+		p := Policy{MinChars: 12, RequireUpper: true, RequireDigit: true, RequireSymbol: true}
+		_, err := p.New("lowercase")
+		want := []Rule{RuleMinLength, RuleUppercase, RuleDigit, RuleSymbol}
+		if got := rules(err); !equalRules(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+		if !errors.As(err, &TooShortError{}) {
+			t.Errorf("expected TooShortError, got %T", err)
+		}
+	})
+
+	t.Run("length bounds", func(t *testing.T) {
+		p := Policy{MinChars: 4, MaxBytes: 16}
+		if _, err := p.New("short12"); !equalRules(rules(err), []Rule{RuleMinLength}) {
+			t.Errorf("expected at least MinChars, got %v", err)
+		}
+		if _, err := p.New(strings.Repeat("a", 17)); !errors.As(err, &TooLongError{}) {
+			t.Errorf("expected TooLongError, got %v", err)
+		}
+		if _, err := p.New(strings.Repeat("a", 16)); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("length capped by the hasher", func(t *testing.T) {
+		prev := current
+		SetHasher(NewBcrypt(4))
+		t.Cleanup(func() { SetHasher(prev) })
+
+		p := Policy{MaxBytes: MaxBytes}
+		if _, err := p.New(strings.Repeat("a", bcryptMaxBytes+1)); !errors.As(err, &TooLongError{}) {
+			t.Errorf("expected TooLongError, got %v", err)
+		}
+		s, err := p.New(strings.Repeat("a", bcryptMaxBytes))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if _, err = Hash(string(s)); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("character classes", func(t *testing.T) {
+		p := Policy{RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+		if _, err := p.New("Ünïcödé-1"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if _, err := p.New("UPPER-CASE-1"); !equalRules(rules(err), []Rule{RuleLowercase}) {
+			t.Errorf("expected lowercase violation, got %v", err)
+		}
+	})
+
+	t.Run("banned words", func(t *testing.T) {
+		p := Policy{BannedWords: []string{"Identity", "ab"}}
+		if _, err := p.New("myIDENTITYpass"); !equalRules(rules(err), []Rule{RuleBannedWord}) {
+			t.Errorf("expected banned word violation, got %v", err)
+		}
+		if _, err := p.New("abstract-thoughts"); err != nil {
+			t.Errorf("expected short banned words to be ignored, got %v", err)
+		}
+		if _, err := p.New("i-am-jane.doe!", "jane.doe"); !equalRules(rules(err), []Rule{RuleBannedWord}) {
+			t.Errorf("expected banned word violation, got %v", err)
+		}
+	})
+
+	t.Run("strength", func(t *testing.T) {
+		p := Policy{MinScore: 3}
+		if _, err := p.New("password1"); !equalRules(rules(err), []Rule{RuleStrength}) {
+			t.Errorf("expected strength violation, got %v", err)
+		}
+		if _, err := p.New("correct horse battery staple"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("default policy", func(t *testing.T) {
+		if _, err := DefaultPolicy.New(strings.Repeat("aB1", 30)); err != nil {
+			t.Errorf("expected passwords longer than 72 bytes, got %v", err)
+		}
+	})
+}
+
+func TestCheckHistory(t *testing.T) {
+	h := NewBcrypt(4)
+	var hashes []string
+	for _, s := range []string{"newest-Passw0rd", "older-Passw0rd", "oldest-Passw0rd"} {
+		encoded, err := h.Hash(s)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		hashes = append(hashes, encoded)
+	}
+
+	p := Policy{History: 2}
+	if err := p.CheckHistory("older-Passw0rd", hashes); !equalRules(rules(err), []Rule{RuleReused}) {
+		t.Errorf("expected reuse violation, got %v", err)
+	}
+	if err := p.CheckHistory("oldest-Passw0rd", hashes); err != nil {
+		t.Errorf("expected passwords beyond the history to be allowed, got %v", err)
+	}
+	if err := (Policy{}).CheckHistory("newest-Passw0rd", hashes); err != nil {
+		t.Errorf("expected no history to allow reuse, got %v", err)
+	}
+}
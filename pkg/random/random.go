@@ -2,6 +2,7 @@ package random
 
 import (
 	crypto "crypto/rand"
+	"encoding/base64"
 	"math/big"
 	"math/rand/v2"
 	"strings"
@@ -62,3 +63,13 @@ func UINT64() (uint64, error) {
 
 	return result, nil
 }
+
+// Token returns a cryptographically secure, URL safe token
+// encoding size random bytes.
+func Token(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := crypto.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -58,3 +58,17 @@ func TestDate(t *testing.T) {
 		assert.IsType(t, res, time.Time{})
 	}
 }
+
+func TestToken(t *testing.T) {
+	seen := make(map[string]struct{})
+	for i := 0; i < 20; i++ {
+		res, err := Token(32)
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 43)
+		assert.NotContains(t, res, "/")
+		assert.NotContains(t, res, "+")
+		assert.NotContains(t, seen, res)
+		seen[res] = struct{}{}
+	}
+}
@@ -0,0 +1,95 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed used by the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// Last 6 digits of the RFC 6238 SHA1 test vectors.
+	for unix, want := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		got, err := Code(rfcSecret, time.Unix(unix, 0))
+		if err != nil {
+			t.Errorf("expected no error, got %s", err.Error())
+		}
+		if got != want {
+			t.Errorf("at %d expected %s, got %s", unix, want, got)
+		}
+	}
+
+	t.Run("invalid secret", func(t *testing.T) {
+		if _, err := Code("not base32!", time.Now()); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err.Error())
+	}
+	now := time.Now()
+
+	t.Run("OK", func(t *testing.T) {
+		code, err := Code(secret, now)
+		if err != nil {
+			t.Error("expected no error")
+		}
+		counter, ok := Validate(secret, code, now)
+		if !ok {
+			t.Error("expected code to be valid")
+		}
+		if counter != Counter(now) {
+			t.Error("wrong counter")
+		}
+	})
+
+	t.Run("previous step is accepted", func(t *testing.T) {
+		code, err := Code(secret, now.Add(-Period))
+		if err != nil {
+			t.Error("expected no error")
+		}
+		if _, ok := Validate(secret, code, now); !ok {
+			t.Error("expected code to be valid")
+		}
+	})
+
+	t.Run("outside of skew is rejected", func(t *testing.T) {
+		code, err := Code(secret, now.Add(-3*Period))
+		if err != nil {
+			t.Error("expected no error")
+		}
+		if _, ok := Validate(secret, code, now); ok {
+			t.Error("expected code to be invalid")
+		}
+	})
+
+	t.Run("wrong length is rejected", func(t *testing.T) {
+		if _, ok := Validate(secret, "12345", now); ok {
+			t.Error("expected code to be invalid")
+		}
+	})
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("identity", "email@email.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/identity:email@email.com?") {
+		t.Errorf("unexpected uri %s", uri)
+	}
+	if !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") {
+		t.Errorf("secret missing from uri %s", uri)
+	}
+}